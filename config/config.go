@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
@@ -14,7 +15,81 @@ type Config struct {
 	DBPass  string `env:"DB_PASS" envDefault:"postgres"`
 	DBName  string `env:"DB_NAME" envDefault:"postgres"`
 	ApiPort string `env:"API_PORT" envDefault:"8080"`
-	JWTSecret string `env:"JWT_SECRET" envDefault:"abcd1234"`
+	// Obligatori amb HS256, d'almenys 32 bytes
+	JWTSecret string `env:"JWT_SECRET"`
+	// Signatura dels JWT: HS256 (secret JWT_SECRET), RS256 o EdDSA (clau
+	// privada PEM a JWT_PRIVATE_KEY_FILE). JWT_VERIFICATION_KEY_FILES són
+	// claus que encara s'accepten per verificar, p.ex. l'anterior en una rotació.
+	JWTAlgorithm string `env:"JWT_ALGORITHM" envDefault:"HS256"`
+	JWTPrivateKeyFile string `env:"JWT_PRIVATE_KEY_FILE"`
+	JWTVerificationKeyFiles []string `env:"JWT_VERIFICATION_KEY_FILES" envSeparator:","`
+	// URL pública del frontend, per construir els enllaços que s'envien als usuaris
+	AppBaseURL string `env:"APP_BASE_URL" envDefault:"http://localhost:5173"`
+	// Notificacions: "log" (log del servidor) o "file" (fitxer NOTIFIER_FILE)
+	Notifier     string `env:"NOTIFIER" envDefault:"log"`
+	NotifierFile string `env:"NOTIFIER_FILE" envDefault:"notifications.log"`
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"30m"`
+	// Secret per signar els enllaços enviats als usuaris (verificació d'email...)
+	// i els reptes de segon factor. Obligatori i diferent de JWT_SECRET.
+	TokenSigningSecret string `env:"TOKEN_SIGNING_SECRET"`
+	EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" envDefault:"24h"`
+	// Els comptes no verificats s'eliminen passat aquest temps
+	UnverifiedAccountTTL time.Duration `env:"UNVERIFIED_ACCOUNT_TTL" envDefault:"168h"`
+	// Registre d'usuaris: "open" (qualsevol a /auth/register), "invite" (només
+	// amb invitació d'un administrador) o "closed"
+	RegistrationMode string `env:"REGISTRATION_MODE" envDefault:"open"`
+	InvitationTTL time.Duration `env:"INVITATION_TTL" envDefault:"72h"`
+	// Login amb OpenID Connect. Es desactiva si OIDC_ISSUER és buit.
+	// OIDC_REDIRECT_URL és la pàgina del frontend que rep el codi i l'envia
+	// a /auth/oidc/callback.
+	OIDCIssuer string `env:"OIDC_ISSUER"`
+	OIDCClientID string `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `env:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL string `env:"OIDC_REDIRECT_URL" envDefault:"http://localhost:5173/oidc/callback"`
+	OIDCScopes []string `env:"OIDC_SCOPES" envDefault:"openid,email,profile" envSeparator:","`
+	// Crear automàticament els usuaris que no existeixen, amb aquest rol
+	OIDCAutoProvision bool `env:"OIDC_AUTO_PROVISION" envDefault:"false"`
+	OIDCDefaultRole string `env:"OIDC_DEFAULT_ROLE" envDefault:"read_only"`
+	// Cost d'Argon2id per als hashos de les contrasenyes. Si es canvia, els
+	// hashos existents es recalculen al següent login de cada usuari.
+	PasswordArgon2Memory uint32 `env:"PASSWORD_ARGON2_MEMORY_KIB" envDefault:"65536"`
+	PasswordArgon2Iterations uint32 `env:"PASSWORD_ARGON2_ITERATIONS" envDefault:"3"`
+	PasswordArgon2Parallelism uint8 `env:"PASSWORD_ARGON2_PARALLELISM" envDefault:"2"`
+	// Política de contrasenyes. PASSWORD_REQUIRED_CLASSES és quants tipus de
+	// caràcters (minúscules, majúscules, dígits, símbols) calen.
+	PasswordMinLength int `env:"PASSWORD_MIN_LENGTH" envDefault:"10"`
+	PasswordRequiredClasses int `env:"PASSWORD_REQUIRED_CLASSES" envDefault:"3"`
+	PasswordHistory int `env:"PASSWORD_HISTORY" envDefault:"5"`
+	// Fitxer amb els SHA-1 de contrasenyes filtrades (format de Have I Been
+	// Pwned). Buit desactiva la comprovació.
+	PasswordBreachedListFile string `env:"PASSWORD_BREACHED_LIST_FILE"`
+	// Durada dels tokens de suplantació (no es poden refrescar)
+	ImpersonationTTL time.Duration `env:"IMPERSONATION_TTL" envDefault:"15m"`
+	// Bloqueig de login després d'intents fallits
+	LoginMaxUserFailures int `env:"LOGIN_MAX_USER_FAILURES" envDefault:"5"`
+	LoginMaxIPFailures int `env:"LOGIN_MAX_IP_FAILURES" envDefault:"20"`
+	LoginFailureWindow time.Duration `env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+	LoginLockoutDuration time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+	// Proxies (IPs o CIDR) dels quals es fia la capçalera X-Forwarded-For.
+	// Buit: l'IP del client és sempre la de la connexió.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+	// Segon factor (TOTP): nom que es mostra a l'aplicació d'autenticació i
+	// permisos que només es concedeixen amb el segon factor verificat (cap per
+	// defecte; p. ex. "stock:write,purchases:receive")
+	MFAIssuer string `env:"MFA_ISSUER" envDefault:"FRDY"`
+	MFARequiredPermissions []string `env:"MFA_REQUIRED_PERMISSIONS" envSeparator:","`
+	// Adjunts dels articles: "local" (directori STORAGE_LOCAL_DIR) o "s3"
+	// (qualsevol servei compatible; amb S3_ENDPOINT buit, AWS a S3_REGION)
+	Storage string `env:"STORAGE" envDefault:"local"`
+	StorageLocalDir string `env:"STORAGE_LOCAL_DIR" envDefault:"uploads"`
+	S3Endpoint string `env:"S3_ENDPOINT"`
+	S3Region string `env:"S3_REGION" envDefault:"us-east-1"`
+	S3Bucket string `env:"S3_BUCKET"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+	S3PathStyle bool `env:"S3_PATH_STYLE" envDefault:"false"`
+	// Mida màxima d'un adjunt, en bytes
+	AttachmentMaxSize int64 `env:"ATTACHMENT_MAX_SIZE" envDefault:"10485760"`
 }
 
 func LoadConfig() (*Config, error) {
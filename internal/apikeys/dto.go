@@ -0,0 +1,15 @@
+package apikeys
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse inclou la clau en clar, que només es mostra en crear-la
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,13 @@
+package apikeys
+
+import "errors"
+
+var (
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrInvalidID          = errors.New("invalid api key id")
+	ErrInvalidAPIKey      = errors.New("invalid or expired api key")
+	ErrInvalidRequest     = errors.New("api key needs a name and at least one permission")
+	ErrInvalidExpiry      = errors.New("api key expiry must be in the future")
+	ErrUnknownPermission  = errors.New("unknown permission")
+	ErrPermissionNotOwned = errors.New("cannot grant a permission you do not have")
+)
@@ -0,0 +1,130 @@
+package apikeys
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	service APIKeyService
+}
+
+func NewAPIKeyHandler(service APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrAPIKeyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidExpiry),
+		errors.Is(err, ErrUnknownPermission):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrPermissionNotOwned):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create godoc
+// @Summary Create an API key
+// @Description Issues an API key scoped to the given permissions. The key is only returned once (Protected route, admin only)
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys [post]
+// @Security BearerAuth
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var request CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.Create(c.Request.Context(), userID, identity.Permissions(c), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// FindAll godoc
+// @Summary Get all API keys
+// @Description Lists API keys, including revoked and expired ones (Protected route, admin only)
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {array} APIKey
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys [get]
+// @Security BearerAuth
+func (h *APIKeyHandler) FindAll(c *gin.Context) {
+	keys, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// FindByID godoc
+// @Summary Get an API key by ID
+// @Description Retrieves an API key without its secret (Protected route, admin only)
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys/{id} [get]
+// @Security BearerAuth
+func (h *APIKeyHandler) FindByID(c *gin.Context) {
+	key, err := h.service.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// Revoke godoc
+// @Summary Revoke an API key
+// @Description Revokes an API key; requests using it are rejected from then on (Protected route, admin only)
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys/{id} [delete]
+// @Security BearerAuth
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
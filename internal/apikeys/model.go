@@ -0,0 +1,31 @@
+package apikeys
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey permet a una integració (botiga en línia, lectors del magatzem...)
+// cridar l'API sense login. Només es guarda el hash de la clau; el prefix
+// serveix per trobar-la i per identificar-la als llistats.
+type APIKey struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Prefix      string     `json:"prefix" db:"prefix"`
+	KeyHash     string     `json:"-" db:"key_hash"`
+	Permissions []string   `json:"permissions" db:"permissions"`
+	CreatedBy   uuid.UUID  `json:"created_by" db:"created_by"`
+	ExpiresAt   *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsActive indica si la clau es pot fer servir
+func (k APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || k.ExpiresAt.After(now)
+}
@@ -0,0 +1,114 @@
+package apikeys
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, name, prefix, key_hash, permissions, created_by, expires_at, last_used_at, revoked_at, created_at`
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key APIKey) (APIKey, error)
+	FindByID(ctx context.Context, id uuid.UUID) (APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	FindAll(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID) error
+}
+
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func apiKeyFields(k *APIKey) []interface{} {
+	return []interface{}{&k.ID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Permissions),
+		&k.CreatedBy, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key APIKey) (APIKey, error) {
+	var created APIKey
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO api_keys (id, name, prefix, key_hash, permissions, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+apiKeyColumns,
+		key.ID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Permissions), key.CreatedBy, key.ExpiresAt,
+	).Scan(apiKeyFields(&created)...)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("error creating api key: %w", err)
+	}
+	return created, nil
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id uuid.UUID) (APIKey, error) {
+	return r.findOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id)
+}
+
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (APIKey, error) {
+	return r.findOne(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix)
+}
+
+func (r *apiKeyRepository) findOne(ctx context.Context, query string, arg interface{}) (APIKey, error) {
+	var key APIKey
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(apiKeyFields(&key)...)
+	if err == sql.ErrNoRows {
+		return APIKey{}, ErrAPIKeyNotFound
+	} else if err != nil {
+		return APIKey{}, fmt.Errorf("error getting api key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		var key APIKey
+		if err := rows.Scan(apiKeyFields(&key)...); err != nil {
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error revoking api key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed actualitza last_used_at com a molt un cop per minut, per no
+// escriure a la base de dades a cada petició.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')`, id)
+	if err != nil {
+		return fmt.Errorf("error updating api key last use: %w", err)
+	}
+	return nil
+}
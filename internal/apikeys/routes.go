@@ -0,0 +1,13 @@
+package apikeys
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *APIKeyHandler) {
+	keys := router.Group("/api-keys")
+	{
+		keys.POST("", handler.Create)
+		keys.GET("", handler.FindAll)
+		keys.GET("/:id", handler.FindByID)
+		keys.DELETE("/:id", handler.Revoke)
+	}
+}
@@ -0,0 +1,160 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/securetoken"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// keyPrefix precedeix totes les claus perquè es puguin reconèixer (i
+// detectar si es filtren a un repositori o a un log).
+const keyPrefix = "frdy"
+
+type APIKeyService interface {
+	// Create genera una clau nova. creatorPermissions són els permisos de qui
+	// la crea: no pot donar a la clau permisos que no té.
+	Create(ctx context.Context, createdBy uuid.UUID, creatorPermissions []string, request CreateAPIKeyRequest) (CreateAPIKeyResponse, error)
+	FindByID(ctx context.Context, id string) (APIKey, error)
+	FindAll(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	// Authenticate retorna la clau si és vàlida, no està revocada i no ha caducat
+	Authenticate(ctx context.Context, rawKey string) (APIKey, error)
+}
+
+type apiKeyService struct {
+	repo     APIKeyRepository
+	roleRepo roles.RoleRepository
+}
+
+func NewAPIKeyService(repo APIKeyRepository, roleRepo roles.RoleRepository) APIKeyService {
+	return &apiKeyService{repo: repo, roleRepo: roleRepo}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, createdBy uuid.UUID, creatorPermissions []string, request CreateAPIKeyRequest) (CreateAPIKeyResponse, error) {
+	if strings.TrimSpace(request.Name) == "" || len(request.Permissions) == 0 {
+		return CreateAPIKeyResponse{}, ErrInvalidRequest
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return CreateAPIKeyResponse{}, ErrInvalidExpiry
+	}
+	if err := s.checkPermissions(ctx, creatorPermissions, request.Permissions); err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+
+	prefix, rawKey, err := generateKey()
+	if err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+	key, err := s.repo.Create(ctx, APIKey{
+		ID:          uuid.New(),
+		Name:        strings.TrimSpace(request.Name),
+		Prefix:      prefix,
+		KeyHash:     securetoken.Hash(rawKey),
+		Permissions: request.Permissions,
+		CreatedBy:   createdBy,
+		ExpiresAt:   request.ExpiresAt,
+	})
+	if err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+	return CreateAPIKeyResponse{APIKey: key, Key: rawKey}, nil
+}
+
+func (s *apiKeyService) FindByID(ctx context.Context, id string) (APIKey, error) {
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return APIKey{}, ErrInvalidID
+	}
+	return s.repo.FindByID(ctx, keyID)
+}
+
+func (s *apiKeyService) FindAll(ctx context.Context) ([]APIKey, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id string) error {
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	return s.repo.Revoke(ctx, keyID)
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (APIKey, error) {
+	prefix, ok := parsePrefix(rawKey)
+	if !ok {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	key, err := s.repo.FindByPrefix(ctx, prefix)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return APIKey{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(securetoken.Hash(rawKey))) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	if !key.IsActive(time.Now()) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	if err := s.repo.TouchLastUsed(ctx, key.ID); err != nil {
+		// No cal rebutjar la petició si només falla el registre de l'ús
+		log.Printf("Error updating api key %s last use: %v", key.Prefix, err)
+	}
+	return key, nil
+}
+
+// checkPermissions comprova que els permisos existeixin i que qui crea la
+// clau els tingui tots.
+func (s *apiKeyService) checkPermissions(ctx context.Context, granted, requested []string) error {
+	known, err := s.roleRepo.FindAllPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	valid := make(map[string]bool, len(known))
+	for _, permission := range known {
+		valid[permission.Code] = true
+	}
+	for _, code := range requested {
+		if !valid[code] {
+			return ErrUnknownPermission
+		}
+		if !roles.HasPermission(granted, code) {
+			return ErrPermissionNotOwned
+		}
+	}
+	return nil
+}
+
+// generateKey retorna una clau amb el format frdy_<prefix>_<secret>
+func generateKey() (string, string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("error generating api key: %w", err)
+	}
+	prefix := hex.EncodeToString(b)
+	secret, err := securetoken.Generate()
+	if err != nil {
+		return "", "", err
+	}
+	return prefix, keyPrefix + "_" + prefix + "_" + secret, nil
+}
+
+func parsePrefix(rawKey string) (string, bool) {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
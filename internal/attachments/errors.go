@@ -0,0 +1,12 @@
+package attachments
+
+import "errors"
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrItemNotFound       = errors.New("item not found")
+	ErrNoThumbnail        = errors.New("this attachment has no thumbnail")
+	ErrInvalidID          = errors.New("invalid ID")
+	ErrEmptyFile          = errors.New("the file is empty")
+	ErrFileTooLarge       = errors.New("the file is too large")
+)
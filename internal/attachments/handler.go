@@ -0,0 +1,167 @@
+package attachments
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachmentHandler struct {
+	service AttachmentService
+}
+
+func NewAttachmentHandler(service AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrAttachmentNotFound), errors.Is(err, ErrItemNotFound), errors.Is(err, ErrNoThumbnail):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrEmptyFile):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Upload godoc
+// @Summary Upload an image or document to an item
+// @Description Stores the file in the configured storage. The type is detected from the content: JPEG, PNG, GIF and WebP files are images and are listed in the item's images (JPEG, PNG and GIF also get a thumbnail); anything else is a document (Protected route)
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param file formData file true "Image or document"
+// @Success 201 {object} Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/attachments [post]
+// @Security BearerAuth
+func (h *AttachmentHandler) Upload(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(c.Request.Context(), c.Param("id"), header.Filename, header.Size, file)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// FindByItemID godoc
+// @Summary List the attachments of an item
+// @Description Retrieves the images and documents of an item, oldest first, with their download URLs (Protected route)
+// @Tags attachments
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {array} Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/attachments [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) FindByItemID(c *gin.Context) {
+	attachments, err := h.service.FindByItemID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// Download godoc
+// @Summary Download an attachment
+// @Description Returns the content of the file. Images are shown inline; documents are downloaded with their original file name (Protected route)
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Item ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/attachments/{attachment_id} [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	h.serve(c, false)
+}
+
+// Thumbnail godoc
+// @Summary Download the thumbnail of an image
+// @Description Returns a reduced version of the image, at most 256 pixels wide or high (Protected route)
+// @Tags attachments
+// @Produce image/jpeg,image/png
+// @Param id path string true "Item ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/attachments/{attachment_id}/thumbnail [get]
+// @Security BearerAuth
+func (h *AttachmentHandler) Thumbnail(c *gin.Context) {
+	h.serve(c, true)
+}
+
+// Delete godoc
+// @Summary Delete an attachment
+// @Description Deletes the attachment and its stored files (Protected route)
+// @Tags attachments
+// @Param id path string true "Item ID"
+// @Param attachment_id path string true "Attachment ID"
+// @Success 204 "Attachment deleted successfully"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/attachments/{attachment_id} [delete]
+// @Security BearerAuth
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), c.Param("attachment_id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *AttachmentHandler) serve(c *gin.Context, thumbnail bool) {
+	attachment, content, err := h.service.Open(c.Request.Context(), c.Param("id"), c.Param("attachment_id"), thumbnail)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	// Els documents no s'obren mai al navegador: podrien ser HTML o scripts
+	disposition := "attachment"
+	if attachment.Kind == KindImage {
+		disposition = "inline"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}); value != "" {
+		disposition = value
+	}
+	headers := map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, headers)
+}
@@ -0,0 +1,49 @@
+package attachments
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipus d'adjunt: les imatges tenen miniatura i surten a les respostes dels
+// articles; la resta són documents (fitxes tècniques, certificats...)
+const (
+	KindImage    = "image"
+	KindDocument = "document"
+)
+
+// Attachment és un fitxer adjunt a un article. El contingut és a
+// l'emmagatzematge configurat; aquí només hi ha les metadades.
+type Attachment struct {
+	ID                   uuid.UUID `json:"id"`
+	ItemID               uuid.UUID `json:"item_id"`
+	Kind                 string    `json:"kind"`
+	FileName             string    `json:"file_name"`
+	ContentType          string    `json:"content_type"`
+	Size                 int64     `json:"size"`
+	StorageKey           string    `json:"-"`
+	ThumbnailKey         string    `json:"-"`
+	ThumbnailContentType string    `json:"-"`
+	URL                  string    `json:"url"`
+	ThumbnailURL         string    `json:"thumbnail_url,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// Image és una imatge d'un article tal com surt a les respostes dels articles
+type Image struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+}
+
+// ContentURL és l'adreça de l'API on es descarrega l'adjunt
+func ContentURL(itemID, id uuid.UUID) string {
+	return fmt.Sprintf("/api/items/%s/attachments/%s", itemID, id)
+}
+
+// ThumbnailURL és l'adreça de l'API on es descarrega la miniatura
+func ThumbnailURL(itemID, id uuid.UUID) string {
+	return ContentURL(itemID, id) + "/thumbnail"
+}
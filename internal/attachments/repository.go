@@ -0,0 +1,97 @@
+package attachments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const attachmentColumns = `id, item_id, kind, file_name, content_type, size, storage_key, COALESCE(thumbnail_key,''), COALESCE(thumbnail_content_type,''), created_at`
+
+type AttachmentRepository interface {
+	ItemExists(ctx context.Context, itemID uuid.UUID) (bool, error)
+	Create(ctx context.Context, attachment Attachment) (Attachment, error)
+	Delete(ctx context.Context, itemID, id uuid.UUID) error
+	FindByID(ctx context.Context, itemID, id uuid.UUID) (Attachment, error)
+	FindByItemID(ctx context.Context, itemID uuid.UUID) ([]Attachment, error)
+}
+
+type attachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func attachmentFields(a *Attachment) []interface{} {
+	return []interface{}{&a.ID, &a.ItemID, &a.Kind, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey, &a.ThumbnailKey, &a.ThumbnailContentType, &a.CreatedAt}
+}
+
+func (r *attachmentRepository) ItemExists(ctx context.Context, itemID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM items WHERE id = $1)`, itemID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking item: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *attachmentRepository) Create(ctx context.Context, a Attachment) (Attachment, error) {
+	var created Attachment
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO item_attachments (id, item_id, kind, file_name, content_type, size, storage_key, thumbnail_key, thumbnail_content_type)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+		RETURNING `+attachmentColumns,
+		a.ID, a.ItemID, a.Kind, a.FileName, a.ContentType, a.Size, a.StorageKey, a.ThumbnailKey, a.ThumbnailContentType,
+	).Scan(attachmentFields(&created)...)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("error creating attachment: %w", err)
+	}
+	return created, nil
+}
+
+func (r *attachmentRepository) Delete(ctx context.Context, itemID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM item_attachments WHERE id = $1 AND item_id = $2`, id, itemID)
+	if err != nil {
+		return fmt.Errorf("error deleting attachment: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+func (r *attachmentRepository) FindByID(ctx context.Context, itemID, id uuid.UUID) (Attachment, error) {
+	var a Attachment
+	err := r.db.QueryRowContext(ctx, `SELECT `+attachmentColumns+` FROM item_attachments WHERE id = $1 AND item_id = $2`, id, itemID).
+		Scan(attachmentFields(&a)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attachment{}, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return Attachment{}, fmt.Errorf("error fetching attachment: %w", err)
+	}
+	return a, nil
+}
+
+func (r *attachmentRepository) FindByItemID(ctx context.Context, itemID uuid.UUID) ([]Attachment, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+attachmentColumns+` FROM item_attachments WHERE item_id = $1 ORDER BY created_at`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(attachmentFields(&a)...); err != nil {
+			return nil, fmt.Errorf("error scanning attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
@@ -0,0 +1,14 @@
+package attachments
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *AttachmentHandler) {
+	attachments := router.Group("/items/:id/attachments")
+	{
+		attachments.POST("", handler.Upload)
+		attachments.GET("", handler.FindByItemID)
+		attachments.GET("/:attachment_id", handler.Download)
+		attachments.GET("/:attachment_id/thumbnail", handler.Thumbnail)
+		attachments.DELETE("/:attachment_id", handler.Delete)
+	}
+}
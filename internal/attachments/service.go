@@ -0,0 +1,233 @@
+package attachments
+
+import (
+	"context"
+	"frdy-api/internal/storage"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// imageTypes són els formats que es tracten com a imatge de l'article
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type AttachmentService interface {
+	// Upload desa el fitxer i, si és una imatge que es pot descodificar, la
+	// seva miniatura
+	Upload(ctx context.Context, itemID, fileName string, size int64, file io.Reader) (Attachment, error)
+	FindByItemID(ctx context.Context, itemID string) ([]Attachment, error)
+	// Open retorna l'adjunt (o la seva miniatura) per descarregar-lo; qui el
+	// crida ha de tancar el contingut
+	Open(ctx context.Context, itemID, id string, thumbnail bool) (Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, itemID, id string) error
+	// ItemFiles retorna els adjunts d'un article sense URLs, per poder-ne
+	// esborrar els fitxers amb RemoveFiles un cop esborrat l'article
+	ItemFiles(ctx context.Context, itemID uuid.UUID) ([]Attachment, error)
+	RemoveFiles(ctx context.Context, attachments []Attachment)
+}
+
+type attachmentService struct {
+	repo    AttachmentRepository
+	storage storage.Storage
+	maxSize int64
+}
+
+func NewAttachmentService(repo AttachmentRepository, storage storage.Storage, maxSize int64) AttachmentService {
+	return &attachmentService{repo: repo, storage: storage, maxSize: maxSize}
+}
+
+func (s *attachmentService) Upload(ctx context.Context, itemID, fileName string, size int64, file io.Reader) (Attachment, error) {
+	parsedItemID, err := s.findItem(ctx, itemID)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if size > s.maxSize {
+		return Attachment{}, ErrFileTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(file, s.maxSize+1))
+	if err != nil {
+		return Attachment{}, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return Attachment{}, ErrFileTooLarge
+	}
+	if len(data) == 0 {
+		return Attachment{}, ErrEmptyFile
+	}
+
+	// El tipus es dedueix del contingut, no del que diu el client
+	id := uuid.New()
+	attachment := Attachment{
+		ID:          id,
+		ItemID:      parsedItemID,
+		Kind:        KindDocument,
+		FileName:    cleanFileName(fileName),
+		ContentType: http.DetectContentType(data),
+		Size:        int64(len(data)),
+		StorageKey:  "items/" + parsedItemID.String() + "/" + id.String(),
+	}
+	if imageTypes[attachment.ContentType] {
+		attachment.Kind = KindImage
+	}
+
+	if err := s.storage.Put(ctx, attachment.StorageKey, attachment.ContentType, data); err != nil {
+		return Attachment{}, err
+	}
+	if attachment.Kind == KindImage {
+		// Sense miniatura (p. ex. WebP) la imatge es desa igualment
+		if thumb, contentType, err := makeThumbnail(data, attachment.ContentType); err == nil {
+			key := attachment.StorageKey + "-thumbnail"
+			if err := s.storage.Put(ctx, key, contentType, thumb); err != nil {
+				s.cleanup(ctx, attachment)
+				return Attachment{}, err
+			}
+			attachment.ThumbnailKey = key
+			attachment.ThumbnailContentType = contentType
+		} else if err != errNotDecodable {
+			log.Printf("Error creating thumbnail for attachment %s: %v", attachment.ID, err)
+		}
+	}
+
+	created, err := s.repo.Create(ctx, attachment)
+	if err != nil {
+		s.cleanup(ctx, attachment)
+		return Attachment{}, err
+	}
+	return withURLs(created), nil
+}
+
+func (s *attachmentService) FindByItemID(ctx context.Context, itemID string) ([]Attachment, error) {
+	parsedItemID, err := s.findItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.FindByItemID(ctx, parsedItemID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		attachments[i] = withURLs(attachments[i])
+	}
+	return attachments, nil
+}
+
+func (s *attachmentService) Open(ctx context.Context, itemID, id string, thumbnail bool) (Attachment, io.ReadCloser, error) {
+	attachment, err := s.find(ctx, itemID, id)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	key := attachment.StorageKey
+	if thumbnail {
+		if attachment.ThumbnailKey == "" {
+			return Attachment{}, nil, ErrNoThumbnail
+		}
+		key = attachment.ThumbnailKey
+		attachment.ContentType = attachment.ThumbnailContentType
+		attachment.Size = -1
+	}
+
+	content, err := s.storage.Get(ctx, key)
+	if err == storage.ErrNotFound {
+		return Attachment{}, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	return withURLs(attachment), content, nil
+}
+
+// Delete esborra l'adjunt. Si el fitxer no es pot esborrar de
+// l'emmagatzematge només queda al log: l'adjunt ja no surt enlloc.
+func (s *attachmentService) Delete(ctx context.Context, itemID, id string) error {
+	attachment, err := s.find(ctx, itemID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, attachment.ItemID, attachment.ID); err != nil {
+		return err
+	}
+	s.cleanup(ctx, attachment)
+	return nil
+}
+
+func (s *attachmentService) ItemFiles(ctx context.Context, itemID uuid.UUID) ([]Attachment, error) {
+	return s.repo.FindByItemID(ctx, itemID)
+}
+
+// RemoveFiles esborra de l'emmagatzematge els fitxers d'adjunts que ja no
+// són a la base de dades, p. ex. perquè s'han esborrat amb el seu article
+func (s *attachmentService) RemoveFiles(ctx context.Context, attachments []Attachment) {
+	for _, attachment := range attachments {
+		s.cleanup(ctx, attachment)
+	}
+}
+
+func (s *attachmentService) find(ctx context.Context, itemID, id string) (Attachment, error) {
+	parsedItemID, err := uuid.Parse(itemID)
+	if err != nil {
+		return Attachment{}, ErrInvalidID
+	}
+	attachmentID, err := uuid.Parse(id)
+	if err != nil {
+		return Attachment{}, ErrInvalidID
+	}
+	return s.repo.FindByID(ctx, parsedItemID, attachmentID)
+}
+
+func (s *attachmentService) findItem(ctx context.Context, itemID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidID
+	}
+	exists, err := s.repo.ItemExists(ctx, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !exists {
+		return uuid.Nil, ErrItemNotFound
+	}
+	return id, nil
+}
+
+// cleanup esborra els fitxers d'un adjunt que no s'ha pogut desar o que
+// s'ha esborrat
+func (s *attachmentService) cleanup(ctx context.Context, attachment Attachment) {
+	for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting stored file %s: %v", key, err)
+		}
+	}
+}
+
+func withURLs(a Attachment) Attachment {
+	a.URL = ContentURL(a.ItemID, a.ID)
+	if a.ThumbnailKey != "" {
+		a.ThumbnailURL = ThumbnailURL(a.ItemID, a.ID)
+	}
+	return a
+}
+
+// cleanFileName es queda amb el nom del fitxer sense directoris
+func cleanFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
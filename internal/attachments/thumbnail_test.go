@@ -0,0 +1,134 @@
+package attachments
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func filledImage(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image, contentType string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMakeThumbnailSizes(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		name            string
+		width, height   int
+		contentType     string
+		wantContentType string
+		wantW, wantH    int
+	}{
+		{"landscape png", 1000, 500, "image/png", "image/png", 256, 128},
+		{"portrait jpeg", 300, 900, "image/jpeg", "image/jpeg", 85, 256},
+		{"gif becomes png", 512, 512, "image/gif", "image/png", 256, 256},
+		{"small image is not enlarged", 40, 10, "image/png", "image/png", 40, 10},
+		{"very thin image keeps a pixel", 3000, 2, "image/png", "image/png", 256, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encode(t, filledImage(tt.width, tt.height, red), tt.contentType)
+			thumb, contentType, err := makeThumbnail(data, tt.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != tt.wantContentType {
+				t.Fatalf("content type = %s, want %s", contentType, tt.wantContentType)
+			}
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if "image/"+format != tt.wantContentType || cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Fatalf("thumbnail is a %dx%d %s, want %dx%d %s", cfg.Width, cfg.Height, format, tt.wantW, tt.wantH, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestMakeThumbnailAveragesAndKeepsTransparency(t *testing.T) {
+	// Columnes alternes negres opaques i transparents: cada píxel de la
+	// miniatura n'és la mitjana
+	src := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	for y := 0; y < 512; y++ {
+		for x := 0; x < 512; x += 2 {
+			src.Set(x, y, color.NRGBA{A: 255})
+		}
+	}
+	thumb, _, err := makeThumbnail(encode(t, src, "image/png"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, a := img.At(100, 100).RGBA()
+	if a>>8 < 120 || a>>8 > 135 {
+		t.Fatalf("alpha = %d, want about half", a>>8)
+	}
+}
+
+func TestMakeThumbnailRejects(t *testing.T) {
+	png1x1 := encode(t, filledImage(1, 1, color.White), "image/png")
+
+	// Capçalera PNG que diu que la imatge fa 100000x100000 píxels
+	huge := append([]byte(nil), png1x1...)
+	binary.BigEndian.PutUint32(huge[16:], 100000)
+	binary.BigEndian.PutUint32(huge[20:], 100000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		want        error
+	}{
+		{"unsupported type", png1x1, "image/webp", errNotDecodable},
+		{"pdf", []byte("%PDF-1.4"), "application/pdf", errNotDecodable},
+		{"too many pixels", huge, "image/png", errNotDecodable},
+		{"corrupt image", []byte("not a png"), "image/png", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, _, err := makeThumbnail(tt.data, tt.contentType)
+			if err == nil || thumb != nil {
+				t.Fatalf("makeThumbnail returned %d bytes and err = %v, want an error", len(thumb), err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
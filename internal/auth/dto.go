@@ -13,4 +13,17 @@ type LoginResponse struct {
 	Token  string `json:"token"`
 	Expire string `json:"expire"`
 	User   users.User   `json:"user"`	
-}
\ No newline at end of file
+}
+
+type MFAVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// MFAChallengeResponse es retorna al login quan l'usuari té el segon factor
+// actiu: cal enviar el challenge_token amb un codi a /auth/mfa/verify.
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	Expire         string `json:"expire"`
+}
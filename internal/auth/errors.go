@@ -6,4 +6,8 @@ var (
     ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotFound      = errors.New("user not found")
 	ErrInactiveUser      = errors.New("inactive user")
+	ErrMissingTokenID    = errors.New("token has no jti")
+	ErrTokenRevoked      = errors.New("token has been revoked")
+	ErrImpersonationRefresh = errors.New("impersonation tokens cannot be refreshed")
+	ErrInvalidChallenge  = errors.New("invalid or expired mfa challenge")
 )
\ No newline at end of file
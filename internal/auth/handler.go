@@ -1,37 +1,57 @@
 package auth
 
 import (
+	"frdy-api/internal/identity"
+	"frdy-api/internal/jwtkeys"
+	"frdy-api/internal/lockout"
+	"frdy-api/internal/mfa"
+	"frdy-api/internal/sessions"
+	"log"
 	"frdy-api/internal/users"
 	"net/http"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
+	jwtlib "github.com/golang-jwt/jwt/v4"
 )
 
 type AuthHandler struct {
     authService    AuthService
     jwtMiddleware *jwt.GinJWTMiddleware
+    issuer        *jwtkeys.Issuer
+    sessions      sessions.SessionService
 }
 
-func NewAuthHandler(authService AuthService, jwtMiddleware *jwt.GinJWTMiddleware) *AuthHandler {
+func NewAuthHandler(authService AuthService, jwtMiddleware *jwt.GinJWTMiddleware, issuer *jwtkeys.Issuer, sessionService sessions.SessionService) *AuthHandler {
     return &AuthHandler{
         authService:    authService,
         jwtMiddleware: jwtMiddleware,
+        issuer:        issuer,
+        sessions:      sessionService,
     }
 }
 
+// RequestClientInfo identifica el client de la petició. L'IP només es pren de
+// X-Forwarded-For si la connexió ve d'un dels TRUSTED_PROXIES.
+func RequestClientInfo(c *gin.Context) ClientInfo {
+    return ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
 // Login godoc
 // @Summary User login
-// @Description Authenticates a user and returns a JWT token
+// @Description Authenticates a user and returns a JWT token. Users with two-factor authentication get a challenge token instead, to be exchanged at /auth/mfa/verify
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login credentials"
 // @Success 200 {object} LoginResponse "Login successful"
+// @Success 202 {object} MFAChallengeResponse "Second factor required"
 // @Failure 400 {object} map[string]string "Invalid login request"
 // @Failure 401 {object} map[string]string "Invalid credentials"
-// @Failure 403 {object} map[string]string "User inactive"
+// @Failure 403 {object} map[string]string "Correct password, but the user is inactive or the email is not verified"
+// @Failure 423 {object} map[string]string "Account temporarily locked"
+// @Failure 429 {object} map[string]string "Too many failed logins from this address"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -41,40 +61,163 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
     
-    token, user, expire, err := h.authService.Login(c.Request.Context(), loginRequest)
+    result, err := h.authService.Login(c.Request.Context(), loginRequest, RequestClientInfo(c))
+    if err != nil {
+        c.JSON(loginStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    WriteLoginResponse(c, result)
+}
+
+// VerifyMFA godoc
+// @Summary Verify second factor
+// @Description Exchanges the login challenge token and a TOTP or recovery code for a JWT token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MFAVerifyRequest true "Challenge token and code"
+// @Success 200 {object} LoginResponse "Login successful"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Invalid challenge or code"
+// @Failure 423 {object} map[string]string "Account temporarily locked"
+// @Failure 429 {object} map[string]string "Too many failed logins from this address"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+    var request MFAVerifyRequest
+    if err := c.ShouldBindJSON(&request); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    result, err := h.authService.VerifyMFA(c.Request.Context(), request, RequestClientInfo(c))
+    if err != nil {
+        c.JSON(loginStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    WriteLoginResponse(c, result)
+}
+
+// WriteLoginResponse respon amb el token o, si cal el segon factor, amb el
+// token de repte
+func WriteLoginResponse(c *gin.Context, result LoginResult) {
+    if result.ChallengeToken != "" {
+        c.JSON(http.StatusAccepted, MFAChallengeResponse{
+            MFARequired:    true,
+            ChallengeToken: result.ChallengeToken,
+            Expire:         result.Expire.Format(time.RFC3339),
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, LoginResponse{
+        Token:  result.Token,
+        Expire: result.Expire.Format(time.RFC3339),
+        User:   result.User,
+    })
+}
+
+func loginStatus(err error) int {
+    switch err {
+    case ErrInvalidCredentials, ErrInvalidChallenge, mfa.ErrInvalidCode:
+        return http.StatusUnauthorized
+    case users.ErrInactiveUser, users.ErrPendingVerification:
+        return http.StatusForbidden
+    case lockout.ErrAccountLocked:
+        return http.StatusLocked
+    case lockout.ErrTooManyAttempts:
+        return http.StatusTooManyRequests
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+// RefreshToken godoc
+// @Summary Refresh JWT token
+// @Description Refreshes an existing JWT token with the current role and permissions of the user. Impersonation tokens cannot be refreshed
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]interface{} "Token refreshed successfully"
+// @Failure 401 {object} map[string]string "Invalid or expired token"
+// @Failure 403 {object} map[string]string "Impersonation token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /auth/refresh_token [get]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+    claims, err := h.jwtMiddleware.CheckIfTokenExpire(c)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        return
+    }
+    // La suplantació dura el que dura el token que s'ha emès
+    if _, impersonated := identity.ClaimImpersonator(claims); impersonated {
+        c.JSON(http.StatusForbidden, gin.H{"error": ErrImpersonationRefresh.Error()})
+        return
+    }
+    revoked, err := h.authService.IsRevoked(c.Request.Context(), jwt.MapClaims(claims))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if revoked {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": ErrTokenRevoked.Error()})
+        return
+    }
+    refreshed, err := h.authService.RefreshClaims(c.Request.Context(), jwt.MapClaims(claims))
     if err != nil {
         var statusCode int
         switch err {
-        case ErrInvalidCredentials:
+        case ErrTokenRevoked, users.ErrInvalidID:
             statusCode = http.StatusUnauthorized
-        case users.ErrInactiveUser:
-            statusCode = http.StatusForbidden
         default:
             statusCode = http.StatusInternalServerError
         }
-        
         c.JSON(statusCode, gin.H{"error": err.Error()})
         return
     }
-    
-    c.JSON(http.StatusOK, LoginResponse{
-        Token:  token,
-        Expire: expire.Format(time.RFC3339),
-        User:   user,
+    token, expire, err := h.issuer.Refresh(jwtlib.MapClaims(refreshed))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if jti, ok := claims["jti"].(string); ok {
+        if err := h.sessions.Touch(c.Request.Context(), jti); err != nil {
+            log.Printf("Error updating session last use: %v", err)
+        }
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "code":   http.StatusOK,
+        "token":  token,
+        "expire": expire.Format(time.RFC3339),
     })
 }
 
-// RefreshToken godoc
-// @Summary Refresh JWT token
-// @Description Refreshes an existing JWT token
+// Logout godoc
+// @Summary User logout
+// @Description Revokes the current JWT token and every token refreshed from it
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Success 200 {object} map[string]interface{} "Token refreshed successfully"
+// @Success 204 "Logout successful"
+// @Failure 400 {object} map[string]string "Token without jti"
 // @Failure 401 {object} map[string]string "Invalid or expired token"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /auth/refresh_token [get]
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-    h.jwtMiddleware.RefreshHandler(c)
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+    err := h.authService.Logout(c.Request.Context(), jwt.ExtractClaims(c))
+    if err != nil {
+        var statusCode int
+        switch err {
+        case ErrMissingTokenID, users.ErrInvalidID:
+            statusCode = http.StatusBadRequest
+        default:
+            statusCode = http.StatusInternalServerError
+        }
+        c.JSON(statusCode, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusNoContent, nil)
 }
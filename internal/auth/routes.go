@@ -1,11 +1,16 @@
 package auth
 
 import (
+	"frdy-api/internal/revocation"
+	"frdy-api/middleware"
+
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.RouterGroup, handler *AuthHandler, jwtMiddleware *jwt.GinJWTMiddleware) {
+func RegisterRoutes(router *gin.RouterGroup, handler *AuthHandler, jwtMiddleware *jwt.GinJWTMiddleware, revocations revocation.Store) {
 	router.POST("/login", handler.Login)
-	router.GET("/refresh_token", jwtMiddleware.RefreshHandler)
+	router.POST("/mfa/verify", handler.VerifyMFA)
+	router.GET("/refresh_token", handler.RefreshToken)
+	router.POST("/logout", jwtMiddleware.MiddlewareFunc(), middleware.RejectRevoked(revocations), handler.Logout)
 }
\ No newline at end of file
@@ -2,71 +2,305 @@ package auth
 
 import (
 	"context"
+	"errors"
+	"frdy-api/internal/jwtkeys"
+	"frdy-api/internal/lockout"
+	"frdy-api/internal/mfa"
+	"frdy-api/internal/password"
+	"frdy-api/internal/revocation"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/securetoken"
+	"frdy-api/internal/sessions"
 	"frdy-api/internal/users"
-
+	"log"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
-	"golang.org/x/crypto/bcrypt"
+	jwtlib "github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
+// mfaChallengePurpose identifica els tokens de repte de segon factor
+const mfaChallengePurpose = "mfa-challenge"
+
+// mfaChallengeTTL és el temps que té l'usuari per introduir el codi TOTP
+const mfaChallengeTTL = 5 * time.Minute
 
+// ClientInfo identifica el dispositiu des del qual es fa el login
+type ClientInfo struct {
+    IP        string
+    UserAgent string
+}
+
+// LoginResult conté el token JWT o, si l'usuari té el segon factor actiu, el
+// token de repte que s'ha de bescanviar a VerifyMFA.
+type LoginResult struct {
+    Token          string
+    Expire         time.Time
+    User           users.User
+    ChallengeToken string
+}
 
 type AuthService interface {
-    Login(ctx context.Context, req LoginRequest) (string, users.User, time.Time, error)
+    Login(ctx context.Context, req LoginRequest, client ClientInfo) (LoginResult, error)
+    VerifyMFA(ctx context.Context, req MFAVerifyRequest, client ClientInfo) (LoginResult, error)
+    // LoginUser inicia la sessió d'un usuari ja autenticat per un altre mitjà
+    // (p.ex. OIDC). Com Login, demana el segon factor si el té actiu.
+    LoginUser(ctx context.Context, user users.User, client ClientInfo) (LoginResult, error)
     ValidateUser(username, password string) (users.User, error)
+    // BuildClaims retorna les claims (rol, permisos, jti...) d'un token de l'usuari
+    BuildClaims(ctx context.Context, user users.User) (jwt.MapClaims, error)
+    // RefreshClaims retorna les claims del token que es refresca amb el rol i
+    // els permisos que l'usuari té ara
+    RefreshClaims(ctx context.Context, claims jwt.MapClaims) (jwt.MapClaims, error)
+    Logout(ctx context.Context, claims jwt.MapClaims) error
+    IsRevoked(ctx context.Context, claims jwt.MapClaims) (bool, error)
 }
 
 type authService struct {
     userRepo users.UserRepository
-    jwtMiddleware *jwt.GinJWTMiddleware
+    roleRepo roles.RoleRepository
+    revocations revocation.Store
+    lockouts lockout.LockoutService
+    sessions sessions.SessionService
+    mfa mfa.MFAService
+    challengeSecret []byte
+    issuer *jwtkeys.Issuer
+    hasher password.Hasher
 }
 
-func NewAuthService(userRepo users.UserRepository,  jwtMiddleware *jwt.GinJWTMiddleware) AuthService {
+func NewAuthService(userRepo users.UserRepository, roleRepo roles.RoleRepository, revocations revocation.Store, lockouts lockout.LockoutService, sessionService sessions.SessionService, mfaService mfa.MFAService, challengeSecret []byte, issuer *jwtkeys.Issuer, hasher password.Hasher) AuthService {
     return &authService{
         userRepo: userRepo,
-        jwtMiddleware: jwtMiddleware,
+        roleRepo: roleRepo,
+        revocations: revocations,
+        lockouts: lockouts,
+        sessions: sessionService,
+        mfa: mfaService,
+        challengeSecret: challengeSecret,
+        issuer: issuer,
+        hasher: hasher,
     }
 }
 
-// Login verifica les credencials i retorna un token JWT si són vàlides
-func (s *authService) Login(ctx context.Context, req LoginRequest) (string, users.User, time.Time, error) {
+// Login verifica les credencials i retorna un token JWT si són vàlides. Si
+// l'usuari té el segon factor actiu, retorna un token de repte en lloc del JWT.
+func (s *authService) Login(ctx context.Context, req LoginRequest, client ClientInfo) (LoginResult, error) {
+    // Rebutjar els usuaris i les IP bloquejats abans de comparar la contrasenya
+    if err := s.lockouts.Check(ctx, req.Username, client.IP); err != nil {
+        return LoginResult{}, err
+    }
+
     // Validar les credencials
     user, err := s.ValidateUser(req.Username, req.Password)
+    if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrUserNotFound) {
+        if lockErr := s.lockouts.RecordFailure(ctx, req.Username, client.IP); lockErr != nil {
+            return LoginResult{}, lockErr
+        }
+        // No diferenciar usuari inexistent de contrasenya incorrecta
+        return LoginResult{}, ErrInvalidCredentials
+    }
+    if err != nil {
+        return LoginResult{}, err
+    }
+    if err := s.lockouts.RecordSuccess(ctx, req.Username); err != nil {
+        return LoginResult{}, err
+    }
+    return s.LoginUser(ctx, user, client)
+}
+
+func (s *authService) LoginUser(ctx context.Context, user users.User, client ClientInfo) (LoginResult, error) {
+    if !user.IsActive {
+        return LoginResult{}, users.ErrInactiveUser
+    }
+    if user.TOTPEnabled {
+        expire := time.Now().Add(mfaChallengeTTL)
+        return LoginResult{
+            Expire:         expire,
+            ChallengeToken: securetoken.Sign(s.challengeSecret, mfaChallengePurpose, user.ID.String(), expire),
+        }, nil
+    }
+    return s.issueToken(ctx, user, false, client)
+}
+
+// VerifyMFA bescanvia un token de repte i un codi TOTP (o de recuperació) pel
+// token JWT. Els codis incorrectes compten com a intents de login fallits.
+func (s *authService) VerifyMFA(ctx context.Context, req MFAVerifyRequest, client ClientInfo) (LoginResult, error) {
+    subject, err := securetoken.Verify(s.challengeSecret, mfaChallengePurpose, req.ChallengeToken)
+    if err != nil {
+        return LoginResult{}, ErrInvalidChallenge
+    }
+    userID, err := uuid.Parse(subject)
     if err != nil {
-        return "", users.User{}, time.Time{}, err
+        return LoginResult{}, ErrInvalidChallenge
     }
-    id := user.ID.String()
+    user, err := s.userRepo.FindByID(ctx, userID)
+    if errors.Is(err, users.ErrUserNotFound) {
+        return LoginResult{}, ErrInvalidChallenge
+    }
+    if err != nil {
+        return LoginResult{}, err
+    }
+
+    if err := s.lockouts.Check(ctx, user.Username, client.IP); err != nil {
+        return LoginResult{}, err
+    }
+    ok, err := s.mfa.VerifyCode(ctx, user, req.Code)
+    if errors.Is(err, mfa.ErrNotEnabled) {
+        return LoginResult{}, ErrInvalidChallenge
+    }
+    if err != nil {
+        return LoginResult{}, err
+    }
+    if !ok {
+        if lockErr := s.lockouts.RecordFailure(ctx, user.Username, client.IP); lockErr != nil {
+            return LoginResult{}, lockErr
+        }
+        return LoginResult{}, mfa.ErrInvalidCode
+    }
+    if err := s.lockouts.RecordSuccess(ctx, user.Username); err != nil {
+        return LoginResult{}, err
+    }
+    return s.issueToken(ctx, user, true, client)
+}
+
+// issueToken genera el token JWT de l'usuari i en registra la sessió. La claim
+// "mfa" indica si s'ha verificat el segon factor; la política d'accés
+// l'exigeix per als permisos sensibles.
+func (s *authService) issueToken(ctx context.Context, user users.User, mfaVerified bool, client ClientInfo) (LoginResult, error) {
+    claims, err := s.buildClaims(ctx, user)
+    if err != nil {
+        return LoginResult{}, err
+    }
+    claims["mfa"] = mfaVerified
     // Generar token JWT
-    token, expire, err := s.jwtMiddleware.TokenGenerator(id)
+    token, expire, err := s.issuer.Generate(jwtlib.MapClaims(claims))
     if err != nil {
-        return "", users.User{}, time.Time{}, err
+        return LoginResult{}, err
+    }
+    authTime := time.Unix(claims["auth_time"].(int64), 0)
+    if err := s.sessions.Start(ctx, user.ID, claims["jti"].(string), authTime, client.IP, client.UserAgent); err != nil {
+        return LoginResult{}, err
     }
-   
+
     user.Password = "" // No retornar la contrasenya en la resposta
-    return token, user,  expire, nil
+    return LoginResult{Token: token, Expire: expire, User: user}, nil
 }
 
 // ValidateUser verifica si les credencials són vàlides i retorna l'ID de l'usuari
 func (s *authService) ValidateUser(username, password string) (users.User, error) {
-    // Obtenir l'usuari per nom d'usuari
-    user, err := s.userRepo.FindByUsername(context.Background(), username)
-
+    // Obtenir l'usuari per nom d'usuari, en qualsevol estat
+    user, err := s.userRepo.FindAnyByUsername(context.Background(), username)
     if err != nil {
         return users.User{}, ErrUserNotFound
     }
     
-    // Verificar que l'usuari estigui actiu
+    // Verificar la contrasenya abans de mirar l'estat del compte: sense la
+    // contrasenya no es pot saber si un compte està desactivat o pendent, i
+    // l'intent compta per al bloqueig com qualsevol altre
+    valid, err := s.hasher.Verify(user.Password, password)
+    if err != nil || !valid {
+        return users.User{}, ErrInvalidCredentials
+    }
+
+    // Els comptes pendents de verificar o desactivats tenen un error propi
     if !user.IsActive {
-        return users.User{}, users.ErrInactiveUser
+        return users.User{}, users.InactiveError(user)
     }
-    
-    // Verificar la contrasenya
-    err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-    if err != nil {
-        return users.User{}, ErrInvalidCredentials
+
+    // Actualitzar els hashos antics (bcrypt o paràmetres anteriors) ara que
+    // tenim la contrasenya en clar. Si falla, es tornarà a provar al següent login.
+    if s.hasher.NeedsRehash(user.Password) {
+        s.rehash(user, password)
     }
     
     // Retornar l'ID de l'usuari com a identificador principal
     return user, nil
-}
\ No newline at end of file
+}
+
+func (s *authService) rehash(user users.User, password string) {
+    hash, err := s.hasher.Hash(password)
+    if err == nil {
+        err = s.userRepo.UpdatePasswordHash(context.Background(), user.ID, user.Password, hash)
+    }
+    if err != nil {
+        log.Printf("Error rehashing password of user %s: %v", user.ID, err)
+    }
+}
+
+// Logout revoca el token actual i tots els que se n'hagin refrescat
+func (s *authService) Logout(ctx context.Context, claims jwt.MapClaims) error {
+    jti, _ := claims["jti"].(string)
+    if jti == "" {
+        return ErrMissingTokenID
+    }
+    id, _ := claims["id"].(string)
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return users.ErrInvalidID
+    }
+    return s.sessions.RevokeFamily(ctx, userID, jti)
+}
+
+func (s *authService) IsRevoked(ctx context.Context, claims jwt.MapClaims) (bool, error) {
+    return revocation.IsClaimsRevoked(ctx, s.revocations, claims)
+}
+
+func (s *authService) BuildClaims(ctx context.Context, user users.User) (jwt.MapClaims, error) {
+    return s.buildClaims(ctx, user)
+}
+
+// RefreshClaims torna a llegir el rol de l'usuari: un canvi de rol o de
+// permisos no pot quedar congelat en un token que es va refrescant
+func (s *authService) RefreshClaims(ctx context.Context, claims jwt.MapClaims) (jwt.MapClaims, error) {
+    id, _ := claims["id"].(string)
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return nil, users.ErrInvalidID
+    }
+    user, err := s.userRepo.FindByID(ctx, userID)
+    if errors.Is(err, users.ErrUserNotFound) || errors.Is(err, users.ErrInactiveUser) || errors.Is(err, users.ErrPendingVerification) {
+        return nil, ErrTokenRevoked
+    }
+    if err != nil {
+        return nil, err
+    }
+    current, err := s.buildClaims(ctx, user)
+    if err != nil {
+        return nil, err
+    }
+
+    refreshed := make(jwt.MapClaims, len(claims))
+    for key, value := range claims {
+        refreshed[key] = value
+    }
+    refreshed["role"] = current["role"]
+    refreshed["permissions"] = current["permissions"]
+    return refreshed, nil
+}
+
+// buildClaims afegeix el rol i els permisos de l'usuari a les claims del token.
+// El jti identifica la família de tokens (es manté en refrescar) i auth_time
+// el moment del login, per poder revocar-los.
+func (s *authService) buildClaims(ctx context.Context, user users.User) (jwt.MapClaims, error) {
+    claims := jwt.MapClaims{
+        "id":          user.ID.String(),
+        "jti":         uuid.New().String(),
+        "auth_time":   time.Now().Unix(),
+        "role":        "",
+        "permissions": []string{},
+    }
+    if !user.RoleID.Valid {
+        return claims, nil
+    }
+    role, err := s.roleRepo.FindByID(ctx, user.RoleID.UUID)
+    if errors.Is(err, roles.ErrRoleNotFound) {
+        return claims, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    claims["role"] = role.Name
+    claims["permissions"] = role.Permissions
+    return claims, nil
+}
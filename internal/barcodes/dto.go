@@ -0,0 +1,10 @@
+package barcodes
+
+type ItemCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+	// Si és buit, els codis numèrics de 8, 12, 13 o 14 dígits es tracten com
+	// a GTIN i la resta com a àlies
+	Kind         string `json:"kind"`
+	SupplierName string `json:"supplier_name"`
+	Unit         string `json:"unit"`
+}
@@ -0,0 +1,14 @@
+package barcodes
+
+import "errors"
+
+var (
+	ErrCodeNotFound    = errors.New("no item found for this code")
+	ErrItemNotFound    = errors.New("item not found")
+	ErrInvalidID       = errors.New("invalid ID")
+	ErrInvalidRequest  = errors.New("invalid request")
+	ErrInvalidKind     = errors.New("unknown code kind")
+	ErrInvalidGTIN     = errors.New("invalid GTIN: wrong length or check digit")
+	ErrSupplierMissing = errors.New("supplier codes need a supplier name")
+	ErrCodeTaken       = errors.New("this code is already assigned to an item")
+)
@@ -0,0 +1,64 @@
+package barcodes
+
+// gtinLengths són les longituds de cada tipus de GTIN
+var gtinLengths = map[string]int{
+	KindEAN8:   8,
+	KindUPC:    12,
+	KindEAN13:  13,
+	KindGTIN14: 14,
+}
+
+// ValidGTIN comprova que el codi només tingui dígits, una longitud de GTIN
+// i un dígit de control correcte (mòdul 10 amb pesos 3 i 1 des de la dreta)
+func ValidGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	last := code[len(code)-1]
+	if last < '0' || last > '9' {
+		return false
+	}
+	return (10-sum%10)%10 == int(last-'0')
+}
+
+// NormalizeGTIN retorna el GTIN amb zeros a l'esquerra fins a 14 dígits, o
+// false si no és un GTIN vàlid
+func NormalizeGTIN(code string) (string, bool) {
+	if !ValidGTIN(code) {
+		return "", false
+	}
+	return "00000000000000"[:14-len(code)] + code, true
+}
+
+// gtinKind dedueix el tipus de GTIN a partir de la longitud
+func gtinKind(code string) string {
+	for kind, length := range gtinLengths {
+		if len(code) == length {
+			return kind
+		}
+	}
+	return ""
+}
+
+func isDigits(code string) bool {
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+	return code != ""
+}
@@ -0,0 +1,132 @@
+package barcodes
+
+import (
+	"errors"
+	"frdy-api/internal/units"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BarcodeHandler struct {
+	service BarcodeService
+}
+
+func NewBarcodeHandler(service BarcodeService) *BarcodeHandler {
+	return &BarcodeHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrCodeNotFound), errors.Is(err, ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidKind),
+		errors.Is(err, ErrInvalidGTIN), errors.Is(err, ErrSupplierMissing),
+		errors.Is(err, units.ErrUnitNotFound), errors.Is(err, units.ErrNoConversion):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrCodeTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create godoc
+// @Summary Add a barcode or alternative code to an item
+// @Description Adds an EAN-13, EAN-8, UPC, GTIN-14, supplier code or internal alias to an item. GTIN check digits are validated; a unit makes the code count as one unit of that kind, e.g. the barcode of a box (Protected route)
+// @Tags barcodes
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param request body ItemCodeRequest true "Code data"
+// @Success 201 {object} ItemCode
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/barcodes [post]
+// @Security BearerAuth
+func (h *BarcodeHandler) Create(c *gin.Context) {
+	var request ItemCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code, err := h.service.Create(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+// FindByItemID godoc
+// @Summary List the barcodes of an item
+// @Description Retrieves the barcodes and alternative codes of an item (Protected route)
+// @Tags barcodes
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {array} ItemCode
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/barcodes [get]
+// @Security BearerAuth
+func (h *BarcodeHandler) FindByItemID(c *gin.Context) {
+	codes, err := h.service.FindByItemID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Delete godoc
+// @Summary Remove a barcode from an item
+// @Description Deletes a barcode or alternative code of an item (Protected route)
+// @Tags barcodes
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param code_id path string true "Code ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/barcodes/{code_id} [delete]
+// @Security BearerAuth
+func (h *BarcodeHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), c.Param("code_id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Lookup godoc
+// @Summary Find an item by any code
+// @Description Resolves an item code, barcode (a UPC also matches when scanned as EAN-13), supplier code or alias to its item (Protected route)
+// @Tags barcodes
+// @Accept json
+// @Produce json
+// @Param code path string true "Code or barcode"
+// @Success 200 {object} Match
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/lookup/{code} [get]
+// @Security BearerAuth
+func (h *BarcodeHandler) Lookup(c *gin.Context) {
+	match, err := h.service.Lookup(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, match)
+}
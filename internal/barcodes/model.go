@@ -0,0 +1,43 @@
+package barcodes
+
+import (
+	"frdy-api/internal/items"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipus de codi alternatiu d'un article
+const (
+	KindEAN13    = "ean13"
+	KindEAN8     = "ean8"
+	KindUPC      = "upc"
+	KindGTIN14   = "gtin14"
+	KindSupplier = "supplier"
+	KindAlias    = "alias"
+)
+
+// ItemCode és un codi de barres o un codi alternatiu d'un article. Els GTIN
+// (EAN-13, EAN-8, UPC-A, GTIN-14) es guarden també normalitzats a 14 dígits
+// perquè un UPC llegit com a EAN-13 (amb un zero davant) es trobi igualment.
+// Si té unitat, escanejar-lo equival a una unitat d'aquest tipus (p. ex. el
+// codi d'una caixa).
+type ItemCode struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	ItemID       uuid.UUID `json:"item_id" db:"item_id"`
+	Code         string    `json:"code" db:"code"`
+	Kind         string    `json:"kind" db:"kind"`
+	GTIN         *string   `json:"gtin,omitempty" db:"gtin"`
+	SupplierName string    `json:"supplier_name,omitempty" db:"supplier_name"`
+	Unit         string    `json:"unit,omitempty" db:"unit"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Match és l'article trobat a partir d'un codi qualsevol
+type Match struct {
+	Item items.Item `json:"item"`
+	// Codi amb què s'ha trobat i de quin tipus és ("code" si és el codi de l'article)
+	MatchedCode string `json:"matched_code"`
+	Kind        string `json:"kind"`
+	Unit        string `json:"unit,omitempty"`
+}
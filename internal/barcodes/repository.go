@@ -0,0 +1,95 @@
+package barcodes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const itemCodeColumns = `id, item_id, code, kind, gtin, COALESCE(supplier_name,''), COALESCE(unit,''), created_at`
+
+type BarcodeRepository interface {
+	Create(ctx context.Context, code ItemCode) (ItemCode, error)
+	Delete(ctx context.Context, itemID, id uuid.UUID) error
+	FindByItemID(ctx context.Context, itemID uuid.UUID) ([]ItemCode, error)
+	// FindByCode busca per codi exacte o, si gtin no és buit, pel GTIN normalitzat
+	FindByCode(ctx context.Context, code, gtin string) (ItemCode, error)
+}
+
+type barcodeRepository struct {
+	db *sql.DB
+}
+
+func NewBarcodeRepository(db *sql.DB) BarcodeRepository {
+	return &barcodeRepository{db: db}
+}
+
+func itemCodeFields(c *ItemCode) []interface{} {
+	return []interface{}{&c.ID, &c.ItemID, &c.Code, &c.Kind, &c.GTIN, &c.SupplierName, &c.Unit, &c.CreatedAt}
+}
+
+func (r *barcodeRepository) Create(ctx context.Context, code ItemCode) (ItemCode, error) {
+	var created ItemCode
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO item_codes (id, item_id, code, kind, gtin, supplier_name, unit)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+		RETURNING `+itemCodeColumns,
+		code.ID, code.ItemID, code.Code, code.Kind, code.GTIN, code.SupplierName, code.Unit,
+	).Scan(itemCodeFields(&created)...)
+	if err != nil {
+		return ItemCode{}, fmt.Errorf("error creating item code: %w", err)
+	}
+	return created, nil
+}
+
+func (r *barcodeRepository) Delete(ctx context.Context, itemID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM item_codes WHERE id = $1 AND item_id = $2`, id, itemID)
+	if err != nil {
+		return fmt.Errorf("error deleting item code: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCodeNotFound
+	}
+	return nil
+}
+
+func (r *barcodeRepository) FindByItemID(ctx context.Context, itemID uuid.UUID) ([]ItemCode, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemCodeColumns+`
+		FROM item_codes
+		WHERE item_id = $1
+		ORDER BY created_at`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting item codes: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []ItemCode
+	for rows.Next() {
+		var code ItemCode
+		if err := rows.Scan(itemCodeFields(&code)...); err != nil {
+			return nil, fmt.Errorf("error scanning item code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+func (r *barcodeRepository) FindByCode(ctx context.Context, code, gtin string) (ItemCode, error) {
+	var found ItemCode
+	err := r.db.QueryRowContext(ctx, `
+		SELECT `+itemCodeColumns+`
+		FROM item_codes
+		WHERE code = $1 OR (NULLIF($2, '') IS NOT NULL AND gtin = $2)
+		ORDER BY code = $1 DESC
+		LIMIT 1`, code, gtin,
+	).Scan(itemCodeFields(&found)...)
+	if err == sql.ErrNoRows {
+		return ItemCode{}, ErrCodeNotFound
+	} else if err != nil {
+		return ItemCode{}, fmt.Errorf("error getting item code: %w", err)
+	}
+	return found, nil
+}
@@ -0,0 +1,14 @@
+package barcodes
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *BarcodeHandler) {
+	router.GET("/items/lookup/:code", handler.Lookup)
+
+	codes := router.Group("/items/:id/barcodes")
+	{
+		codes.POST("", handler.Create)
+		codes.GET("", handler.FindByItemID)
+		codes.DELETE("/:code_id", handler.Delete)
+	}
+}
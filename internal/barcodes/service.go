@@ -0,0 +1,149 @@
+package barcodes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"frdy-api/internal/items"
+	"frdy-api/internal/units"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// kindItemCode indica que s'ha trobat l'article pel seu codi principal
+const kindItemCode = "code"
+
+type BarcodeService interface {
+	Create(ctx context.Context, itemID string, request ItemCodeRequest) (ItemCode, error)
+	Delete(ctx context.Context, itemID, id string) error
+	FindByItemID(ctx context.Context, itemID string) ([]ItemCode, error)
+	// Lookup troba l'article pel seu codi, un codi de barres o un àlies
+	Lookup(ctx context.Context, code string) (Match, error)
+}
+
+type barcodeService struct {
+	repo  BarcodeRepository
+	items items.ItemRepository
+	units units.UnitService
+}
+
+func NewBarcodeService(repo BarcodeRepository, items items.ItemRepository, units units.UnitService) BarcodeService {
+	return &barcodeService{repo: repo, items: items, units: units}
+}
+
+func (s *barcodeService) Create(ctx context.Context, itemID string, request ItemCodeRequest) (ItemCode, error) {
+	item, err := s.findItem(itemID)
+	if err != nil {
+		return ItemCode{}, err
+	}
+
+	code := ItemCode{
+		ID:           uuid.New(),
+		ItemID:       item.ID,
+		Code:         strings.TrimSpace(request.Code),
+		Kind:         strings.ToLower(strings.TrimSpace(request.Kind)),
+		SupplierName: strings.TrimSpace(request.SupplierName),
+		Unit:         units.NormalizeCode(request.Unit),
+	}
+	if code.Code == "" {
+		return ItemCode{}, ErrInvalidRequest
+	}
+	if code.Kind == "" {
+		code.Kind = KindAlias
+		if isDigits(code.Code) {
+			if kind := gtinKind(code.Code); kind != "" {
+				code.Kind = kind
+			}
+		}
+	}
+
+	switch code.Kind {
+	case KindEAN13, KindEAN8, KindUPC, KindGTIN14:
+		gtin, ok := NormalizeGTIN(code.Code)
+		if !ok || len(code.Code) != gtinLengths[code.Kind] {
+			return ItemCode{}, ErrInvalidGTIN
+		}
+		code.GTIN = &gtin
+	case KindSupplier:
+		if code.SupplierName == "" {
+			return ItemCode{}, ErrSupplierMissing
+		}
+	case KindAlias:
+	default:
+		return ItemCode{}, ErrInvalidKind
+	}
+
+	// La unitat ha de ser la base o tenir conversió per a l'article
+	if code.Unit != "" {
+		if _, err := s.units.ToBase(ctx, itemID, code.Unit, 1); err != nil {
+			return ItemCode{}, err
+		}
+	}
+
+	if _, err := s.Lookup(ctx, code.Code); err == nil {
+		return ItemCode{}, ErrCodeTaken
+	} else if !errors.Is(err, ErrCodeNotFound) {
+		return ItemCode{}, err
+	}
+
+	return s.repo.Create(ctx, code)
+}
+
+func (s *barcodeService) Delete(ctx context.Context, itemID, id string) error {
+	parsedItemID, err := uuid.Parse(itemID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	codeID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	return s.repo.Delete(ctx, parsedItemID, codeID)
+}
+
+func (s *barcodeService) FindByItemID(ctx context.Context, itemID string) ([]ItemCode, error) {
+	item, err := s.findItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByItemID(ctx, item.ID)
+}
+
+func (s *barcodeService) Lookup(ctx context.Context, code string) (Match, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return Match{}, ErrInvalidRequest
+	}
+
+	item, err := s.items.FindByCode(code)
+	if err == nil {
+		return Match{Item: item, MatchedCode: code, Kind: kindItemCode}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Match{}, err
+	}
+
+	gtin, _ := NormalizeGTIN(code)
+	found, err := s.repo.FindByCode(ctx, code, gtin)
+	if err != nil {
+		return Match{}, err
+	}
+	item, err = s.items.FindByID(found.ItemID)
+	if err != nil {
+		return Match{}, err
+	}
+	return Match{Item: item, MatchedCode: found.Code, Kind: found.Kind, Unit: found.Unit}, nil
+}
+
+func (s *barcodeService) findItem(itemID string) (items.Item, error) {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return items.Item{}, ErrInvalidID
+	}
+	item, err := s.items.FindByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return items.Item{}, ErrItemNotFound
+	}
+	return item, err
+}
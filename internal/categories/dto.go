@@ -0,0 +1,7 @@
+package categories
+
+type CategoryRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description"`
+	ParentID    *string `json:"parent_id"`
+}
@@ -0,0 +1,12 @@
+package categories
+
+import "errors"
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrInvalidID        = errors.New("invalid category ID")
+	ErrInvalidRequest   = errors.New("invalid request")
+	ErrInvalidParent    = errors.New("a category cannot be moved under itself or one of its descendants")
+	ErrCategoryExists   = errors.New("a category with this name already exists under the same parent")
+	ErrCategoryInUse    = errors.New("category has subcategories")
+)
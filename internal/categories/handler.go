@@ -0,0 +1,177 @@
+package categories
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	service CategoryService
+}
+
+func NewCategoryHandler(service CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidParent):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrCategoryExists), errors.Is(err, ErrCategoryInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create godoc
+// @Summary Create a category
+// @Description Creates a category, optionally under a parent category (Protected route)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param request body CategoryRequest true "Category data"
+// @Success 201 {object} Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories [post]
+// @Security BearerAuth
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Create(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// Update godoc
+// @Summary Update a category
+// @Description Renames a category or moves it under another parent; a category cannot be moved under its own subtree (Protected route)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param request body CategoryRequest true "Category data"
+// @Success 200 {object} Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id} [put]
+// @Security BearerAuth
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	var request CategoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Update(c.Request.Context(), id, request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// Delete godoc
+// @Summary Delete a category
+// @Description Deletes a category without subcategories; its items are left uncategorised (Protected route)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id} [delete]
+// @Security BearerAuth
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// FindByID godoc
+// @Summary Get a category by ID
+// @Description Retrieves a category (Protected route)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} Category
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id} [get]
+// @Security BearerAuth
+func (h *CategoryHandler) FindByID(c *gin.Context) {
+	category, err := h.service.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// FindAll godoc
+// @Summary List categories
+// @Description Retrieves all categories as a flat list ordered by name (Protected route)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Success 200 {array} Category
+// @Failure 500 {object} map[string]string
+// @Router /api/categories [get]
+// @Security BearerAuth
+func (h *CategoryHandler) FindAll(c *gin.Context) {
+	categories, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// Tree godoc
+// @Summary Get the category tree
+// @Description Retrieves all categories nested under their root categories (Protected route)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Success 200 {array} CategoryNode
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/tree [get]
+// @Security BearerAuth
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	tree, err := h.service.Tree(c.Request.Context())
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
@@ -0,0 +1,24 @@
+package categories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category agrupa articles en un arbre sense límit de nivells. Les categories
+// arrel no tenen pare.
+type Category struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	ParentID    *uuid.UUID `json:"parent_id" db:"parent_id"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CategoryNode és una categoria amb les seves subcategories, per retornar l'arbre
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
+}
@@ -0,0 +1,135 @@
+package categories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const categoryColumns = `id, name, COALESCE(description,''), parent_id, created_at, updated_at`
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category Category) (Category, error)
+	Update(ctx context.Context, category Category) (Category, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (Category, error)
+	FindByName(ctx context.Context, parentID *uuid.UUID, name string) (Category, error)
+	FindAll(ctx context.Context) ([]Category, error)
+	CountChildren(ctx context.Context, id uuid.UUID) (int, error)
+	InSubtree(ctx context.Context, rootID, id uuid.UUID) (bool, error)
+}
+
+type categoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryRepository(db *sql.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func categoryFields(c *Category) []interface{} {
+	return []interface{}{&c.ID, &c.Name, &c.Description, &c.ParentID, &c.CreatedAt, &c.UpdatedAt}
+}
+
+func (r *categoryRepository) Create(ctx context.Context, category Category) (Category, error) {
+	var created Category
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO categories (id, name, description, parent_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+categoryColumns,
+		category.ID, category.Name, category.Description, category.ParentID,
+	).Scan(categoryFields(&created)...)
+	if err != nil {
+		return Category{}, fmt.Errorf("error creating category: %w", err)
+	}
+	return created, nil
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category Category) (Category, error) {
+	var updated Category
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE categories
+		SET name = $1, description = $2, parent_id = $3, updated_at = now()
+		WHERE id = $4
+		RETURNING `+categoryColumns,
+		category.Name, category.Description, category.ParentID, category.ID,
+	).Scan(categoryFields(&updated)...)
+	if err == sql.ErrNoRows {
+		return Category{}, ErrCategoryNotFound
+	} else if err != nil {
+		return Category{}, fmt.Errorf("error updating category: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *categoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting category: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (r *categoryRepository) FindByID(ctx context.Context, id uuid.UUID) (Category, error) {
+	return r.findOne(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id)
+}
+
+func (r *categoryRepository) FindByName(ctx context.Context, parentID *uuid.UUID, name string) (Category, error) {
+	return r.findOne(ctx, `
+		SELECT `+categoryColumns+`
+		FROM categories
+		WHERE parent_id IS NOT DISTINCT FROM $1 AND lower(name) = lower($2)`, parentID, name)
+}
+
+func (r *categoryRepository) findOne(ctx context.Context, query string, args ...interface{}) (Category, error) {
+	var category Category
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(categoryFields(&category)...)
+	if err == sql.ErrNoRows {
+		return Category{}, ErrCategoryNotFound
+	} else if err != nil {
+		return Category{}, fmt.Errorf("error getting category: %w", err)
+	}
+	return category, nil
+}
+
+func (r *categoryRepository) FindAll(ctx context.Context) ([]Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+categoryColumns+` FROM categories ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []Category
+	for rows.Next() {
+		var category Category
+		if err := rows.Scan(categoryFields(&category)...); err != nil {
+			return nil, fmt.Errorf("error scanning category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *categoryRepository) CountChildren(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT count(*) FROM categories WHERE parent_id = $1`, id).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting subcategories: %w", err)
+	}
+	return count, nil
+}
+
+// InSubtree indica si id és rootID o un dels seus descendents
+func (r *categoryRepository) InSubtree(ctx context.Context, rootID, id uuid.UUID) (bool, error) {
+	var found bool
+	err := r.db.QueryRowContext(ctx, `SELECT $2::uuid IN `+SubtreeIDs("$1"), rootID, id).Scan(&found)
+	if err != nil {
+		return false, fmt.Errorf("error checking category tree: %w", err)
+	}
+	return found, nil
+}
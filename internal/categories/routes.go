@@ -0,0 +1,15 @@
+package categories
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *CategoryHandler) {
+	categories := router.Group("/categories")
+	{
+		categories.POST("", handler.Create)
+		categories.PUT("/:id", handler.Update)
+		categories.DELETE("/:id", handler.Delete)
+		categories.GET("/tree", handler.Tree)
+		categories.GET("/:id", handler.FindByID)
+		categories.GET("", handler.FindAll)
+	}
+}
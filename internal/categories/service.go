@@ -0,0 +1,179 @@
+package categories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type CategoryService interface {
+	Create(ctx context.Context, request CategoryRequest) (Category, error)
+	Update(ctx context.Context, id string, request CategoryRequest) (Category, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (Category, error)
+	FindAll(ctx context.Context) ([]Category, error)
+	Tree(ctx context.Context) ([]CategoryNode, error)
+}
+
+type categoryService struct {
+	repo CategoryRepository
+}
+
+func NewCategoryService(repo CategoryRepository) CategoryService {
+	return &categoryService{repo: repo}
+}
+
+func (s *categoryService) Create(ctx context.Context, request CategoryRequest) (Category, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return Category{}, ErrInvalidRequest
+	}
+	parentID, err := s.parent(ctx, request.ParentID)
+	if err != nil {
+		return Category{}, err
+	}
+	if err := s.checkName(ctx, uuid.Nil, parentID, name); err != nil {
+		return Category{}, err
+	}
+
+	category := Category{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: request.Description,
+		ParentID:    parentID,
+	}
+	return s.repo.Create(ctx, category)
+}
+
+func (s *categoryService) Update(ctx context.Context, id string, request CategoryRequest) (Category, error) {
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		return Category{}, ErrInvalidID
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return Category{}, ErrInvalidRequest
+	}
+	if _, err := s.repo.FindByID(ctx, categoryID); err != nil {
+		return Category{}, err
+	}
+	parentID, err := s.parent(ctx, request.ParentID)
+	if err != nil {
+		return Category{}, err
+	}
+	// El nou pare no pot ser la mateixa categoria ni cap descendent seu, o
+	// l'arbre tindria un cicle
+	if parentID != nil {
+		cycle, err := s.repo.InSubtree(ctx, categoryID, *parentID)
+		if err != nil {
+			return Category{}, err
+		}
+		if cycle {
+			return Category{}, ErrInvalidParent
+		}
+	}
+	if err := s.checkName(ctx, categoryID, parentID, name); err != nil {
+		return Category{}, err
+	}
+
+	category := Category{
+		ID:          categoryID,
+		Name:        name,
+		Description: request.Description,
+		ParentID:    parentID,
+	}
+	return s.repo.Update(ctx, category)
+}
+
+// Delete elimina una categoria sense subcategories. Els articles que en
+// formaven part queden sense categoria.
+func (s *categoryService) Delete(ctx context.Context, id string) error {
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if _, err := s.repo.FindByID(ctx, categoryID); err != nil {
+		return err
+	}
+	count, err := s.repo.CountChildren(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+	return s.repo.Delete(ctx, categoryID)
+}
+
+func (s *categoryService) FindByID(ctx context.Context, id string) (Category, error) {
+	categoryID, err := uuid.Parse(id)
+	if err != nil {
+		return Category{}, ErrInvalidID
+	}
+	return s.repo.FindByID(ctx, categoryID)
+}
+
+func (s *categoryService) FindAll(ctx context.Context) ([]Category, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Tree retorna totes les categories niades sota les seves arrels
+func (s *categoryService) Tree(ctx context.Context) ([]CategoryNode, error) {
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[uuid.UUID][]Category)
+	var roots []Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+	return buildNodes(roots, children), nil
+}
+
+func buildNodes(categories []Category, children map[uuid.UUID][]Category) []CategoryNode {
+	nodes := make([]CategoryNode, 0, len(categories))
+	for _, category := range categories {
+		nodes = append(nodes, CategoryNode{
+			Category: category,
+			Children: buildNodes(children[category.ID], children),
+		})
+	}
+	return nodes
+}
+
+// parent valida el pare indicat a la petició; nil o buit vol dir arrel
+func (s *categoryService) parent(ctx context.Context, parentID *string) (*uuid.UUID, error) {
+	if parentID == nil || *parentID == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*parentID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// checkName comprova que cap germana no tingui el mateix nom
+func (s *categoryService) checkName(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, name string) error {
+	existing, err := s.repo.FindByName(ctx, parentID, name)
+	if errors.Is(err, ErrCategoryNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != id {
+		return ErrCategoryExists
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package categories
+
+import "fmt"
+
+// SubtreeIDs retorna una subconsulta amb l'id de la categoria indicada pel
+// paràmetre (p. ex. "$1") i els de tots els seus descendents. Serveix per
+// filtrar articles d'una categoria incloent-hi les subcategories.
+func SubtreeIDs(param string) string {
+	return fmt.Sprintf(`(
+		WITH RECURSIVE subtree (id) AS (
+			SELECT id FROM categories WHERE id = %s
+			UNION ALL
+			SELECT c.id FROM categories c INNER JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree)`, param)
+}
+
+// ClosureCTE relaciona cada categoria (root_id) amb ella mateixa i amb tots
+// els seus descendents (id). S'ha d'afegir després d'un WITH RECURSIVE i
+// permet agregar informes per categoria acumulant les subcategories.
+const ClosureCTE = `closure (root_id, id) AS (
+		SELECT id, id FROM categories
+		UNION ALL
+		SELECT cl.root_id, c.id FROM categories c INNER JOIN closure cl ON c.parent_id = cl.id
+	)`
@@ -0,0 +1,75 @@
+// Package identity llegeix qui fa la petició (i amb quins permisos) a partir
+// de les claims que el middleware JWT deixa al context de gin.
+package identity
+
+import (
+	"errors"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ErrNoIdentity = errors.New("no authenticated user")
+
+// UserID retorna l'ID de l'usuari autenticat
+func UserID(c *gin.Context) (uuid.UUID, error) {
+	id, ok := jwt.ExtractClaims(c)["id"].(string)
+	if !ok {
+		return uuid.Nil, ErrNoIdentity
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrNoIdentity
+	}
+	return userID, nil
+}
+
+// Permissions retorna els permisos de qui fa la petició
+func Permissions(c *gin.Context) []string {
+	return ClaimPermissions(jwt.ExtractClaims(c))
+}
+
+// ClaimPermissions llegeix la claim "permissions", tant si ve d'un token
+// acabat de generar ([]string) com d'un token parsejat ([]interface{}).
+func ClaimPermissions(claims map[string]interface{}) []string {
+	switch v := claims["permissions"].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		permissions := make([]string, 0, len(v))
+		for _, p := range v {
+			if s, ok := p.(string); ok {
+				permissions = append(permissions, s)
+			}
+		}
+		return permissions
+	}
+	return nil
+}
+
+// TokenFamily retorna el jti del token de la petició, que identifica la sessió
+func TokenFamily(c *gin.Context) string {
+	jti, _ := jwt.ExtractClaims(c)["jti"].(string)
+	return jti
+}
+
+// ClaimImpersonator retorna l'usuari que actua en nom del titular del token
+// (claim "act", RFC 8693), si el token és d'una suplantació.
+func ClaimImpersonator(claims map[string]interface{}) (uuid.UUID, bool) {
+	act, ok := claims["act"].(map[string]interface{})
+	if !ok {
+		return uuid.Nil, false
+	}
+	sub, _ := act["sub"].(string)
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// Impersonator retorna qui suplanta l'usuari de la petició, si és el cas
+func Impersonator(c *gin.Context) (uuid.UUID, bool) {
+	return ClaimImpersonator(jwt.ExtractClaims(c))
+}
@@ -0,0 +1,17 @@
+package impersonation
+
+import "frdy-api/internal/users"
+
+type StartRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	// Motiu de la suplantació (p.ex. la incidència de suport). Queda al registre d'accions.
+	Reason string `json:"reason"`
+}
+
+type StartResponse struct {
+	Token  string     `json:"token"`
+	Expire string     `json:"expire"`
+	User   users.User `json:"user"`
+	// ID de l'administrador que fa la suplantació
+	ImpersonatorID string `json:"impersonator_id"`
+}
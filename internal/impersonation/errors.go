@@ -0,0 +1,11 @@
+package impersonation
+
+import "errors"
+
+var (
+	ErrInvalidID           = errors.New("invalid user ID")
+	ErrSelfImpersonation   = errors.New("cannot impersonate yourself")
+	ErrTargetNotAllowed    = errors.New("users who can impersonate others cannot be impersonated")
+	ErrNestedImpersonation = errors.New("cannot impersonate while impersonating another user")
+	ErrNotImpersonating    = errors.New("the token is not an impersonation token")
+)
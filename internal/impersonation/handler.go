@@ -0,0 +1,95 @@
+package impersonation
+
+import (
+	"errors"
+	"frdy-api/internal/auth"
+	"frdy-api/internal/identity"
+	"frdy-api/internal/users"
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+type ImpersonationHandler struct {
+	service ImpersonationService
+}
+
+func NewImpersonationHandler(service ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrSelfImpersonation), errors.Is(err, ErrNotImpersonating),
+		errors.Is(err, auth.ErrMissingTokenID), errors.Is(err, users.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, identity.ErrNoIdentity):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrTargetNotAllowed), errors.Is(err, ErrNestedImpersonation),
+		errors.Is(err, users.ErrInactiveUser), errors.Is(err, users.ErrPendingVerification):
+		return http.StatusForbidden
+	case errors.Is(err, users.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Start godoc
+// @Summary Impersonate a user
+// @Description Issues a short-lived token acting as another user, to reproduce what they see. The token carries an "act" claim with the administrator, every request made with it is logged with the impersonator, it cannot be refreshed and it cannot change passwords or manage users (Protected route, admin only)
+// @Tags impersonation
+// @Accept json
+// @Produce json
+// @Param request body StartRequest true "User to impersonate"
+// @Success 201 {object} StartResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string "The user cannot be impersonated"
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/impersonation [post]
+// @Security BearerAuth
+func (h *ImpersonationHandler) Start(c *gin.Context) {
+	var request StartRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := jwt.ExtractClaims(c)
+	result, err := h.service.Start(c.Request.Context(), claims, request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	impersonatorID, _ := claims["id"].(string)
+	c.JSON(http.StatusCreated, StartResponse{
+		Token:          result.Token,
+		Expire:         result.Expire.Format(time.RFC3339),
+		User:           result.User,
+		ImpersonatorID: impersonatorID,
+	})
+}
+
+// End godoc
+// @Summary End impersonation
+// @Description Revokes the impersonation token used in the request. The client goes back to the administrator's own token (Protected route)
+// @Tags impersonation
+// @Accept json
+// @Produce json
+// @Success 204
+// @Failure 400 {object} map[string]string "The token is not an impersonation token"
+// @Failure 500 {object} map[string]string
+// @Router /api/impersonation [delete]
+// @Security BearerAuth
+func (h *ImpersonationHandler) End(c *gin.Context) {
+	if err := h.service.End(c.Request.Context(), jwt.ExtractClaims(c)); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
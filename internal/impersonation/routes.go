@@ -0,0 +1,8 @@
+package impersonation
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *ImpersonationHandler) {
+	router.POST("/impersonation", handler.Start)
+	router.DELETE("/impersonation", handler.End)
+}
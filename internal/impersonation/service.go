@@ -0,0 +1,117 @@
+package impersonation
+
+import (
+	"context"
+	"frdy-api/internal/auth"
+	"frdy-api/internal/identity"
+	"frdy-api/internal/jwtkeys"
+	"frdy-api/internal/revocation"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/users"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	jwtlib "github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// Result és el token de suplantació emès
+type Result struct {
+	Token  string
+	Expire time.Time
+	User   users.User
+}
+
+type ImpersonationService interface {
+	// Start emet un token de curta durada de l'usuari indicat. impersonator
+	// són les claims del token de l'administrador.
+	Start(ctx context.Context, impersonator jwt.MapClaims, request StartRequest) (Result, error)
+	// End revoca el token de suplantació amb què es fa la petició
+	End(ctx context.Context, claims jwt.MapClaims) error
+}
+
+type impersonationService struct {
+	userRepo    users.UserRepository
+	authService auth.AuthService
+	issuer      *jwtkeys.Issuer
+	revocations revocation.Store
+	ttl         time.Duration
+}
+
+func NewImpersonationService(userRepo users.UserRepository, authService auth.AuthService, issuer *jwtkeys.Issuer, revocations revocation.Store, ttl time.Duration) ImpersonationService {
+	return &impersonationService{
+		userRepo:    userRepo,
+		authService: authService,
+		issuer:      issuer,
+		revocations: revocations,
+		ttl:         ttl,
+	}
+}
+
+func (s *impersonationService) Start(ctx context.Context, impersonator jwt.MapClaims, request StartRequest) (Result, error) {
+	if _, nested := identity.ClaimImpersonator(impersonator); nested {
+		return Result{}, ErrNestedImpersonation
+	}
+	impersonatorID, err := uuid.Parse(stringClaim(impersonator, "id"))
+	if err != nil {
+		return Result{}, identity.ErrNoIdentity
+	}
+	targetID, err := uuid.Parse(request.UserID)
+	if err != nil {
+		return Result{}, ErrInvalidID
+	}
+	if targetID == impersonatorID {
+		return Result{}, ErrSelfImpersonation
+	}
+
+	// FindByID només retorna usuaris actius
+	target, err := s.userRepo.FindByID(ctx, targetID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	claims, err := s.authService.BuildClaims(ctx, target)
+	if err != nil {
+		return Result{}, err
+	}
+	// Un administrador no pot fer-se passar per un altre administrador
+	for _, permission := range identity.ClaimPermissions(claims) {
+		if permission == roles.PermUsersImpersonate || permission == roles.PermAll {
+			return Result{}, ErrTargetNotAllowed
+		}
+	}
+
+	claims["act"] = map[string]interface{}{"sub": impersonatorID.String()}
+	// El segon factor és el de l'administrador
+	claims["mfa"] = impersonator["mfa"] == true
+	if request.Reason != "" {
+		claims["act_reason"] = request.Reason
+	}
+
+	token, expire, err := s.issuer.GenerateWithTTL(jwtlib.MapClaims(claims), s.ttl)
+	if err != nil {
+		return Result{}, err
+	}
+	target.Password = ""
+	return Result{Token: token, Expire: expire, User: target}, nil
+}
+
+func (s *impersonationService) End(ctx context.Context, claims jwt.MapClaims) error {
+	if _, ok := identity.ClaimImpersonator(claims); !ok {
+		return ErrNotImpersonating
+	}
+	jti := stringClaim(claims, "jti")
+	if jti == "" {
+		return auth.ErrMissingTokenID
+	}
+	userID, err := uuid.Parse(stringClaim(claims, "id"))
+	if err != nil {
+		return users.ErrInvalidID
+	}
+	return s.revocations.RevokeToken(ctx, jti, userID, time.Now().Add(s.ttl))
+}
+
+func stringClaim(claims jwt.MapClaims, key string) string {
+	value, _ := claims[key].(string)
+	return value
+}
@@ -0,0 +1,12 @@
+package invitations
+
+type CreateInvitationRequest struct {
+	Email  string `json:"email" binding:"required"`
+	RoleID string `json:"role_id" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package invitations
+
+import "errors"
+
+var (
+	ErrInvitationNotFound  = errors.New("invitation not found")
+	ErrInvalidID           = errors.New("invalid invitation ID")
+	ErrInvalidRequest      = errors.New("invalid request")
+	ErrInvalidToken        = errors.New("invalid or expired invitation")
+	ErrEmailRegistered     = errors.New("a user with this email already exists")
+	ErrInvitationsDisabled = errors.New("registration is closed")
+)
@@ -0,0 +1,169 @@
+package invitations
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"frdy-api/internal/password"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/users"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationHandler struct {
+	service InvitationService
+}
+
+func NewInvitationHandler(service InvitationService) *InvitationHandler {
+	return &InvitationHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	var policyErr *password.PolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInvitationNotFound), errors.Is(err, roles.ErrRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidToken),
+		errors.Is(err, roles.ErrInvalidID), errors.Is(err, users.ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrEmailRegistered), errors.Is(err, users.ErrUsernameTaken), errors.Is(err, users.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvitationsDisabled), errors.Is(err, roles.ErrRoleNotGrantable):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create godoc
+// @Summary Invite a user
+// @Description Creates an invitation with a pre-assigned role and emails the registration link. The inviter must hold every permission of the role (Protected route, admin only)
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param request body CreateInvitationRequest true "Invitation data"
+// @Success 201 {object} Invitation
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/invitations [post]
+// @Security BearerAuth
+func (h *InvitationHandler) Create(c *gin.Context) {
+	adminID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var request CreateInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.service.Create(c.Request.Context(), adminID, identity.Permissions(c), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// FindAll godoc
+// @Summary Get all invitations
+// @Description Lists pending, accepted, revoked and expired invitations (Protected route, admin only)
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Success 200 {array} Invitation
+// @Failure 500 {object} map[string]string
+// @Router /api/invitations [get]
+// @Security BearerAuth
+func (h *InvitationHandler) FindAll(c *gin.Context) {
+	invitations, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// FindByID godoc
+// @Summary Get an invitation by ID
+// @Description Retrieves an invitation (Protected route, admin only)
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} Invitation
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/invitations/{id} [get]
+// @Security BearerAuth
+func (h *InvitationHandler) FindByID(c *gin.Context) {
+	invitation, err := h.service.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// Revoke godoc
+// @Summary Revoke an invitation
+// @Description Revokes an invitation that has not been accepted yet (Protected route, admin only)
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/invitations/{id} [delete]
+// @Security BearerAuth
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// Accept godoc
+// @Summary Register with an invitation
+// @Description Creates the invited user's account with the role chosen by the admin. The account is active immediately (Public route)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body AcceptInvitationRequest true "Invitation token and account data"
+// @Success 201 {object} users.UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/register/invitation [post]
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	var request AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.Accept(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
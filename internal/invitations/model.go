@@ -0,0 +1,22 @@
+package invitations
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation permet a una persona crear el seu compte amb un rol ja assignat.
+// Només es guarda el hash del token que s'envia per email.
+type Invitation struct {
+	ID         uuid.UUID     `json:"id" db:"id"`
+	Email      string        `json:"email" db:"email"`
+	RoleID     uuid.UUID     `json:"role_id" db:"role_id"`
+	InvitedBy  uuid.UUID     `json:"invited_by" db:"invited_by"`
+	TokenHash  string        `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time     `json:"expires_at" db:"expires_at"`
+	AcceptedAt *time.Time    `json:"accepted_at" db:"accepted_at"`
+	UserID     uuid.NullUUID `json:"user_id" db:"user_id"`
+	RevokedAt  *time.Time    `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,146 @@
+package invitations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const invitationColumns = `id, email, role_id, invited_by, token_hash, expires_at, accepted_at, user_id, revoked_at, created_at`
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation Invitation) (Invitation, error)
+	FindByID(ctx context.Context, id uuid.UUID) (Invitation, error)
+	FindAll(ctx context.Context) ([]Invitation, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokePendingForEmail(ctx context.Context, email string) error
+	Consume(ctx context.Context, tokenHash string) (Invitation, error)
+	Release(ctx context.Context, id uuid.UUID) error
+	SetUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
+
+type invitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func invitationFields(i *Invitation) []interface{} {
+	return []interface{}{&i.ID, &i.Email, &i.RoleID, &i.InvitedBy, &i.TokenHash, &i.ExpiresAt,
+		&i.AcceptedAt, &i.UserID, &i.RevokedAt, &i.CreatedAt}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, invitation Invitation) (Invitation, error) {
+	var created Invitation
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO invitations (id, email, role_id, invited_by, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+invitationColumns,
+		invitation.ID, invitation.Email, invitation.RoleID, invitation.InvitedBy, invitation.TokenHash, invitation.ExpiresAt,
+	).Scan(invitationFields(&created)...)
+	if err != nil {
+		return Invitation{}, fmt.Errorf("error creating invitation: %w", err)
+	}
+	return created, nil
+}
+
+func (r *invitationRepository) FindByID(ctx context.Context, id uuid.UUID) (Invitation, error) {
+	var invitation Invitation
+	err := r.db.QueryRowContext(ctx, `SELECT `+invitationColumns+` FROM invitations WHERE id = $1`, id).
+		Scan(invitationFields(&invitation)...)
+	if err == sql.ErrNoRows {
+		return Invitation{}, ErrInvitationNotFound
+	} else if err != nil {
+		return Invitation{}, fmt.Errorf("error getting invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+func (r *invitationRepository) FindAll(ctx context.Context) ([]Invitation, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+invitationColumns+` FROM invitations ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []Invitation
+	for rows.Next() {
+		var invitation Invitation
+		if err := rows.Scan(invitationFields(&invitation)...); err != nil {
+			return nil, fmt.Errorf("error scanning invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// Revoke anul·la una invitació que encara no s'ha acceptat
+func (r *invitationRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE invitations
+		SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1 AND accepted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("error revoking invitation: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+func (r *invitationRepository) RevokePendingForEmail(ctx context.Context, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE invitations
+		SET revoked_at = now()
+		WHERE lower(email) = lower($1) AND accepted_at IS NULL AND revoked_at IS NULL`, email)
+	if err != nil {
+		return fmt.Errorf("error revoking invitations: %w", err)
+	}
+	return nil
+}
+
+// Consume marca la invitació com a acceptada en una sola sentència, perquè
+// dues peticions simultànies no la puguin fer servir.
+func (r *invitationRepository) Consume(ctx context.Context, tokenHash string) (Invitation, error) {
+	var invitation Invitation
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE invitations
+		SET accepted_at = now()
+		WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now()
+		RETURNING `+invitationColumns, tokenHash,
+	).Scan(invitationFields(&invitation)...)
+	if err == sql.ErrNoRows {
+		return Invitation{}, ErrInvalidToken
+	} else if err != nil {
+		return Invitation{}, fmt.Errorf("error consuming invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+// Release torna a deixar pendent una invitació si no s'ha pogut crear l'usuari
+func (r *invitationRepository) Release(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE invitations SET accepted_at = NULL
+		WHERE id = $1 AND user_id IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("error releasing invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *invitationRepository) SetUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE invitations SET user_id = $1 WHERE id = $2`, userID, id)
+	if err != nil {
+		return fmt.Errorf("error updating invitation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package invitations
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *InvitationHandler) {
+	invitations := router.Group("/invitations")
+	{
+		invitations.POST("", handler.Create)
+		invitations.GET("", handler.FindAll)
+		invitations.GET("/:id", handler.FindByID)
+		invitations.DELETE("/:id", handler.Revoke)
+	}
+}
+
+func RegisterPublicRoutes(router *gin.RouterGroup, handler *InvitationHandler) {
+	router.POST("/register/invitation", handler.Accept)
+}
@@ -0,0 +1,182 @@
+package invitations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"frdy-api/internal/notify"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/securetoken"
+	"frdy-api/internal/users"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InvitationService interface {
+	// Create convida un usuari. inviterPermissions són els permisos de qui
+	// convida, que ha de tenir tots els del rol de la invitació.
+	Create(ctx context.Context, invitedBy uuid.UUID, inviterPermissions []string, request CreateInvitationRequest) (Invitation, error)
+	FindByID(ctx context.Context, id string) (Invitation, error)
+	FindAll(ctx context.Context) ([]Invitation, error)
+	Revoke(ctx context.Context, id string) error
+	// Accept crea el compte de la persona convidada amb el rol de la invitació
+	Accept(ctx context.Context, request AcceptInvitationRequest) (users.UserResponse, error)
+}
+
+type invitationService struct {
+	repo        InvitationRepository
+	userRepo    users.UserRepository
+	userService users.UserService
+	roleRepo    roles.RoleRepository
+	notifier    notify.Notifier
+	baseURL     string
+	ttl         time.Duration
+}
+
+func NewInvitationService(repo InvitationRepository, userRepo users.UserRepository, userService users.UserService, roleRepo roles.RoleRepository, notifier notify.Notifier, baseURL string, ttl time.Duration) InvitationService {
+	return &invitationService{
+		repo:        repo,
+		userRepo:    userRepo,
+		userService: userService,
+		roleRepo:    roleRepo,
+		notifier:    notifier,
+		baseURL:     baseURL,
+		ttl:         ttl,
+	}
+}
+
+// Create guarda la invitació i l'envia per email. Una invitació nova per al
+// mateix email anul·la les anteriors que encara estiguin pendents.
+func (s *invitationService) Create(ctx context.Context, invitedBy uuid.UUID, inviterPermissions []string, request CreateInvitationRequest) (Invitation, error) {
+	if s.userService.RegistrationMode() == users.RegistrationClosed {
+		return Invitation{}, ErrInvitationsDisabled
+	}
+	email := strings.TrimSpace(request.Email)
+	if !strings.Contains(email, "@") {
+		return Invitation{}, ErrInvalidRequest
+	}
+	roleID, err := uuid.Parse(request.RoleID)
+	if err != nil {
+		return Invitation{}, roles.ErrInvalidID
+	}
+	role, err := s.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return Invitation{}, err
+	}
+	if !roles.Covers(inviterPermissions, role.Permissions) {
+		return Invitation{}, roles.ErrRoleNotGrantable
+	}
+	if err := s.checkEmailFree(ctx, email); err != nil {
+		return Invitation{}, err
+	}
+
+	if err := s.repo.RevokePendingForEmail(ctx, email); err != nil {
+		return Invitation{}, err
+	}
+	token, err := securetoken.Generate()
+	if err != nil {
+		return Invitation{}, err
+	}
+	invitation, err := s.repo.Create(ctx, Invitation{
+		ID:        uuid.New(),
+		Email:     email,
+		RoleID:    role.ID,
+		InvitedBy: invitedBy,
+		TokenHash: securetoken.Hash(token),
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return Invitation{}, err
+	}
+
+	link := fmt.Sprintf("%s/accept-invitation?token=%s", s.baseURL, url.QueryEscape(token))
+	err = s.notifier.Send(ctx, notify.Message{
+		To:      email,
+		Subject: "You have been invited to FRDY",
+		Body: fmt.Sprintf("Hello,\n\nYou have been invited to create an account with the %s role. The link expires in %s and can only be used once.\n\n%s",
+			role.Name, s.ttl, link),
+	})
+	if err != nil {
+		return Invitation{}, fmt.Errorf("error sending invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) FindByID(ctx context.Context, id string) (Invitation, error) {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return Invitation{}, ErrInvalidID
+	}
+	return s.repo.FindByID(ctx, invitationID)
+}
+
+func (s *invitationService) FindAll(ctx context.Context) ([]Invitation, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *invitationService) Revoke(ctx context.Context, id string) error {
+	invitationID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	return s.repo.Revoke(ctx, invitationID)
+}
+
+func (s *invitationService) Accept(ctx context.Context, request AcceptInvitationRequest) (users.UserResponse, error) {
+	if s.userService.RegistrationMode() == users.RegistrationClosed {
+		return users.UserResponse{}, ErrInvitationsDisabled
+	}
+	if request.Token == "" || request.Username == "" || request.Password == "" {
+		return users.UserResponse{}, ErrInvalidRequest
+	}
+
+	invitation, err := s.repo.Consume(ctx, securetoken.Hash(request.Token))
+	if err != nil {
+		return users.UserResponse{}, err
+	}
+
+	user, err := s.createUser(ctx, invitation, request)
+	if err != nil {
+		// La invitació es pot tornar a fer servir (p.ex. amb un altre nom d'usuari)
+		if releaseErr := s.repo.Release(ctx, invitation.ID); releaseErr != nil {
+			log.Printf("Error releasing invitation %s: %v", invitation.ID, releaseErr)
+		}
+		return users.UserResponse{}, err
+	}
+	userID, err := uuid.Parse(user.ID)
+	if err != nil {
+		return users.UserResponse{}, err
+	}
+	if err := s.repo.SetUser(ctx, invitation.ID, userID); err != nil {
+		return users.UserResponse{}, err
+	}
+	return user, nil
+}
+
+func (s *invitationService) createUser(ctx context.Context, invitation Invitation, request AcceptInvitationRequest) (users.UserResponse, error) {
+	if err := s.checkEmailFree(ctx, invitation.Email); err != nil {
+		return users.UserResponse{}, err
+	}
+	return s.userService.CreateInvited(ctx, users.CreateUserRequest{
+		Email:    invitation.Email,
+		Username: request.Username,
+		Password: request.Password,
+	}, invitation.RoleID)
+}
+
+// checkEmailFree comprova que cap usuari (actiu, pendent o desactivat) no
+// tingui ja aquest email
+func (s *invitationService) checkEmailFree(ctx context.Context, email string) error {
+	_, err := s.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return nil
+	}
+	if err == nil || errors.Is(err, users.ErrInactiveUser) || errors.Is(err, users.ErrPendingVerification) {
+		return ErrEmailRegistered
+	}
+	return err
+}
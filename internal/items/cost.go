@@ -0,0 +1,19 @@
+package items
+
+import "math"
+
+// WeightedAverageCost retorna el cost mitjà per unitat base després de rebre
+// quantity unitats a unitCost quan n'hi havia stock a cost. Si l'estoc no era
+// positiu (res o venut en negatiu) el cost anterior no pesa i queda el de la
+// compra.
+func WeightedAverageCost(stock, cost, quantity, unitCost float64) float64 {
+	if stock <= 0 {
+		return roundCost(unitCost)
+	}
+	return roundCost((stock*cost + quantity*unitCost) / (stock + quantity))
+}
+
+// roundCost arrodoneix a 4 decimals, la precisió amb què es guarden els costos
+func roundCost(cost float64) float64 {
+	return math.Round(cost*10000) / 10000
+}
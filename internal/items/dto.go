@@ -1,8 +1,42 @@
 package items
 
+import "github.com/google/uuid"
+
 type ItemRequest struct {
 	Code        string  `json:"code" binding:"required"`
 	Description string  `json:"description" binding:"required"`
-	Cost        float64 `json:"cost" binding:"required"`
+	// Cost inicial; només es fa servir en crear l'article; després és el cost
+	// mitjà que mantenen les recepcions de compra
+	Cost        float64 `json:"cost"`
 	Price       float64 `json:"price" binding:"required"`
-}
\ No newline at end of file
+	CategoryID  *uuid.UUID `json:"category_id"`
+	BaseUnit    string  `json:"base_unit"`
+	// Si no s'envia, un article nou queda actiu i un d'existent es manté com està
+	IsActive    *bool   `json:"is_active"`
+}
+// ImportRequest són els camps del formulari de la importació, a més del fitxer
+type ImportRequest struct {
+	// csv o xlsx; si és buit, el de l'extensió del fitxer
+	Format string `form:"format"`
+	// JSON amb la columna del fitxer de cada camp, p. ex. {"code": "SKU", "price": "PVP"}.
+	// Els camps que no hi són es busquen per nom.
+	Mapping string `form:"mapping"`
+	DryRun  bool   `form:"dry_run"`
+}
+
+// ItemListRequest són els paràmetres de GET /api/items
+type ItemListRequest struct {
+	// Text a buscar al codi i a la descripció
+	Q          string   `form:"q"`
+	CategoryID string   `form:"category_id"`
+	IsActive   *bool    `form:"is_active"`
+	MinPrice   *float64 `form:"min_price"`
+	MaxPrice   *float64 `form:"max_price"`
+	MinCost    *float64 `form:"min_cost"`
+	MaxCost    *float64 `form:"max_cost"`
+	// code, description, price, cost o relevance (només amb q); amb "-" davant, descendent
+	Sort   string `form:"sort"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+	Cursor string `form:"cursor"`
+}
@@ -0,0 +1,16 @@
+package items
+
+import "errors"
+
+var (
+	ErrBaseUnitInUse    = errors.New("the base unit cannot change once the item has stock, movements or unit conversions")
+	ErrModelHasVariants = errors.New("the item is the model of some variants, delete them first")
+
+	ErrInvalidMapping    = errors.New("invalid column mapping")
+	ErrMissingCodeColumn = errors.New("the file has no code column")
+	ErrEmptyFile         = errors.New("the file has no rows")
+
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidSort   = errors.New("invalid sort, expected code, description, price, cost or relevance")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
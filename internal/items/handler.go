@@ -1,11 +1,18 @@
 package items
 
 import (
+	"encoding/json"
+	"errors"
+	"frdy-api/internal/spreadsheet"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxImportSize és la mida màxima del fitxer d'importació
+const maxImportSize = 20 << 20
+
 type ItemHandler struct {
 	service ItemService
 }
@@ -43,7 +50,7 @@ func (h *ItemHandler) Create(c *gin.Context) {
 
 // Update godoc
 // @Summary Update an item
-// @Description Updates an existing item with the provided information
+// @Description Updates an existing item with the provided information. The cost is not changed: it is the weighted average cost kept by purchase receipts. Without base_unit the current base unit is kept; it can only change while the item has no stock, purchase or sales lines, or unit conversions. Without is_active the item stays active or inactive as it is
 // @Tags items
 // @Accept json
 // @Produce json
@@ -51,6 +58,7 @@ func (h *ItemHandler) Create(c *gin.Context) {
 // @Param request body ItemRequest true "Item data"
 // @Success 200 {object} Item "Item updated successfully"
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "Base unit in use"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/items/{id} [put]
 // @Security BearerAuth
@@ -64,7 +72,11 @@ func (h *ItemHandler) Update(c *gin.Context) {
 
 	item, err := h.service.Update(id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, ErrBaseUnitInUse) {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -73,19 +85,24 @@ func (h *ItemHandler) Update(c *gin.Context) {
 
 // Delete godoc
 // @Summary Delete an item
-// @Description Deletes an item by its ID
+// @Description Deletes an item by its ID. A model cannot be deleted while it still has variants.
 // @Tags items
 // @Accept json
 // @Produce json
 // @Param id path string true "Item ID"
 // @Success 204 "Item deleted successfully"
+// @Failure 409 {object} map[string]string "The item is the model of some variants"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/items/{id} [delete]
 // @Security BearerAuth
 func (h *ItemHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, ErrModelHasVariants) {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -137,21 +154,169 @@ func (h *ItemHandler) FindByCode(c *gin.Context) {
 }
 
 // FindAll godoc
-// @Summary Get all items
-// @Description Retrieves all items
+// @Summary Search items
+// @Description Retrieves a page of items. Free-text search matches the code and description (also with small typos) and sorts by relevance unless another sort is given. Filters can be combined; the category filter includes subcategories. Pages are selected with offset, or with the next_cursor of the previous page for long listings. The response includes the total number of matching items
 // @Tags items
 // @Accept json
 // @Produce json
-// @Success 200 {array} Item "List of items"
+// @Param q query string false "Text to search in code and description"
+// @Param category_id query string false "Category ID"
+// @Param is_active query bool false "Only active or inactive items"
+// @Param min_price query number false "Minimum price"
+// @Param max_price query number false "Maximum price"
+// @Param min_cost query number false "Minimum cost"
+// @Param max_cost query number false "Maximum cost"
+// @Param sort query string false "code (default), description, price, cost or relevance; prefix with - for descending"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Items to skip"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} ItemPage "Page of items"
+// @Failure 400 {object} map[string]string "Invalid filter, sort or cursor"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/items [get]
 // @Security BearerAuth
 func (h *ItemHandler) FindAll(c *gin.Context) {
-	items, err := h.service.FindAll()
+	var request ItemListRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.FindAll(request)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidFilter) || errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// FindCostHistory godoc
+// @Summary Get the cost history of an item
+// @Description Retrieves the changes of the item's weighted average cost caused by received purchases, newest first. Quantities and costs are per base unit
+// @Tags items
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {array} CostEntry "Cost history"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/items/{id}/cost-history [get]
+// @Security BearerAuth
+func (h *ItemHandler) FindCostHistory(c *gin.Context) {
+	history, err := h.service.FindCostHistory(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, items)
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, history)
+}
+
+// Import godoc
+// @Summary Import items from a CSV or XLSX file
+// @Description Creates or updates items by code from the first sheet of the file. Columns are matched by name (code, description, cost, price, is_active, category_id, base_unit) or through the mapping; empty cells keep the current value and a stock column is ignored. The cost is only read for new items; existing items keep their weighted average cost. Everything is saved in one transaction: if any row is invalid nothing is saved and the errors are returned per row. With dry_run the file is only validated (Protected route)
+// @Tags items
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param format formData string false "csv or xlsx (default: from the file extension)"
+// @Param mapping formData string false "JSON object from field to file column, e.g. {\"code\": \"SKU\"}"
+// @Param dry_run formData bool false "Validate without saving"
+// @Success 200 {object} ImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 422 {object} ImportResult
+// @Failure 500 {object} map[string]string
+// @Router /api/items/import [post]
+// @Security BearerAuth
+func (h *ItemHandler) Import(c *gin.Context) {
+	var request ImportRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if header.Size > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large"})
+		return
+	}
+	format, err := spreadsheet.Format(request.Format, header.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var mapping map[string]string
+	if request.Mapping != "" {
+		if err := json.Unmarshal([]byte(request.Mapping), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidMapping.Error()})
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Import(format, data, mapping, request.DryRun)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, spreadsheet.ErrInvalidFile) || errors.Is(err, ErrInvalidMapping) ||
+			errors.Is(err, ErrMissingCodeColumn) || errors.Is(err, ErrEmptyFile) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Export godoc
+// @Summary Export items to a CSV or XLSX file
+// @Description Downloads all items, optionally only those in a category and its subcategories, with their stock in base units. The columns are the ones the import reads, plus stock (Protected route)
+// @Tags items
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default) or xlsx"
+// @Param category_id query string false "Category ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/export [get]
+// @Security BearerAuth
+func (h *ItemHandler) Export(c *gin.Context) {
+	format, err := spreadsheet.Format(c.DefaultQuery("format", spreadsheet.FormatCSV), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Export(format, c.Query("category_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="items.`+format+`"`)
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), data)
+}
@@ -0,0 +1,275 @@
+package items
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"frdy-api/internal/spreadsheet"
+	"frdy-api/internal/units"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Columnes que es poden importar, en l'ordre en què s'exporten. L'exportació
+// hi afegeix l'estoc, que a la importació s'ignora: l'estoc només canvia amb
+// moviments.
+var importColumns = []string{"code", "description", "cost", "price", "is_active", "category_id", "base_unit"}
+
+const exportStockColumn = "stock"
+
+// Import crea o actualitza articles (pel codi) a partir d'un CSV o XLSX. Una
+// cel·la buida deixa el valor que l'article ja tenia. Si alguna fila té
+// errors no es desa res; amb dryRun només es valida.
+func (s *itemService) Import(format string, data []byte, mapping map[string]string, dryRun bool) (ImportResult, error) {
+	rows, err := spreadsheet.Read(format, data)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if len(rows) == 0 {
+		return ImportResult{}, ErrEmptyFile
+	}
+	columns, err := mapColumns(rows[0], mapping)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	// Files amb contingut i el número de línia que veu l'usuari
+	type record struct {
+		line   int
+		fields map[string]string
+	}
+	var records []record
+	var codes []string
+	for i, row := range rows[1:] {
+		fields := make(map[string]string, len(columns))
+		empty := true
+		for field, col := range columns {
+			if col < len(row) {
+				fields[field] = strings.TrimSpace(row[col])
+				empty = empty && fields[field] == ""
+			}
+		}
+		if empty {
+			continue
+		}
+		records = append(records, record{line: i + 2, fields: fields})
+		codes = append(codes, fields["code"])
+	}
+
+	existing, err := s.repo.FindByCodes(codes)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	knownUnits, err := s.repo.KnownUnits()
+	if err != nil {
+		return ImportResult{}, err
+	}
+	knownCategories, err := s.repo.KnownCategories()
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result := ImportResult{DryRun: dryRun, Rows: len(records), Errors: []ImportError{}}
+	var created, updated []Item
+	seen := make(map[string]int)
+	for _, rec := range records {
+		fail := func(column, message string) {
+			result.Errors = append(result.Errors, ImportError{Row: rec.line, Column: column, Error: message})
+		}
+
+		code := rec.fields["code"]
+		if code == "" {
+			fail("code", "code is required")
+			continue
+		}
+		if first, ok := seen[code]; ok {
+			fail("code", fmt.Sprintf("duplicate code, already in row %d", first))
+			continue
+		}
+		seen[code] = rec.line
+
+		item, exists := existing[code]
+		if !exists {
+			item = Item{ID: uuid.New(), Code: code, IsActive: true, BaseUnit: units.DefaultUnit}
+		}
+		errorsBefore := len(result.Errors)
+
+		if v, ok := rec.fields["description"]; ok && v != "" {
+			item.Description = v
+		}
+		// El cost només és l'inicial dels articles nous: el dels que ja
+		// existeixen és el cost mitjà de les compres
+		if v, ok := rec.fields["cost"]; ok && v != "" && !exists {
+			if n, err := parseNumber(v); err != nil {
+				fail("cost", "not a number")
+			} else {
+				item.Cost = n
+			}
+		}
+		if v, ok := rec.fields["price"]; ok && v != "" {
+			if n, err := parseNumber(v); err != nil {
+				fail("price", "not a number")
+			} else {
+				item.Price = n
+			}
+		}
+		if v, ok := rec.fields["is_active"]; ok && v != "" {
+			if b, err := parseBool(v); err != nil {
+				fail("is_active", "expected true or false")
+			} else {
+				item.IsActive = b
+			}
+		}
+		if v, ok := rec.fields["category_id"]; ok && v != "" {
+			if id, err := uuid.Parse(v); err != nil || !knownCategories[id] {
+				fail("category_id", "unknown category")
+			} else {
+				item.CategoryID = &id
+			}
+		}
+		if v, ok := rec.fields["base_unit"]; ok && v != "" {
+			if unit := units.NormalizeCode(v); !knownUnits[unit] {
+				fail("base_unit", "unknown unit")
+			} else if exists {
+				if unit, err := s.changeBaseUnit(item, unit); err == ErrBaseUnitInUse {
+					fail("base_unit", err.Error())
+				} else if err != nil {
+					return ImportResult{}, err
+				} else {
+					item.BaseUnit = unit
+				}
+			} else {
+				item.BaseUnit = unit
+			}
+		}
+
+		// Les mateixes regles que en crear un article un per un
+		if item.Description == "" {
+			fail("description", "description is required")
+		}
+		if item.Cost <= 0 {
+			fail("cost", "cost must be greater than 0")
+		}
+		if item.Price <= 0 {
+			fail("price", "price must be greater than 0")
+		}
+		if len(result.Errors) > errorsBefore {
+			continue
+		}
+
+		if exists {
+			updated = append(updated, item)
+		} else {
+			created = append(created, item)
+		}
+	}
+
+	result.Created, result.Updated = len(created), len(updated)
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+	if err := s.repo.Import(created, updated); err != nil {
+		return ImportResult{}, err
+	}
+	return result, nil
+}
+
+// Export escriu els articles, amb l'estoc, amb les mateixes columnes que
+// llegeix Import
+func (s *itemService) Export(format, categoryID string) ([]byte, error) {
+	var category *uuid.UUID
+	if categoryID != "" {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			return nil, errors.New("invalid category ID format")
+		}
+		category = &id
+	}
+	items, err := s.repo.FindAllWithStock(category)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append(append([]string{}, importColumns...), exportStockColumn)
+	rows := make([][]interface{}, len(items))
+	for i, item := range items {
+		categoryCell := ""
+		if item.CategoryID != nil {
+			categoryCell = item.CategoryID.String()
+		}
+		rows[i] = []interface{}{item.Code, item.Description, item.Cost, item.Price, item.IsActive, categoryCell, item.BaseUnit, item.Stock}
+	}
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(format, &buf, header, rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mapColumns troba la columna del fitxer de cada camp: la del mapatge o, si
+// no n'hi ha, la que es diu com el camp
+func mapColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := index[name]; !ok && name != "" {
+			index[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, column := range mapping {
+		if !isImportColumn(field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidMapping, field)
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("%w: column %q not found in the file", ErrInvalidMapping, column)
+		}
+		columns[field] = i
+	}
+	for _, field := range importColumns {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if i, ok := index[field]; ok {
+			columns[field] = i
+		}
+	}
+
+	if _, ok := columns["code"]; !ok {
+		return nil, ErrMissingCodeColumn
+	}
+	return columns, nil
+}
+
+func isImportColumn(field string) bool {
+	for _, c := range importColumns {
+		if c == field {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNumber accepta el punt o la coma decimal ("12.5" o "12,5")
+func parseNumber(value string) (float64, error) {
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "y", "si", "sí", "s":
+		return true, nil
+	case "0", "false", "no", "n":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean %q", value)
+	}
+}
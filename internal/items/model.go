@@ -1,12 +1,99 @@
 package items
 
-import "github.com/google/uuid"
+import (
+	"frdy-api/internal/attachments"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Item struct {
 	ID 			uuid.UUID `json:"id" binding:"required"`
 	Code        string  `json:"code" binding:"required"`
 	Description string  `json:"description" binding:"required"`
+	// Cost mitjà ponderat per unitat base; es recalcula a cada recepció de compra
 	Cost        float64 `json:"cost" binding:"required"`
+	// Cost per unitat base de l'última compra rebuda; nul si no se n'ha rebut cap
+	LastPurchaseCost *float64 `json:"last_purchase_cost"`
 	Price       float64 `json:"price" binding:"required"`
 	IsActive	bool    `json:"is_active" binding:"required"`
-}
\ No newline at end of file
+	CategoryID  *uuid.UUID `json:"category_id"`
+	BaseUnit    string  `json:"base_unit"`
+	// Imatges adjuntes a l'article, de la més antiga a la més nova
+	Images      []attachments.Image `json:"images,omitempty"`
+}
+
+// CostEntry és un canvi del cost mitjà d'un article per la recepció d'una
+// línia de compra. Les quantitats i els costos són en la unitat base.
+type CostEntry struct {
+	ID               uuid.UUID `json:"id"`
+	ItemID           uuid.UUID `json:"item_id"`
+	PurchaseHeaderID uuid.UUID `json:"purchase_header_id"`
+	PurchaseDetailID uuid.UUID `json:"purchase_detail_id"`
+	Quantity         float64   `json:"quantity"`
+	UnitCost         float64   `json:"unit_cost"`
+	StockBefore      float64   `json:"stock_before"`
+	CostBefore       float64   `json:"cost_before"`
+	CostAfter        float64   `json:"cost_after"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ItemStock és un article amb l'estoc en unitats base, per a l'exportació
+type ItemStock struct {
+	Item
+	Stock float64 `json:"stock"`
+}
+
+// ImportResult resumeix una importació. Si hi ha errors no s'ha desat res.
+type ImportResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Rows    int           `json:"rows"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Errors  []ImportError `json:"errors"`
+}
+
+// ImportError és un error d'una fila del fitxer; Row és el número de línia
+// tal com es veu al full de càlcul (la capçalera és la 1)
+type ImportError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// ItemFilter són els criteris de cerca, ordre i paginació de la llista
+// d'articles. After és el cursor de l'últim article de la pàgina anterior i
+// exclou Offset.
+type ItemFilter struct {
+	Search     string
+	CategoryID *uuid.UUID
+	IsActive   *bool
+	MinPrice   *float64
+	MaxPrice   *float64
+	MinCost    *float64
+	MaxCost    *float64
+	Sort       string
+	Desc       bool
+	Limit      int
+	Offset     int
+	After      *ItemCursor
+}
+
+// ItemCursor és la posició de l'últim article d'una pàgina: el valor de la
+// columna d'ordenació i l'ID, que desfà els empats
+type ItemCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// ItemPage és una pàgina de la llista d'articles. Total compta tots els que
+// compleixen els filtres; NextCursor és buit a l'última pàgina.
+type ItemPage struct {
+	Items      []Item `json:"items"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
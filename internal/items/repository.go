@@ -2,9 +2,14 @@ package items
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"frdy-api/internal/attachments"
+	"frdy-api/internal/categories"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type ItemRepository interface {
@@ -13,22 +18,43 @@ type ItemRepository interface {
 	Delete(id uuid.UUID) error
 	FindByID(id uuid.UUID) (Item, error)
 	FindByCode(code string) (Item, error)
-	FindAll() ([]Item, error)
+	// FindAll retorna els articles de la pàgina i quants compleixen els filtres
+	FindAll(filter ItemFilter) ([]Item, int, error)
+
+	FindCostHistory(itemID uuid.UUID) ([]CostEntry, error)
+	// BaseUnitInUse indica si l'article ja té estoc, línies de compra o venda
+	// o conversions, que estan comptades en la unitat base actual
+	BaseUnitInUse(id uuid.UUID) (bool, error)
+
+	FindByCodes(codes []string) (map[string]Item, error)
+	FindAllWithStock(categoryID *uuid.UUID) ([]ItemStock, error)
+	KnownUnits() (map[string]bool, error)
+	KnownCategories() (map[uuid.UUID]bool, error)
+	// Import crea i actualitza els articles en una sola transacció
+	Import(created, updated []Item) error
+}
+
+// VariantPrices fa que les variants sense preu propi segueixin el preu del
+// seu model, dins de la transacció que l'ha canviat. L'implementa el
+// repositori de variants.
+type VariantPrices interface {
+	UpdateModelPrices(tx *sql.Tx, modelID uuid.UUID, price float64) error
 }
 
 type itemRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	variants VariantPrices
 }
 
-func NewItemRepository(db *sql.DB) ItemRepository {
-	return &itemRepository{db: db}
+func NewItemRepository(db *sql.DB, variants VariantPrices) ItemRepository {
+	return &itemRepository{db: db, variants: variants}
 }
 
 func (r *itemRepository) Create(item Item) (Item, error) {
 	_, err := r.db.Exec(`
-		INSERT INTO items (id, code, description, cost, price, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
-		item.ID, item.Code, item.Description, item.Cost, item.Price, item.IsActive,
+		INSERT INTO items (id, code, description, cost, price, is_active, category_id, base_unit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		item.ID, item.Code, item.Description, item.Cost, item.Price, item.IsActive, item.CategoryID, item.BaseUnit,
 	)
 	if err != nil {
 		return Item{}, err
@@ -37,13 +63,30 @@ func (r *itemRepository) Create(item Item) (Item, error) {
 }
 
 func (r *itemRepository) Update(item Item) (Item, error) {
-	_, err := r.db.Exec(`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return Item{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
 		UPDATE items
-		SET code = $1, description = $2, cost = $3, price = $4, is_active = $5
-		WHERE id = $6`,
-		item.Code, item.Description, item.Cost, item.Price, item.IsActive, item.ID,
-	)
+		SET code = $1, description = $2, price = $3, is_active = $4, category_id = $5, base_unit = $6
+		WHERE id = $7
+		RETURNING cost, last_purchase_cost`,
+		item.Code, item.Description, item.Price, item.IsActive, item.CategoryID, item.BaseUnit, item.ID,
+	).Scan(&item.Cost, &item.LastPurchaseCost)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return Item{}, fmt.Errorf("reference not found: %w", err)
+		}
+		return Item{}, err
+	}
+
+	if err := r.variants.UpdateModelPrices(tx, item.ID, item.Price); err != nil {
+		return Item{}, err
+	}
+	if err := tx.Commit(); err != nil {
 		return Item{}, err
 	}
 	return item, nil
@@ -54,61 +97,385 @@ func (r *itemRepository) Delete(id uuid.UUID) error {
 		DELETE FROM items
 		WHERE id = $1`, id,
 	)
+	if isModelWithVariants(err) {
+		return ErrModelHasVariants
+	}
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// isModelWithVariants indica si l'article no s'ha pogut esborrar perquè és
+// el model d'alguna variant
+func isModelWithVariants(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503" && pqErr.Constraint == "item_variants_model_id_fkey"
+}
+
 func (r *itemRepository) FindByID(id uuid.UUID) (Item, error) {
 	var item Item
 	err := r.db.QueryRow(`
-		SELECT id, code, description, cost, price, is_active
+		SELECT id, code, description, cost, last_purchase_cost, price, is_active, category_id, base_unit
 		FROM items
 		WHERE id = $1`, id,
-	).Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.Price, &item.IsActive)
+	).Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.LastPurchaseCost, &item.Price, &item.IsActive, &item.CategoryID, &item.BaseUnit)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return Item{}, fmt.Errorf("reference not found: %w", err)
 		}
 		return Item{}, err
 	}
-	return item, nil
+	return r.withImages(item)
 }
 
 func (r *itemRepository) FindByCode(code string) (Item, error) {
 	var item Item
 	err := r.db.QueryRow(`
-		SELECT id, code, description, cost, price, is_active
+		SELECT id, code, description, cost, last_purchase_cost, price, is_active, category_id, base_unit
 		FROM items
 		WHERE code = $1`, code,
-	).Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.Price, &item.IsActive)
+	).Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.LastPurchaseCost, &item.Price, &item.IsActive, &item.CategoryID, &item.BaseUnit)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return Item{}, fmt.Errorf("reference not found: %w", err)
 		}
 		return Item{}, err
 	}
-	return item, nil
+	return r.withImages(item)
 }
 
-func (r *itemRepository) FindAll() ([]Item, error) {
+func (r *itemRepository) FindAll(filter ItemFilter) ([]Item, int, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// La cerca de text fa servir els índexs de trigrames: ILIKE troba el text
+	// dins del codi o la descripció i % admet faltes d'ortografia
+	var search string
+	if filter.Search != "" {
+		search = arg(filter.Search)
+		like := arg("%" + escapeLike(filter.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf("(code ILIKE %[1]s OR description ILIKE %[1]s OR description %% %[2]s)", like, search))
+	}
+	if filter.CategoryID != nil {
+		conditions = append(conditions, "category_id IN "+categories.SubtreeIDs(arg(*filter.CategoryID)+"::uuid"))
+	}
+	if filter.IsActive != nil {
+		conditions = append(conditions, "is_active = "+arg(*filter.IsActive))
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= "+arg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= "+arg(*filter.MaxPrice))
+	}
+	if filter.MinCost != nil {
+		conditions = append(conditions, "cost >= "+arg(*filter.MinCost))
+	}
+	if filter.MaxCost != nil {
+		conditions = append(conditions, "cost <= "+arg(*filter.MaxCost))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT count(*) FROM items`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	direction, compare := "", ">"
+	if filter.Desc {
+		direction, compare = " DESC", "<"
+	}
+	var order string
+	if filter.Sort == sortRelevance {
+		order = fmt.Sprintf("GREATEST(similarity(code, %[1]s), similarity(description, %[1]s)) DESC, code, id", search)
+	} else {
+		order = fmt.Sprintf("%[1]s%[2]s, id%[2]s", filter.Sort, direction)
+		if filter.After != nil {
+			keyset := fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+				filter.Sort, compare, arg(filter.After.Value), sortColumns[filter.Sort], arg(filter.After.ID))
+			if where == "" {
+				where = " WHERE " + keyset
+			} else {
+				where += " AND " + keyset
+			}
+		}
+	}
+
 	rows, err := r.db.Query(`
-		SELECT id, code, description, cost, price, is_active
-		FROM items`)
-		
+		SELECT id, code, description, cost, last_purchase_cost, price, is_active, category_id, base_unit
+		FROM items`+where+`
+		ORDER BY `+order+`
+		LIMIT `+arg(filter.Limit)+` OFFSET `+arg(filter.Offset), args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var items []Item
 	for rows.Next() {
 		var item Item
-		if err := rows.Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.Price, &item.IsActive); err != nil {
+		if err := rows.Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.LastPurchaseCost, &item.Price, &item.IsActive, &item.CategoryID, &item.BaseUnit); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadImages(items); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *itemRepository) withImages(item Item) (Item, error) {
+	items := []Item{item}
+	if err := r.loadImages(items); err != nil {
+		return Item{}, err
+	}
+	return items[0], nil
+}
+
+// loadImages omple les imatges dels articles amb una sola consulta
+func (r *itemRepository) loadImages(items []Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	index := make(map[uuid.UUID]int, len(items))
+	ids := make([]string, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+		ids[i] = item.ID.String()
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, item_id, thumbnail_key IS NOT NULL
+		FROM item_attachments
+		WHERE kind = $1 AND item_id = ANY($2::uuid[])
+		ORDER BY created_at, id`, attachments.KindImage, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("error querying item images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, itemID uuid.UUID
+		var hasThumbnail bool
+		if err := rows.Scan(&id, &itemID, &hasThumbnail); err != nil {
+			return fmt.Errorf("error scanning item image: %w", err)
+		}
+		image := attachments.Image{ID: id, URL: attachments.ContentURL(itemID, id)}
+		if hasThumbnail {
+			image.ThumbnailURL = attachments.ThumbnailURL(itemID, id)
+		}
+		i := index[itemID]
+		items[i].Images = append(items[i].Images, image)
+	}
+	return rows.Err()
+}
+
+// ApplyPurchaseCost recalcula el cost mitjà de l'article amb l'entrada rebuda
+// i l'estoc que hi havia abans, i ho deixa anotat a l'historial. Es fa dins
+// de la transacció de la recepció: la fila de l'article queda bloquejada fins
+// que s'hi ha sumat també l'estoc, perquè dues recepcions simultànies no
+// calculin la mitjana a partir del mateix estoc.
+func ApplyPurchaseCost(tx *sql.Tx, entry CostEntry) (CostEntry, error) {
+	if entry.Quantity <= 0 || entry.UnitCost < 0 {
+		return CostEntry{}, errors.New("invalid request")
+	}
+	entry.ID = uuid.New()
+	entry.UnitCost = roundCost(entry.UnitCost)
+
+	err := tx.QueryRow(`
+		SELECT i.cost, COALESCE(s.quantity, 0)
+		FROM items i
+			LEFT JOIN stocks s ON s.item_id = i.id
+		WHERE i.id = $1
+		FOR UPDATE OF i`, entry.ItemID,
+	).Scan(&entry.CostBefore, &entry.StockBefore)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CostEntry{}, fmt.Errorf("reference not found: %w", err)
+		}
+		return CostEntry{}, err
+	}
+	entry.CostAfter = WeightedAverageCost(entry.StockBefore, entry.CostBefore, entry.Quantity, entry.UnitCost)
+
+	if _, err := tx.Exec(`
+		UPDATE items
+		SET cost = $1, last_purchase_cost = $2
+		WHERE id = $3`,
+		entry.CostAfter, entry.UnitCost, entry.ItemID,
+	); err != nil {
+		return CostEntry{}, err
+	}
+	err = tx.QueryRow(`
+		INSERT INTO item_cost_history (id, item_id, purchase_header_id, purchase_detail_id, quantity, unit_cost, stock_before, cost_before, cost_after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at`,
+		entry.ID, entry.ItemID, entry.PurchaseHeaderID, entry.PurchaseDetailID, entry.Quantity, entry.UnitCost, entry.StockBefore, entry.CostBefore, entry.CostAfter,
+	).Scan(&entry.CreatedAt)
+	if err != nil {
+		return CostEntry{}, err
+	}
+	return entry, nil
+}
+
+func (r *itemRepository) BaseUnitInUse(id uuid.UUID) (bool, error) {
+	var inUse bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM stocks WHERE item_id = $1 AND quantity <> 0)
+			OR EXISTS (SELECT 1 FROM purchase_details WHERE item_id = $1)
+			OR EXISTS (SELECT 1 FROM sales_details WHERE item_id = $1)
+			OR EXISTS (SELECT 1 FROM item_units WHERE item_id = $1)`, id,
+	).Scan(&inUse)
+	return inUse, err
+}
+
+// FindCostHistory retorna els canvis de cost de l'article, del més recent al
+// més antic
+func (r *itemRepository) FindCostHistory(itemID uuid.UUID) ([]CostEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, item_id, purchase_header_id, purchase_detail_id, quantity, unit_cost, stock_before, cost_before, cost_after, created_at
+		FROM item_cost_history
+		WHERE item_id = $1
+		ORDER BY created_at DESC`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []CostEntry{}
+	for rows.Next() {
+		var entry CostEntry
+		if err := rows.Scan(&entry.ID, &entry.ItemID, &entry.PurchaseHeaderID, &entry.PurchaseDetailID, &entry.Quantity, &entry.UnitCost,
+			&entry.StockBefore, &entry.CostBefore, &entry.CostAfter, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, rows.Err()
+}
+
+func (r *itemRepository) FindByCodes(codes []string) (map[string]Item, error) {
+	rows, err := r.db.Query(`
+		SELECT id, code, description, cost, last_purchase_cost, price, is_active, category_id, base_unit
+		FROM items
+		WHERE code = ANY($1)`, pq.Array(codes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]Item)
+	for rows.Next() {
+		var item Item
+		if err := rows.Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.LastPurchaseCost, &item.Price, &item.IsActive, &item.CategoryID, &item.BaseUnit); err != nil {
+			return nil, err
+		}
+		found[item.Code] = item
+	}
+	return found, rows.Err()
+}
+
+// FindAllWithStock és com FindAll però amb l'estoc de cada article
+func (r *itemRepository) FindAllWithStock(categoryID *uuid.UUID) ([]ItemStock, error) {
+	rows, err := r.db.Query(`
+		SELECT i.id, i.code, i.description, i.cost, i.last_purchase_cost, i.price, i.is_active, i.category_id, i.base_unit, COALESCE(s.quantity, 0)
+		FROM items i
+			LEFT JOIN stocks s ON s.item_id = i.id
+		WHERE $1::uuid IS NULL OR i.category_id IN `+categories.SubtreeIDs("$1")+`
+		ORDER BY i.code`, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ItemStock
+	for rows.Next() {
+		var item ItemStock
+		if err := rows.Scan(&item.ID, &item.Code, &item.Description, &item.Cost, &item.LastPurchaseCost, &item.Price, &item.IsActive, &item.CategoryID, &item.BaseUnit, &item.Stock); err != nil {
 			return nil, err
 		}
 		items = append(items, item)
 	}
-	return items, nil
-}
\ No newline at end of file
+	return items, rows.Err()
+}
+
+func (r *itemRepository) KnownUnits() (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT code FROM units`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[string]bool)
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		known[code] = true
+	}
+	return known, rows.Err()
+}
+
+func (r *itemRepository) KnownCategories() (map[uuid.UUID]bool, error) {
+	rows, err := r.db.Query(`SELECT id FROM categories`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		known[id] = true
+	}
+	return known, rows.Err()
+}
+
+func (r *itemRepository) Import(created, updated []Item) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range created {
+		if _, err := tx.Exec(`
+			INSERT INTO items (id, code, description, cost, price, is_active, category_id, base_unit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			item.ID, item.Code, item.Description, item.Cost, item.Price, item.IsActive, item.CategoryID, item.BaseUnit,
+		); err != nil {
+			return fmt.Errorf("error creating item %s: %w", item.Code, err)
+		}
+	}
+	for _, item := range updated {
+		if _, err := tx.Exec(`
+			UPDATE items
+			SET description = $1, price = $2, is_active = $3, category_id = $4, base_unit = $5
+			WHERE id = $6`,
+			item.Description, item.Price, item.IsActive, item.CategoryID, item.BaseUnit, item.ID,
+		); err != nil {
+			return fmt.Errorf("error updating item %s: %w", item.Code, err)
+		}
+		if err := r.variants.UpdateModelPrices(tx, item.ID, item.Price); err != nil {
+			return fmt.Errorf("error updating item %s: %w", item.Code, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -6,9 +6,12 @@ func RegisterRoutes(router *gin.RouterGroup, handler *ItemHandler) {
 	items := router.Group("/items")
 	{
 		items.POST("", handler.Create)
+		items.POST("/import", handler.Import)
+		items.GET("/export", handler.Export)
 		items.PUT("/:id", handler.Update)
 		items.DELETE("/:id", handler.Delete)
 		items.GET("/:id", handler.FindByID)
+		items.GET("/:id/cost-history", handler.FindCostHistory)
 		items.GET("/code/:code", handler.FindByCode)
 		items.GET("", handler.FindAll)
 	}
@@ -0,0 +1,151 @@
+package items
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 50
+	sortRelevance   = "relevance"
+)
+
+// sortColumns són les columnes per on es pot ordenar la llista i el tipus
+// amb què es compara el valor del cursor
+var sortColumns = map[string]string{
+	"code":        "text",
+	"description": "text",
+	"price":       "numeric",
+	"cost":        "numeric",
+}
+
+// FindAll retorna una pàgina d'articles que compleixen els filtres. Es pot
+// paginar per offset o, per a llistes llargues, amb el cursor de la pàgina
+// anterior.
+func (s *itemService) FindAll(request ItemListRequest) (ItemPage, error) {
+	filter, err := newItemFilter(request)
+	if err != nil {
+		return ItemPage{}, err
+	}
+
+	// Es demana un article de més per saber si hi ha una pàgina següent
+	limit := filter.Limit
+	filter.Limit++
+	items, total, err := s.repo.FindAll(filter)
+	if err != nil {
+		return ItemPage{}, err
+	}
+
+	page := ItemPage{Items: items, Total: total, Limit: limit, Offset: filter.Offset}
+	if page.Items == nil {
+		page.Items = []Item{}
+	}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		if filter.Sort != sortRelevance {
+			page.NextCursor = encodeCursor(filter, page.Items[limit-1])
+		}
+	}
+	return page, nil
+}
+
+func newItemFilter(request ItemListRequest) (ItemFilter, error) {
+	filter := ItemFilter{
+		Search:   strings.TrimSpace(request.Q),
+		IsActive: request.IsActive,
+		MinPrice: request.MinPrice,
+		MaxPrice: request.MaxPrice,
+		MinCost:  request.MinCost,
+		MaxCost:  request.MaxCost,
+		Limit:    request.Limit,
+		Offset:   request.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageSize
+	}
+	if request.CategoryID != "" {
+		id, err := uuid.Parse(request.CategoryID)
+		if err != nil {
+			return ItemFilter{}, fmt.Errorf("%w: invalid category ID format", ErrInvalidFilter)
+		}
+		filter.CategoryID = &id
+	}
+	if outOfOrder(filter.MinPrice, filter.MaxPrice) || outOfOrder(filter.MinCost, filter.MaxCost) {
+		return ItemFilter{}, fmt.Errorf("%w: minimum is greater than maximum", ErrInvalidFilter)
+	}
+
+	// Per defecte, per codi; si es busca un text, els més semblants primer
+	sort := strings.TrimSpace(request.Sort)
+	filter.Desc = strings.HasPrefix(sort, "-")
+	filter.Sort = strings.TrimPrefix(sort, "-")
+	if filter.Sort == "" {
+		filter.Sort = "code"
+		if filter.Search != "" {
+			filter.Sort = sortRelevance
+		}
+	}
+	if filter.Sort == sortRelevance {
+		if filter.Search == "" || filter.Desc {
+			return ItemFilter{}, ErrInvalidSort
+		}
+	} else if _, ok := sortColumns[filter.Sort]; !ok {
+		return ItemFilter{}, ErrInvalidSort
+	}
+
+	if request.Cursor != "" {
+		if filter.Offset > 0 || filter.Sort == sortRelevance {
+			return ItemFilter{}, fmt.Errorf("%w: a cursor cannot be combined with offset or relevance sort", ErrInvalidFilter)
+		}
+		cursor, err := decodeCursor(request.Cursor)
+		if err != nil || cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+			return ItemFilter{}, ErrInvalidCursor
+		}
+		filter.After = &cursor
+	}
+	return filter, nil
+}
+
+func outOfOrder(min, max *float64) bool {
+	return min != nil && max != nil && *min > *max
+}
+
+func encodeCursor(filter ItemFilter, last Item) string {
+	cursor := ItemCursor{Sort: filter.Sort, Desc: filter.Desc, ID: last.ID}
+	switch filter.Sort {
+	case "code":
+		cursor.Value = last.Code
+	case "description":
+		cursor.Value = last.Description
+	case "price":
+		cursor.Value = strconv.FormatFloat(last.Price, 'f', -1, 64)
+	case "cost":
+		cursor.Value = strconv.FormatFloat(last.Cost, 'f', -1, 64)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (ItemCursor, error) {
+	var cursor ItemCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ItemCursor{}, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return ItemCursor{}, err
+	}
+	if _, ok := sortColumns[cursor.Sort]; !ok {
+		return ItemCursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// escapeLike escapa els comodins d'ILIKE perquè el text es busqui tal qual
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
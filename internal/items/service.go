@@ -1,7 +1,10 @@
 package items
 
 import (
+	"context"
 	"errors"
+	"frdy-api/internal/attachments"
+	"frdy-api/internal/units"
 
 	"github.com/google/uuid"
 )
@@ -12,15 +15,28 @@ type ItemService interface {
 	Delete(id string) error
 	FindByID(id string) (Item, error)
 	FindByCode(code string) (Item, error)
-	FindAll() ([]Item, error)
+	FindAll(request ItemListRequest) (ItemPage, error)
+
+	FindCostHistory(id string) ([]CostEntry, error)
+
+	Import(format string, data []byte, mapping map[string]string, dryRun bool) (ImportResult, error)
+	Export(format, categoryID string) ([]byte, error)
+}
+
+// AttachmentFiles esborra els fitxers guardats dels adjunts d'un article;
+// les files de la base de dades s'esborren en cascada amb l'article
+type AttachmentFiles interface {
+	ItemFiles(ctx context.Context, itemID uuid.UUID) ([]attachments.Attachment, error)
+	RemoveFiles(ctx context.Context, attachments []attachments.Attachment)
 }
 
 type itemService struct {
-	repo ItemRepository
+	repo  ItemRepository
+	files AttachmentFiles
 }
 
-func NewItemService(repo ItemRepository) ItemService {
-	return &itemService{repo: repo}
+func NewItemService(repo ItemRepository, files AttachmentFiles) ItemService {
+	return &itemService{repo: repo, files: files}
 }
 
 func (s *itemService) Create(item ItemRequest) (Item, error) {
@@ -34,14 +50,18 @@ func (s *itemService) Create(item ItemRequest) (Item, error) {
 		Description: item.Description,
 		Cost:        item.Cost,
 		Price:       item.Price,
-		IsActive:    true, // Default to active
+		IsActive:    item.IsActive == nil || *item.IsActive, // Default to active
+		CategoryID:  item.CategoryID,
+		BaseUnit:    baseUnit(item.BaseUnit),
 	}
 
 	return s.repo.Create(reference)
 }
 
+// Update no toca el cost: és el cost mitjà ponderat de les compres rebudes i
+// un valor escrit a mà el desfaria sense deixar-ne rastre a l'historial
 func (s *itemService) Update(id string, item ItemRequest) (Item, error) {
-	if item.Code == "" || item.Description == "" || item.Cost <= 0 || item.Price <= 0 {
+	if item.Code == "" || item.Description == "" || item.Price <= 0 {
 		return Item{}, errors.New("invalid request")
 	}
 
@@ -50,13 +70,28 @@ func (s *itemService) Update(id string, item ItemRequest) (Item, error) {
 		return Item{}, errors.New("invalid ID format")
 	}
 
+	current, err := s.repo.FindByID(referenceID)
+	if err != nil {
+		return Item{}, err
+	}
+	unit, err := s.changeBaseUnit(current, item.BaseUnit)
+	if err != nil {
+		return Item{}, err
+	}
+
+	isActive := current.IsActive
+	if item.IsActive != nil {
+		isActive = *item.IsActive
+	}
+
 	reference := Item{
 		ID:          referenceID,
 		Code:        item.Code,
 		Description: item.Description,
-		Cost:        item.Cost,
 		Price:       item.Price,
-		IsActive:    true, // Default to active
+		IsActive:    isActive,
+		CategoryID:  item.CategoryID,
+		BaseUnit:    unit,
 	}
 
 	return s.repo.Update(reference)
@@ -68,7 +103,17 @@ func (s *itemService) Delete(id string) error {
 		return errors.New("invalid ID format")
 	}
 
-	return s.repo.Delete(referenceID)
+	ctx := context.Background()
+	files, err := s.files.ItemFiles(ctx, referenceID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(referenceID); err != nil {
+		return err
+	}
+	// Els fitxers només s'esborren si l'article ja no hi és
+	s.files.RemoveFiles(ctx, files)
+	return nil
 }
 
 func (s *itemService) FindByID(id string) (Item, error) {
@@ -88,6 +133,39 @@ func (s *itemService) FindByCode(code string) (Item, error) {
 	return s.repo.FindByCode(code)
 }
 
-func (s *itemService) FindAll() ([]Item, error) {
-	return s.repo.FindAll()
-}
\ No newline at end of file
+func (s *itemService) FindCostHistory(id string) ([]CostEntry, error) {
+	referenceID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+	if _, err := s.repo.FindByID(referenceID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindCostHistory(referenceID)
+}
+
+// changeBaseUnit retorna la unitat base que ha de tenir l'article. Si no se
+// n'indica cap es manté la que té; només es pot canviar mentre no hi ha res
+// comptat en la unitat actual.
+func (s *itemService) changeBaseUnit(item Item, requested string) (string, error) {
+	unit := units.NormalizeCode(requested)
+	if unit == "" || unit == item.BaseUnit {
+		return item.BaseUnit, nil
+	}
+	inUse, err := s.repo.BaseUnitInUse(item.ID)
+	if err != nil {
+		return "", err
+	}
+	if inUse {
+		return "", ErrBaseUnitInUse
+	}
+	return unit, nil
+}
+
+// baseUnit retorna la unitat en què es comptarà l'estoc de l'article
+func baseUnit(code string) string {
+	if code = units.NormalizeCode(code); code == "" {
+		return units.DefaultUnit
+	}
+	return code
+}
@@ -0,0 +1,27 @@
+package jwtkeys
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keys *KeySet
+}
+
+func NewJWKSHandler(keys *KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys that verify the JWT tokens issued by this API. Empty when tokens are signed with a shared secret (Public route)
+// @Tags auth
+// @Produce json
+// @Success 200 {object} JWKS
+// @Router /auth/.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,48 @@
+package jwtkeys
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Issuer genera i refresca els tokens de sessió amb les mateixes claims que
+// el middleware JWT (exp i orig_iat) però signats amb el KeySet.
+type Issuer struct {
+	keys       *KeySet
+	Timeout    time.Duration
+	MaxRefresh time.Duration
+}
+
+func NewIssuer(keys *KeySet, timeout, maxRefresh time.Duration) *Issuer {
+	return &Issuer{keys: keys, Timeout: timeout, MaxRefresh: maxRefresh}
+}
+
+// Generate signa un token nou amb les claims donades
+func (i *Issuer) Generate(claims jwt.MapClaims) (string, time.Time, error) {
+	return i.GenerateWithTTL(claims, i.Timeout)
+}
+
+// GenerateWithTTL signa un token que caduca passat ttl en lloc de Timeout
+func (i *Issuer) GenerateWithTTL(claims jwt.MapClaims, ttl time.Duration) (string, time.Time, error) {
+	token := make(jwt.MapClaims, len(claims)+2)
+	for key, value := range claims {
+		token[key] = value
+	}
+	now := time.Now()
+	expire := now.Add(ttl)
+	token["exp"] = expire.Unix()
+	token["orig_iat"] = now.Unix()
+
+	signed, err := i.keys.Sign(token)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expire, nil
+}
+
+// Refresh torna a signar les claims d'un token vàlid amb una caducitat nova.
+// Cal haver comprovat abans que el token encara es pot refrescar.
+func (i *Issuer) Refresh(claims jwt.MapClaims) (string, time.Time, error) {
+	return i.Generate(claims)
+}
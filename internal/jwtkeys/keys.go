@@ -0,0 +1,190 @@
+// Package jwtkeys signa els tokens JWT de l'API i en publica les claus
+// públiques (JWKS) perquè altres serveis els puguin verificar sense secret.
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algorismes de signatura suportats
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported jwt signing algorithm")
+	ErrUnknownKey           = errors.New("unknown jwt key id")
+	ErrUnexpectedAlgorithm  = errors.New("unexpected jwt signing algorithm")
+)
+
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// KeySet conté la clau amb què se signen els tokens nous i totes les claus
+// amb què se'n poden verificar, identificades pel kid de la capçalera. Per
+// rotar la clau, l'antiga passa a la llista de verificació fins que caduquen
+// els tokens que va signar.
+type KeySet struct {
+	alg        string
+	kid        string
+	signingKey interface{}
+	verify     map[string]verificationKey
+	jwks       JWKS
+}
+
+// NewHMACKeySet manté el comportament antic: un únic secret compartit, sense
+// kid ni claus públiques.
+func NewHMACKeySet(secret []byte) *KeySet {
+	return &KeySet{alg: AlgHS256, signingKey: secret, jwks: JWKS{Keys: []JWK{}}}
+}
+
+// Load crea el joc de claus per a l'algorisme indicat. Amb HS256 s'utilitza
+// el secret; amb RS256 i EdDSA la clau privada de privateKeyFile (PEM).
+// verificationKeyFiles són claus (públiques o privades) que encara s'accepten
+// per verificar, p.ex. la clau anterior durant una rotació.
+func Load(alg string, secret []byte, privateKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	if alg == AlgHS256 {
+		return NewHMACKeySet(secret), nil
+	}
+	if alg != AlgRS256 && alg != AlgEdDSA {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+
+	key, err := readPEM(privateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, public, keyAlg, err := splitKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", privateKeyFile, err)
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("%s: signing key must be a private key", privateKeyFile)
+	}
+	if keyAlg != alg {
+		return nil, fmt.Errorf("%s: key type does not match algorithm %s", privateKeyFile, alg)
+	}
+
+	ks := &KeySet{alg: alg, signingKey: signer, verify: make(map[string]verificationKey), jwks: JWKS{Keys: []JWK{}}}
+	if ks.kid, err = ks.addVerificationKey(public, keyAlg); err != nil {
+		return nil, err
+	}
+	for _, file := range verificationKeyFiles {
+		key, err := readPEM(file)
+		if err != nil {
+			return nil, err
+		}
+		_, public, keyAlg, err := splitKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if _, err := ks.addVerificationKey(public, keyAlg); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Algorithm() string {
+	return ks.alg
+}
+
+// Sign signa les claims amb la clau activa i hi posa el kid a la capçalera
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.alg), claims)
+	if ks.kid != "" {
+		token.Header["kid"] = ks.kid
+	}
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc retorna la clau per verificar un token. Es fa servir com a KeyFunc
+// del middleware JWT.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks.alg == AlgHS256 {
+		if token.Method.Alg() != AlgHS256 {
+			return nil, ErrUnexpectedAlgorithm
+		}
+		return ks.signingKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.verify[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// L'algorisme ve del token: s'ha de comprovar que és el de la clau
+	if token.Method.Alg() != key.alg {
+		return nil, ErrUnexpectedAlgorithm
+	}
+	return key.key, nil
+}
+
+// JWKS retorna les claus públiques de verificació
+func (ks *KeySet) JWKS() JWKS {
+	return ks.jwks
+}
+
+func (ks *KeySet) addVerificationKey(public interface{}, alg string) (string, error) {
+	jwk, err := newJWK(public, alg)
+	if err != nil {
+		return "", err
+	}
+	if _, exists := ks.verify[jwk.Kid]; !exists {
+		ks.verify[jwk.Kid] = verificationKey{alg: alg, key: public}
+		ks.jwks.Keys = append(ks.jwks.Keys, jwk)
+	}
+	return jwk.Kid, nil
+}
+
+func readPEM(file string) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jwt key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported key format", file)
+}
+
+// splitKey retorna la clau privada (si n'hi ha), la pública i l'algorisme
+func splitKey(key interface{}) (interface{}, interface{}, string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, &k.PublicKey, AlgRS256, nil
+	case *rsa.PublicKey:
+		return nil, k, AlgRS256, nil
+	case ed25519.PrivateKey:
+		return k, k.Public(), AlgEdDSA, nil
+	case ed25519.PublicKey:
+		return nil, k, AlgEdDSA, nil
+	}
+	return nil, nil, "", fmt.Errorf("%w: key type %T", ErrUnsupportedAlgorithm, key)
+}
@@ -0,0 +1,7 @@
+package jwtkeys
+
+import "github.com/gin-gonic/gin"
+
+func RegisterPublicRoutes(router *gin.RouterGroup, handler *JWKSHandler) {
+	router.GET("/.well-known/jwks.json", handler.JWKS)
+}
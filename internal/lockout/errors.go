@@ -0,0 +1,10 @@
+package lockout
+
+import "errors"
+
+var (
+	ErrAccountLocked   = errors.New("account temporarily locked after too many failed logins")
+	ErrTooManyAttempts = errors.New("too many failed logins from this address")
+	ErrInvalidScope    = errors.New("invalid lockout scope")
+	ErrLockoutNotFound = errors.New("lockout not found")
+)
@@ -0,0 +1,71 @@
+package lockout
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LockoutHandler struct {
+	service LockoutService
+}
+
+func NewLockoutHandler(service LockoutService) *LockoutHandler {
+	return &LockoutHandler{service: service}
+}
+
+// FindAll godoc
+// @Summary Get login lockouts
+// @Description Lists failed-login counters and active lockouts per username and IP (Protected route, admin only)
+// @Tags lockouts
+// @Accept json
+// @Produce json
+// @Success 200 {array} Lockout
+// @Failure 500 {object} map[string]string
+// @Router /api/lockouts [get]
+// @Security BearerAuth
+func (h *LockoutHandler) FindAll(c *gin.Context) {
+	lockouts, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lockouts)
+}
+
+// Clear godoc
+// @Summary Clear a login lockout
+// @Description Unlocks a username or IP and resets its failed-login counter (Protected route, admin only)
+// @Tags lockouts
+// @Accept json
+// @Produce json
+// @Param scope path string true "Lockout scope (user or ip)"
+// @Param key path string true "Username or IP address"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/lockouts/{scope}/{key} [delete]
+// @Security BearerAuth
+func (h *LockoutHandler) Clear(c *gin.Context) {
+	adminID, _ := identity.UserID(c)
+	err := h.service.Clear(c.Request.Context(), c.Param("scope"), c.Param("key"), adminID)
+	if err != nil {
+		var statusCode int
+		switch {
+		case errors.Is(err, ErrInvalidScope):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, ErrLockoutNotFound):
+			statusCode = http.StatusNotFound
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,22 @@
+package lockout
+
+import "time"
+
+const (
+	ScopeUser = "user"
+	ScopeIP   = "ip"
+)
+
+// Lockout guarda els intents fallits d'un usuari o d'una IP
+type Lockout struct {
+	Scope          string     `json:"scope" db:"scope"`
+	Key            string     `json:"key" db:"key"`
+	Failures       int        `json:"failures" db:"failures"`
+	FirstFailureAt time.Time  `json:"first_failure_at" db:"first_failure_at"`
+	LockedUntil    *time.Time `json:"locked_until" db:"locked_until"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (l Lockout) IsLocked(now time.Time) bool {
+	return l.LockedUntil != nil && l.LockedUntil.After(now)
+}
@@ -0,0 +1,110 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type LockoutRepository interface {
+	RecordFailure(ctx context.Context, scope, key string, window time.Duration) (Lockout, error)
+	Lock(ctx context.Context, scope, key string, until time.Time) error
+	Find(ctx context.Context, scope, key string) (Lockout, error)
+	FindAll(ctx context.Context) ([]Lockout, error)
+	Delete(ctx context.Context, scope, key string) (bool, error)
+}
+
+type lockoutRepository struct {
+	db *sql.DB
+}
+
+func NewLockoutRepository(db *sql.DB) LockoutRepository {
+	return &lockoutRepository{db: db}
+}
+
+// RecordFailure suma un intent fallit. Si el primer intent comptat és més
+// antic que la finestra, el comptador torna a començar.
+func (r *lockoutRepository) RecordFailure(ctx context.Context, scope, key string, window time.Duration) (Lockout, error) {
+	var l Lockout
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO login_throttles (scope, key, failures, first_failure_at, updated_at)
+		VALUES ($1, $2, 1, now(), now())
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.first_failure_at < now() - make_interval(secs => $3)
+				THEN 1 ELSE login_throttles.failures + 1 END,
+			first_failure_at = CASE WHEN login_throttles.first_failure_at < now() - make_interval(secs => $3)
+				THEN now() ELSE login_throttles.first_failure_at END,
+			updated_at = now()
+		RETURNING scope, key, failures, first_failure_at, locked_until, updated_at`,
+		scope, key, window.Seconds(),
+	).Scan(&l.Scope, &l.Key, &l.Failures, &l.FirstFailureAt, &l.LockedUntil, &l.UpdatedAt)
+	if err != nil {
+		return Lockout{}, fmt.Errorf("error recording login failure: %w", err)
+	}
+	return l, nil
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, scope, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_throttles
+		SET locked_until = $1, updated_at = now()
+		WHERE scope = $2 AND key = $3`,
+		until, scope, key,
+	)
+	if err != nil {
+		return fmt.Errorf("error locking %s %s: %w", scope, key, err)
+	}
+	return nil
+}
+
+func (r *lockoutRepository) Find(ctx context.Context, scope, key string) (Lockout, error) {
+	var l Lockout
+	err := r.db.QueryRowContext(ctx, `
+		SELECT scope, key, failures, first_failure_at, locked_until, updated_at
+		FROM login_throttles
+		WHERE scope = $1 AND key = $2`, scope, key,
+	).Scan(&l.Scope, &l.Key, &l.Failures, &l.FirstFailureAt, &l.LockedUntil, &l.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return Lockout{}, ErrLockoutNotFound
+	} else if err != nil {
+		return Lockout{}, fmt.Errorf("error getting lockout: %w", err)
+	}
+	return l, nil
+}
+
+func (r *lockoutRepository) FindAll(ctx context.Context) ([]Lockout, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT scope, key, failures, first_failure_at, locked_until, updated_at
+		FROM login_throttles
+		ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting lockouts: %w", err)
+	}
+	defer rows.Close()
+
+	var lockouts []Lockout
+	for rows.Next() {
+		var l Lockout
+		if err := rows.Scan(&l.Scope, &l.Key, &l.Failures, &l.FirstFailureAt, &l.LockedUntil, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning lockout: %w", err)
+		}
+		lockouts = append(lockouts, l)
+	}
+	return lockouts, rows.Err()
+}
+
+func (r *lockoutRepository) Delete(ctx context.Context, scope, key string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM login_throttles
+		WHERE scope = $1 AND key = $2`, scope, key,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error deleting lockout: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,8 @@
+package lockout
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *LockoutHandler) {
+	router.GET("/lockouts", handler.FindAll)
+	router.DELETE("/lockouts/:scope/:key", handler.Clear)
+}
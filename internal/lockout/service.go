@@ -0,0 +1,139 @@
+package lockout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActionLogger escriu esdeveniments a action_logs (ActionLogMiddleware)
+type ActionLogger interface {
+	SaveActionLog(userID uuid.UUID, actionType, metadata, timezone string, performedAt time.Time) error
+}
+
+// Settings defineix quan es bloqueja un usuari o una IP
+type Settings struct {
+	// Intents fallits per usuari abans de bloquejar el compte
+	MaxUserFailures int
+	// Intents fallits per IP (de qualsevol usuari) abans de bloquejar-la
+	MaxIPFailures int
+	// Finestra en què es compten els intents
+	Window time.Duration
+	// Durada del bloqueig
+	LockDuration time.Duration
+}
+
+type LockoutService interface {
+	Check(ctx context.Context, username, ip string) error
+	RecordFailure(ctx context.Context, username, ip string) error
+	RecordSuccess(ctx context.Context, username string) error
+	FindAll(ctx context.Context) ([]Lockout, error)
+	Clear(ctx context.Context, scope, key string, clearedBy uuid.UUID) error
+}
+
+type lockoutService struct {
+	repo      LockoutRepository
+	actionLog ActionLogger
+	settings  Settings
+}
+
+func NewLockoutService(repo LockoutRepository, actionLog ActionLogger, settings Settings) LockoutService {
+	return &lockoutService{repo: repo, actionLog: actionLog, settings: settings}
+}
+
+// Check retorna ErrAccountLocked si l'usuari està bloquejat i
+// ErrTooManyAttempts si ho està la IP. Es crida abans de comprovar la
+// contrasenya, de manera que un compte bloquejat no calcula cap hash.
+func (s *lockoutService) Check(ctx context.Context, username, ip string) error {
+	now := time.Now()
+
+	l, err := s.repo.Find(ctx, ScopeIP, ip)
+	if err != nil && !errors.Is(err, ErrLockoutNotFound) {
+		return err
+	}
+	if err == nil && l.IsLocked(now) {
+		return ErrTooManyAttempts
+	}
+
+	l, err = s.repo.Find(ctx, ScopeUser, username)
+	if err != nil && !errors.Is(err, ErrLockoutNotFound) {
+		return err
+	}
+	if err == nil && l.IsLocked(now) {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+func (s *lockoutService) RecordFailure(ctx context.Context, username, ip string) error {
+	if err := s.recordFailure(ctx, ScopeUser, username, s.settings.MaxUserFailures); err != nil {
+		return err
+	}
+	return s.recordFailure(ctx, ScopeIP, ip, s.settings.MaxIPFailures)
+}
+
+func (s *lockoutService) recordFailure(ctx context.Context, scope, key string, max int) error {
+	l, err := s.repo.RecordFailure(ctx, scope, key, s.settings.Window)
+	if err != nil {
+		return err
+	}
+	if l.Failures < max || l.IsLocked(time.Now()) {
+		return nil
+	}
+
+	until := time.Now().Add(s.settings.LockDuration)
+	if err := s.repo.Lock(ctx, scope, key, until); err != nil {
+		return err
+	}
+	s.logEvent(uuid.Nil, "LOCKOUT "+scope, map[string]interface{}{
+		"scope":        scope,
+		"key":          key,
+		"failures":     l.Failures,
+		"locked_until": until,
+	})
+	return nil
+}
+
+// RecordSuccess reinicia el comptador de l'usuari. El de la IP es manté
+// perquè un login correcte no amagui intents contra altres comptes.
+func (s *lockoutService) RecordSuccess(ctx context.Context, username string) error {
+	_, err := s.repo.Delete(ctx, ScopeUser, username)
+	return err
+}
+
+func (s *lockoutService) FindAll(ctx context.Context) ([]Lockout, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *lockoutService) Clear(ctx context.Context, scope, key string, clearedBy uuid.UUID) error {
+	if scope != ScopeUser && scope != ScopeIP {
+		return ErrInvalidScope
+	}
+	deleted, err := s.repo.Delete(ctx, scope, key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrLockoutNotFound
+	}
+	s.logEvent(clearedBy, "UNLOCK "+scope, map[string]interface{}{
+		"scope": scope,
+		"key":   key,
+	})
+	return nil
+}
+
+func (s *lockoutService) logEvent(userID uuid.UUID, actionType string, metadata map[string]interface{}) {
+	body, err := json.Marshal(metadata)
+	if err != nil {
+		log.Printf("Error encoding lockout event: %v", err)
+		return
+	}
+	if err := s.actionLog.SaveActionLog(userID, actionType, string(body), "", time.Now()); err != nil {
+		log.Printf("Error saving action log: %v", err)
+	}
+}
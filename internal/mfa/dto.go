@@ -0,0 +1,15 @@
+package mfa
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type EnrollResponse struct {
+	Secret string `json:"secret"`
+	// URI otpauth:// per generar el codi QR
+	URI string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,159 @@
+package mfa
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"frdy-api/internal/users"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service MFAService
+}
+
+func NewMFAHandler(service MFAService) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrAlreadyEnabled), errors.Is(err, ErrNotEnrolled), errors.Is(err, ErrNotEnabled):
+		return http.StatusConflict
+	case errors.Is(err, users.ErrUserNotFound), errors.Is(err, identity.ErrNoIdentity):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Enroll godoc
+// @Summary Start TOTP enrollment
+// @Description Generates a new TOTP secret and its otpauth URI for the current user. It is not active until confirmed (Protected route)
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Success 200 {object} EnrollResponse
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/mfa/totp/enroll [post]
+// @Security BearerAuth
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.Enroll(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Confirm godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enables TOTP after checking a code from the authenticator app and returns single-use recovery codes (Protected route)
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body CodeRequest true "Current TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/mfa/totp/confirm [post]
+// @Security BearerAuth
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var request CodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.Confirm(c.Request.Context(), userID, request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Disable godoc
+// @Summary Disable TOTP
+// @Description Disables two-factor authentication after checking a TOTP or recovery code (Protected route)
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body CodeRequest true "TOTP or recovery code"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/mfa/totp/disable [post]
+// @Security BearerAuth
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var request CodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), userID, request); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces all recovery codes after checking a TOTP or recovery code (Protected route)
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body CodeRequest true "TOTP or recovery code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/mfa/recovery-codes [post]
+// @Security BearerAuth
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var request CodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,13 @@
+package mfa
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *MFAHandler) {
+	mfa := router.Group("/mfa")
+	{
+		mfa.POST("/totp/enroll", handler.Enroll)
+		mfa.POST("/totp/confirm", handler.Confirm)
+		mfa.POST("/totp/disable", handler.Disable)
+		mfa.POST("/recovery-codes", handler.RegenerateRecoveryCodes)
+	}
+}
@@ -0,0 +1,152 @@
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"frdy-api/internal/securetoken"
+	"frdy-api/internal/totp"
+	"frdy-api/internal/users"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const recoveryCodeCount = 10
+
+type MFAService interface {
+	Enroll(ctx context.Context, userID uuid.UUID) (EnrollResponse, error)
+	Confirm(ctx context.Context, userID uuid.UUID, request CodeRequest) (RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID uuid.UUID, request CodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, request CodeRequest) (RecoveryCodesResponse, error)
+	// VerifyCode accepta un codi TOTP o un codi de recuperació (que es gasta)
+	VerifyCode(ctx context.Context, user users.User, code string) (bool, error)
+}
+
+type mfaService struct {
+	userRepo users.UserRepository
+	issuer   string
+}
+
+func NewMFAService(userRepo users.UserRepository, issuer string) MFAService {
+	return &mfaService{userRepo: userRepo, issuer: issuer}
+}
+
+// Enroll genera un secret nou. No s'activa fins que l'usuari el confirma amb
+// un codi vàlid, així que tornar a cridar-lo substitueix el secret pendent.
+func (s *mfaService) Enroll(ctx context.Context, userID uuid.UUID) (EnrollResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return EnrollResponse{}, err
+	}
+	if user.TOTPEnabled {
+		return EnrollResponse{}, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return EnrollResponse{}, err
+	}
+	if err := s.userRepo.UpdateTOTP(ctx, user.ID, secret, false, nil); err != nil {
+		return EnrollResponse{}, err
+	}
+	return EnrollResponse{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, userID uuid.UUID, request CodeRequest) (RecoveryCodesResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	if user.TOTPEnabled {
+		return RecoveryCodesResponse{}, ErrAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return RecoveryCodesResponse{}, ErrNotEnrolled
+	}
+	if _, ok := totp.Validate(user.TOTPSecret, request.Code, time.Now()); !ok {
+		return RecoveryCodesResponse{}, ErrInvalidCode
+	}
+	return s.enable(ctx, user)
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID uuid.UUID, request CodeRequest) error {
+	user, err := s.enabledUser(ctx, userID, request.Code)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.UpdateTOTP(ctx, user.ID, "", false, nil)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, request CodeRequest) (RecoveryCodesResponse, error) {
+	user, err := s.enabledUser(ctx, userID, request.Code)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	return s.enable(ctx, user)
+}
+
+func (s *mfaService) VerifyCode(ctx context.Context, user users.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, ErrNotEnabled
+	}
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now()); ok {
+		// Un mateix codi no es pot fer servir dues vegades
+		return s.userRepo.UseTOTPStep(ctx, user.ID, step)
+	}
+	return s.userRepo.ConsumeRecoveryCode(ctx, user.ID, securetoken.Hash(normalizeRecoveryCode(code)))
+}
+
+// enabledUser retorna l'usuari si té el segon factor actiu i el codi és vàlid
+func (s *mfaService) enabledUser(ctx context.Context, userID uuid.UUID, code string) (users.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return users.User{}, err
+	}
+	ok, err := s.VerifyCode(ctx, user, code)
+	if err != nil {
+		return users.User{}, err
+	}
+	if !ok {
+		return users.User{}, ErrInvalidCode
+	}
+	return user, nil
+}
+
+// enable activa el segon factor amb codis de recuperació nous. Només se'n
+// guarda el hash; els codis en clar es mostren una sola vegada.
+func (s *mfaService) enable(ctx context.Context, user users.User) (RecoveryCodesResponse, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return RecoveryCodesResponse{}, err
+		}
+		codes[i] = code
+		hashes[i] = securetoken.Hash(normalizeRecoveryCode(code))
+	}
+	if err := s.userRepo.UpdateTOTP(ctx, user.ID, user.TOTPSecret, true, hashes); err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+	return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating recovery code: %w", err)
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
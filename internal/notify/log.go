@@ -0,0 +1,17 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+type logNotifier struct{}
+
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Send(ctx context.Context, message Message) error {
+	log.Printf("Notification to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
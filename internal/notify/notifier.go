@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message és un missatge adreçat a un usuari (correu electrònic, SMS...)
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier envia missatges als usuaris. Les implementacions reals (SMTP,
+// proveïdors externs) s'hi poden connectar sense tocar els serveis.
+type Notifier interface {
+	Send(ctx context.Context, message Message) error
+}
+
+const (
+	KindLog  = "log"
+	KindFile = "file"
+)
+
+// New crea el notifier configurat. "log" escriu els missatges al log del
+// servidor i "file" els afegeix a un fitxer (desenvolupament i proves).
+func New(kind, filePath string) (Notifier, error) {
+	switch kind {
+	case "", KindLog:
+		return NewLogNotifier(), nil
+	case KindFile:
+		return NewFileNotifier(filePath)
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
@@ -0,0 +1,8 @@
+package oidc
+
+// CallbackRequest conté els paràmetres amb què el proveïdor redirigeix a
+// OIDC_REDIRECT_URL; el frontend els envia a /auth/oidc/callback
+type CallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package oidc
+
+import "errors"
+
+var (
+	ErrNotConfigured       = errors.New("oidc login is not configured")
+	ErrProviderUnavailable = errors.New("identity provider unavailable")
+	ErrExchangeFailed      = errors.New("could not exchange authorization code")
+	ErrInvalidIDToken      = errors.New("invalid id token")
+	ErrInvalidState        = errors.New("invalid or expired oidc state")
+	ErrNoLinkedUser        = errors.New("no user is linked to this identity")
+)
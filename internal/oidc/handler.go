@@ -0,0 +1,85 @@
+package oidc
+
+import (
+	"errors"
+	"frdy-api/internal/auth"
+	"frdy-api/internal/users"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	service OIDCService
+}
+
+func NewOIDCHandler(service OIDCService) *OIDCHandler {
+	return &OIDCHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrInvalidIDToken), errors.Is(err, ErrExchangeFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrNoLinkedUser), errors.Is(err, users.ErrInactiveUser), errors.Is(err, users.ErrPendingVerification):
+		return http.StatusForbidden
+	case errors.Is(err, users.ErrUsernameTaken), errors.Is(err, users.ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, ErrProviderUnavailable):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Login godoc
+// @Summary Start OIDC login
+// @Description Redirects to the identity provider using the authorization code flow with PKCE (Public route)
+// @Tags auth
+// @Produce json
+// @Success 302
+// @Failure 404 {object} map[string]string "OIDC not configured"
+// @Failure 502 {object} map[string]string "Identity provider unavailable"
+// @Failure 500 {object} map[string]string
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, err := h.service.Start(c.Request.Context())
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Complete OIDC login
+// @Description Exchanges the code and state returned by the identity provider for a JWT token, like /auth/login (Public route)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body CallbackRequest true "Code and state from the provider redirect"
+// @Success 200 {object} auth.LoginResponse "Login successful"
+// @Success 202 {object} auth.MFAChallengeResponse "Second factor required"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string "Invalid state, code or id token"
+// @Failure 403 {object} map[string]string "No user linked to the identity, or user inactive"
+// @Failure 502 {object} map[string]string "Identity provider unavailable"
+// @Failure 500 {object} map[string]string
+// @Router /auth/oidc/callback [post]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var request CallbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Callback(c.Request.Context(), request, auth.RequestClientInfo(c))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+	auth.WriteLoginResponse(c, result)
+}
@@ -0,0 +1,25 @@
+package oidc
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity vincula un usuari amb el subjecte (sub) d'un proveïdor d'identitat
+type Identity struct {
+	Issuer    string    `json:"issuer" db:"issuer"`
+	Subject   string    `json:"subject" db:"subject"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// loginState és una petició d'autorització en curs. Es guarda el hash de
+// l'state, que torna el proveïdor, amb el verificador PKCE i el nonce.
+type loginState struct {
+	StateHash    string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,312 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testClientID     = "frdy-test"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "http://localhost:5173/oidc/callback"
+	testKeyID        = "test-key"
+)
+
+// fakeIdP és un proveïdor d'identitat mínim per a les proves: publica la
+// configuració i les claus, i bescanvia codis comprovant el verificador PKCE.
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu             sync.Mutex
+	codes          map[string]authorization
+	discoveryCalls int
+	jwksCalls      int
+}
+
+// authorization és el que el proveïdor recorda d'un codi emès: el repte PKCE
+// i les claims de l'ID token que tornarà
+type authorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{t: t, key: key, codes: map[string]authorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		idp.discoveryCalls++
+		idp.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 idp.issuer(),
+			"authorization_endpoint": idp.issuer() + "/authorize",
+			"token_endpoint":         idp.issuer() + "/token",
+			"jwks_uri":               idp.issuer() + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		idp.jwksCalls++
+		idp.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": testKeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) issuer() string {
+	return idp.server.URL
+}
+
+func (idp *fakeIdP) provider() *Provider {
+	return NewProvider(ProviderConfig{
+		IssuerURL:    idp.issuer(),
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}, idp.server.Client())
+}
+
+// authorize fa el paper de la pàgina de login del proveïdor: a partir de
+// l'URL d'autorització retorna l'state i un codi per a l'usuari amb claims
+func (idp *fakeIdP) authorize(authURL string, claims jwt.MapClaims) (state, code string) {
+	idp.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	query := u.Query()
+	if got := u.Scheme + "://" + u.Host + u.Path; got != idp.issuer()+"/authorize" {
+		idp.t.Fatalf("authorization endpoint = %s", got)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		idp.t.Fatalf("missing PKCE challenge in %s", authURL)
+	}
+	if query.Get("client_id") != testClientID || query.Get("redirect_uri") != testRedirectURL {
+		idp.t.Fatalf("unexpected client parameters in %s", authURL)
+	}
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = query.Get("nonce")
+	}
+	return query.Get("state"), idp.issueCode(query.Get("code_challenge"), claims)
+}
+
+func (idp *fakeIdP) issueCode(challenge string, claims jwt.MapClaims) string {
+	code := randomString(idp.t)
+	idp.mu.Lock()
+	idp.codes[code] = authorization{challenge: challenge, claims: claims}
+	idp.mu.Unlock()
+	return code
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if r.Form.Get("grant_type") != "authorization_code" || r.Form.Get("client_id") != testClientID ||
+		r.Form.Get("client_secret") != testClientSecret || r.Form.Get("redirect_uri") != testRedirectURL {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.Form.Get("code")]
+	delete(idp.codes, r.Form.Get("code"))
+	idp.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "bad code or code_verifier"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": idp.sign(auth.claims, idp.key), "token_type": "Bearer"})
+}
+
+// claims retorna unes claims vàlides per a l'usuari; les proves les canvien
+// per provocar cada error
+func (idp *fakeIdP) claims(subject string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": idp.issuer(),
+		"sub": subject,
+		"aud": testClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func (idp *fakeIdP) sign(claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	idp.t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestProviderDiscoveryAndJWKS(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "the-state", "the-nonce", "the-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, _ := url.ParseQuery(authURL[strings.Index(authURL, "?")+1:])
+	if !strings.HasPrefix(authURL, idp.issuer()+"/authorize?") || query.Get("state") != "the-state" ||
+		query.Get("nonce") != "the-nonce" || query.Get("scope") != "openid email profile" {
+		t.Fatalf("unexpected authorization URL %s", authURL)
+	}
+
+	claims := idp.claims("user-1")
+	claims["nonce"] = "the-nonce"
+	claims["email"] = "ada@example.com"
+	claims["email_verified"] = true
+	for i := 0; i < 2; i++ {
+		got, err := provider.VerifyIDToken(ctx, idp.sign(claims, idp.key), "the-nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Issuer != idp.issuer() || got.Subject != "user-1" || got.Email != "ada@example.com" || !got.EmailVerified {
+			t.Fatalf("unexpected claims %+v", got)
+		}
+	}
+	// La configuració i les claus es descarreguen una sola vegada
+	if idp.discoveryCalls != 1 || idp.jwksCalls != 1 {
+		t.Fatalf("discovery fetched %d times, jwks %d times", idp.discoveryCalls, idp.jwksCalls)
+	}
+}
+
+func TestProviderExchangeChecksPKCEVerifier(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	verifier := randomString(t)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	code := idp.issueCode(challenge, idp.claims("user-1"))
+	if _, err := provider.Exchange(ctx, code, "not-the-verifier"); !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("exchange with a wrong verifier: err = %v, want ErrExchangeFailed", err)
+	}
+
+	code = idp.issueCode(challenge, idp.claims("user-1"))
+	rawToken, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, rawToken, ""); err != nil {
+		t.Fatalf("token from the exchange: %v", err)
+	}
+
+	// Un codi només es pot bescanviar una vegada
+	if _, err := provider.Exchange(ctx, code, verifier); !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("second exchange of the same code: err = %v, want ErrExchangeFailed", err)
+	}
+}
+
+func TestProviderRejectsInvalidIDTokens(t *testing.T) {
+	idp := newFakeIdP(t)
+	provider := idp.provider()
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(jwt.MapClaims)
+		key    *rsa.PrivateKey
+	}{
+		{name: "wrong issuer", change: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "wrong audience", change: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "wrong nonce", change: func(c jwt.MapClaims) { c["nonce"] = "another-nonce" }},
+		{name: "missing nonce", change: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "expired", change: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "missing expiry", change: func(c jwt.MapClaims) { delete(c, "exp") }},
+		{name: "missing subject", change: func(c jwt.MapClaims) { delete(c, "sub") }},
+		{name: "bad signature", key: otherKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.claims("user-1")
+			claims["nonce"] = "the-nonce"
+			if tt.change != nil {
+				tt.change(claims)
+			}
+			key := idp.key
+			if tt.key != nil {
+				key = tt.key
+			}
+			_, err := provider.VerifyIDToken(context.Background(), idp.sign(claims, key), "the-nonce")
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+
+	// Un token sense signar (alg none) tampoc s'accepta
+	claims := idp.claims("user-1")
+	claims["nonce"] = "the-nonce"
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(context.Background(), unsigned, "the-nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("unsigned token: err = %v, want ErrInvalidIDToken", err)
+	}
+}
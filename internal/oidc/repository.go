@@ -0,0 +1,82 @@
+package oidc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type OIDCRepository interface {
+	CreateState(ctx context.Context, state loginState) error
+	ConsumeState(ctx context.Context, stateHash string) (loginState, error)
+	FindIdentity(ctx context.Context, issuer, subject string) (Identity, error)
+	CreateIdentity(ctx context.Context, identity Identity) error
+}
+
+type oidcRepository struct {
+	db *sql.DB
+}
+
+func NewOIDCRepository(db *sql.DB) OIDCRepository {
+	return &oidcRepository{db: db}
+}
+
+// CreateState guarda l'state i aprofita per esborrar els caducats
+func (r *oidcRepository) CreateState(ctx context.Context, state loginState) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < now()`); err != nil {
+		return fmt.Errorf("error deleting expired oidc states: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		state.StateHash, state.CodeVerifier, state.Nonce, state.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating oidc state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState esborra l'state i el retorna, de manera que només es pot fer
+// servir una vegada
+func (r *oidcRepository) ConsumeState(ctx context.Context, stateHash string) (loginState, error) {
+	var state loginState
+	err := r.db.QueryRowContext(ctx, `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND expires_at > now()
+		RETURNING state_hash, code_verifier, nonce, expires_at`, stateHash,
+	).Scan(&state.StateHash, &state.CodeVerifier, &state.Nonce, &state.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return loginState{}, ErrInvalidState
+	} else if err != nil {
+		return loginState{}, fmt.Errorf("error consuming oidc state: %w", err)
+	}
+	return state, nil
+}
+
+func (r *oidcRepository) FindIdentity(ctx context.Context, issuer, subject string) (Identity, error) {
+	var identity Identity
+	err := r.db.QueryRowContext(ctx, `
+		SELECT issuer, subject, user_id, email, created_at
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2`, issuer, subject,
+	).Scan(&identity.Issuer, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt)
+	if err == sql.ErrNoRows {
+		return Identity{}, ErrNoLinkedUser
+	} else if err != nil {
+		return Identity{}, fmt.Errorf("error getting identity: %w", err)
+	}
+	return identity, nil
+}
+
+func (r *oidcRepository) CreateIdentity(ctx context.Context, identity Identity) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, user_id, email)
+		VALUES ($1, $2, $3, $4)`,
+		identity.Issuer, identity.Subject, identity.UserID, identity.Email,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating identity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package oidc
+
+import "github.com/gin-gonic/gin"
+
+func RegisterPublicRoutes(router *gin.RouterGroup, handler *OIDCHandler) {
+	oidc := router.Group("/oidc")
+	{
+		oidc.GET("/login", handler.Login)
+		oidc.POST("/callback", handler.Callback)
+	}
+}
@@ -0,0 +1,196 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"frdy-api/internal/auth"
+	"frdy-api/internal/password"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/securetoken"
+	"frdy-api/internal/users"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// stateTTL és el temps que té l'usuari per iniciar sessió al proveïdor
+const stateTTL = 10 * time.Minute
+
+// Longitud de la contrasenya aleatòria dels usuaris creats automàticament
+const randomPasswordLength = 48
+
+// ProvisionConfig decideix què es fa amb les identitats que no corresponen a
+// cap usuari
+type ProvisionConfig struct {
+	// Si és cert, es crea un usuari nou amb el rol DefaultRole
+	Enabled     bool
+	DefaultRole string
+}
+
+type OIDCService interface {
+	// Start retorna l'URL del proveïdor on s'ha de redirigir l'usuari
+	Start(ctx context.Context) (string, error)
+	// Callback completa el login amb el codi que ha retornat el proveïdor i
+	// emet el mateix token que el login amb contrasenya
+	Callback(ctx context.Context, request CallbackRequest, client auth.ClientInfo) (auth.LoginResult, error)
+}
+
+type oidcService struct {
+	provider    *Provider
+	repo        OIDCRepository
+	userRepo    users.UserRepository
+	userService users.UserService
+	roleRepo    roles.RoleRepository
+	authService auth.AuthService
+	provision   ProvisionConfig
+}
+
+// NewOIDCService crea el servei. Si provider és nil, l'OIDC està desactivat
+// i els endpoints retornen ErrNotConfigured.
+func NewOIDCService(provider *Provider, repo OIDCRepository, userRepo users.UserRepository, userService users.UserService, roleRepo roles.RoleRepository, authService auth.AuthService, provision ProvisionConfig) OIDCService {
+	return &oidcService{
+		provider:    provider,
+		repo:        repo,
+		userRepo:    userRepo,
+		userService: userService,
+		roleRepo:    roleRepo,
+		authService: authService,
+		provision:   provision,
+	}
+}
+
+func (s *oidcService) Start(ctx context.Context) (string, error) {
+	if s.provider == nil {
+		return "", ErrNotConfigured
+	}
+	state, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	// PKCE: el proveïdor rep el hash i, en bescanviar el codi, el verificador
+	verifier, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	err = s.repo.CreateState(ctx, loginState{
+		StateHash:    securetoken.Hash(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(stateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.provider.AuthCodeURL(ctx, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+}
+
+func (s *oidcService) Callback(ctx context.Context, request CallbackRequest, client auth.ClientInfo) (auth.LoginResult, error) {
+	if s.provider == nil {
+		return auth.LoginResult{}, ErrNotConfigured
+	}
+	state, err := s.repo.ConsumeState(ctx, securetoken.Hash(request.State))
+	if err != nil {
+		return auth.LoginResult{}, err
+	}
+	rawToken, err := s.provider.Exchange(ctx, request.Code, state.CodeVerifier)
+	if err != nil {
+		return auth.LoginResult{}, err
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, rawToken, state.Nonce)
+	if err != nil {
+		return auth.LoginResult{}, err
+	}
+
+	user, err := s.resolveUser(ctx, claims)
+	if err != nil {
+		return auth.LoginResult{}, err
+	}
+	return s.authService.LoginUser(ctx, user, client)
+}
+
+// resolveUser busca l'usuari vinculat a la identitat. Si no n'hi ha, la
+// vincula a l'usuari amb el mateix email (si tots dos l'han verificat) o,
+// si està activat, en crea un de nou.
+func (s *oidcService) resolveUser(ctx context.Context, claims Claims) (users.User, error) {
+	identity, err := s.repo.FindIdentity(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		return s.userRepo.FindByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, ErrNoLinkedUser) {
+		return users.User{}, err
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return users.User{}, ErrNoLinkedUser
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		if user.EmailVerifiedAt == nil {
+			return users.User{}, ErrNoLinkedUser
+		}
+	case errors.Is(err, users.ErrUserNotFound) && s.provision.Enabled:
+		if user, err = s.provisionUser(ctx, claims); err != nil {
+			return users.User{}, err
+		}
+	case errors.Is(err, users.ErrUserNotFound):
+		return users.User{}, ErrNoLinkedUser
+	default:
+		return users.User{}, err
+	}
+
+	err = s.repo.CreateIdentity(ctx, Identity{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		UserID:  user.ID,
+		Email:   claims.Email,
+	})
+	if err != nil {
+		return users.User{}, err
+	}
+	return user, nil
+}
+
+// provisionUser crea un usuari per a la identitat. La contrasenya és
+// aleatòria: l'usuari entra pel proveïdor o la pot restablir.
+func (s *oidcService) provisionUser(ctx context.Context, claims Claims) (users.User, error) {
+	role, err := s.roleRepo.FindByName(ctx, s.provision.DefaultRole)
+	if err != nil {
+		return users.User{}, err
+	}
+	randomPassword, err := password.Generate(randomPasswordLength)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	username := claims.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(claims.Email, "@")
+	}
+	request := users.CreateUserRequest{Email: claims.Email, Username: username, Password: randomPassword}
+	created, err := s.userService.CreateInvited(ctx, request, role.ID)
+	if errors.Is(err, users.ErrUsernameTaken) {
+		// Un altre usuari ja té aquest nom: s'hi afegeix un sufix aleatori
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return users.User{}, err
+		}
+		request.Username = username + "-" + hex.EncodeToString(suffix)
+		created, err = s.userService.CreateInvited(ctx, request, role.ID)
+	}
+	if err != nil {
+		return users.User{}, err
+	}
+	return s.userRepo.FindByID(ctx, uuid.MustParse(created.ID))
+}
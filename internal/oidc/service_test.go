@@ -0,0 +1,291 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"frdy-api/internal/auth"
+	"frdy-api/internal/roles"
+	"frdy-api/internal/users"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dobles en memòria de les dependències del servei. Els que embeuen la
+// interfície només implementen el que fa servir l'OIDC.
+
+type memoryOIDCRepository struct {
+	mu         sync.Mutex
+	states     map[string]loginState
+	identities map[string]Identity
+}
+
+func newMemoryOIDCRepository() *memoryOIDCRepository {
+	return &memoryOIDCRepository{states: map[string]loginState{}, identities: map[string]Identity{}}
+}
+
+func (r *memoryOIDCRepository) CreateState(ctx context.Context, state loginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *memoryOIDCRepository) ConsumeState(ctx context.Context, stateHash string) (loginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	delete(r.states, stateHash)
+	if !ok || state.ExpiresAt.Before(time.Now()) {
+		return loginState{}, ErrInvalidState
+	}
+	return state, nil
+}
+
+func (r *memoryOIDCRepository) FindIdentity(ctx context.Context, issuer, subject string) (Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity, ok := r.identities[issuer+" "+subject]
+	if !ok {
+		return Identity{}, ErrNoLinkedUser
+	}
+	return identity, nil
+}
+
+func (r *memoryOIDCRepository) CreateIdentity(ctx context.Context, identity Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.identities[identity.Issuer+" "+identity.Subject] = identity
+	return nil
+}
+
+type memoryUsers struct {
+	users.UserRepository
+
+	mu    sync.Mutex
+	users map[uuid.UUID]users.User
+}
+
+func newMemoryUsers(existing ...users.User) *memoryUsers {
+	m := &memoryUsers{users: map[uuid.UUID]users.User{}}
+	for _, user := range existing {
+		m.users[user.ID] = user
+	}
+	return m
+}
+
+func (m *memoryUsers) FindByID(ctx context.Context, id uuid.UUID) (users.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return users.User{}, users.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (m *memoryUsers) FindByEmail(ctx context.Context, email string) (users.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+	return users.User{}, users.ErrUserNotFound
+}
+
+// memoryUserService crea els usuaris al mateix magatzem que memoryUsers
+type memoryUserService struct {
+	users.UserService
+	store *memoryUsers
+}
+
+func (s memoryUserService) CreateInvited(ctx context.Context, request users.CreateUserRequest, roleID uuid.UUID) (users.UserResponse, error) {
+	m := s.store
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.Username == request.Username {
+			return users.UserResponse{}, users.ErrUsernameTaken
+		}
+	}
+	now := time.Now()
+	user := users.User{
+		ID:              uuid.New(),
+		Email:           request.Email,
+		Username:        request.Username,
+		IsActive:        true,
+		RoleID:          uuid.NullUUID{UUID: roleID, Valid: true},
+		EmailVerifiedAt: &now,
+	}
+	m.users[user.ID] = user
+	return users.UserResponse{ID: user.ID.String(), Email: user.Email, Username: user.Username}, nil
+}
+
+type memoryRoles struct {
+	roles.RoleRepository
+	roles []roles.Role
+}
+
+func (r *memoryRoles) FindByName(ctx context.Context, name string) (roles.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return roles.Role{}, roles.ErrRoleNotFound
+}
+
+// fakeAuth inicia la sessió sense emetre cap token: només cal saber qui entra
+type fakeAuth struct {
+	auth.AuthService
+}
+
+func (fakeAuth) LoginUser(ctx context.Context, user users.User, client auth.ClientInfo) (auth.LoginResult, error) {
+	return auth.LoginResult{Token: "session-for-" + user.ID.String(), User: user}, nil
+}
+
+type serviceFixture struct {
+	idp     *fakeIdP
+	repo    *memoryOIDCRepository
+	users   *memoryUsers
+	role    roles.Role
+	service OIDCService
+}
+
+func newServiceFixture(t *testing.T, provision bool, existing ...users.User) *serviceFixture {
+	idp := newFakeIdP(t)
+	f := &serviceFixture{
+		idp:   idp,
+		repo:  newMemoryOIDCRepository(),
+		users: newMemoryUsers(existing...),
+		role:  roles.Role{ID: uuid.New(), Name: roles.RoleReadOnly},
+	}
+	f.service = NewOIDCService(idp.provider(), f.repo, f.users, memoryUserService{store: f.users}, &memoryRoles{roles: []roles.Role{f.role}}, fakeAuth{},
+		ProvisionConfig{Enabled: provision, DefaultRole: roles.RoleReadOnly})
+	return f
+}
+
+// login fa tot el recorregut: Start, login al proveïdor i Callback
+func (f *serviceFixture) login(t *testing.T, subject, email string, emailVerified bool, username string) (auth.LoginResult, error) {
+	t.Helper()
+	authURL, err := f.service.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := f.idp.claims(subject)
+	claims["email"] = email
+	claims["email_verified"] = emailVerified
+	if username != "" {
+		claims["preferred_username"] = username
+	}
+	state, code := f.idp.authorize(authURL, claims)
+	return f.service.Callback(context.Background(), CallbackRequest{Code: code, State: state}, auth.ClientInfo{IP: "127.0.0.1"})
+}
+
+func verifiedUser(email, username string) users.User {
+	verified := time.Now().Add(-24 * time.Hour)
+	return users.User{ID: uuid.New(), Email: email, Username: username, IsActive: true, EmailVerifiedAt: &verified}
+}
+
+func TestCallbackLinksExistingUserByVerifiedEmail(t *testing.T) {
+	existing := verifiedUser("ada@example.com", "ada")
+	f := newServiceFixture(t, false, existing)
+
+	result, err := f.login(t, "idp-ada", "ADA@example.com", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.User.ID != existing.ID {
+		t.Fatalf("logged in as %s, want %s", result.User.ID, existing.ID)
+	}
+	identity, err := f.repo.FindIdentity(context.Background(), f.idp.issuer(), "idp-ada")
+	if err != nil || identity.UserID != existing.ID {
+		t.Fatalf("identity = %+v, %v; want a link to %s", identity, err, existing.ID)
+	}
+
+	// Un cop vinculada, la identitat decideix l'usuari encara que l'email canviï
+	result, err = f.login(t, "idp-ada", "ada@another.example.com", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.User.ID != existing.ID {
+		t.Fatalf("second login as %s, want %s", result.User.ID, existing.ID)
+	}
+}
+
+func TestCallbackDoesNotLinkUnverifiedEmails(t *testing.T) {
+	pending := users.User{ID: uuid.New(), Email: "bob@example.com", Username: "bob"}
+	f := newServiceFixture(t, false, verifiedUser("ada@example.com", "ada"), pending)
+
+	// L'email no està verificat al proveïdor
+	if _, err := f.login(t, "idp-ada", "ada@example.com", false, ""); !errors.Is(err, ErrNoLinkedUser) {
+		t.Fatalf("unverified email at the provider: err = %v, want ErrNoLinkedUser", err)
+	}
+	// L'email no està verificat a l'API
+	if _, err := f.login(t, "idp-bob", "bob@example.com", true, ""); !errors.Is(err, ErrNoLinkedUser) {
+		t.Fatalf("unverified email in the API: err = %v, want ErrNoLinkedUser", err)
+	}
+	// Sense aprovisionament, un email desconegut no crea cap usuari
+	if _, err := f.login(t, "idp-carol", "carol@example.com", true, ""); !errors.Is(err, ErrNoLinkedUser) {
+		t.Fatalf("unknown email: err = %v, want ErrNoLinkedUser", err)
+	}
+	if len(f.repo.identities) != 0 || len(f.users.users) != 2 {
+		t.Fatalf("got %d identities and %d users, want none created", len(f.repo.identities), len(f.users.users))
+	}
+}
+
+func TestCallbackProvisionsNewUsers(t *testing.T) {
+	f := newServiceFixture(t, true, verifiedUser("someone@example.com", "carol"))
+
+	result, err := f.login(t, "idp-carol", "carol@example.com", true, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := result.User
+	if user.Email != "carol@example.com" || !user.RoleID.Valid || user.RoleID.UUID != f.role.ID {
+		t.Fatalf("provisioned user %+v, want carol@example.com with the default role", user)
+	}
+	// El nom ja el té un altre usuari: s'hi afegeix un sufix
+	if !strings.HasPrefix(user.Username, "carol-") {
+		t.Fatalf("username = %q, want a suffixed carol", user.Username)
+	}
+	if identity, err := f.repo.FindIdentity(context.Background(), f.idp.issuer(), "idp-carol"); err != nil || identity.UserID != user.ID {
+		t.Fatalf("identity = %+v, %v; want a link to %s", identity, err, user.ID)
+	}
+
+	// Sense preferred_username, el nom surt de l'email
+	result, err = f.login(t, "idp-dave", "dave@example.com", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.User.Username != "dave" {
+		t.Fatalf("username = %q, want dave", result.User.Username)
+	}
+}
+
+func TestCallbackRejectsReusedOrUnknownState(t *testing.T) {
+	f := newServiceFixture(t, true)
+
+	authURL, err := f.service.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, code := f.idp.authorize(authURL, f.idp.claims("idp-erin"))
+	ctx := context.Background()
+
+	if _, err := f.service.Callback(ctx, CallbackRequest{Code: code, State: "forged"}, auth.ClientInfo{}); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("unknown state: err = %v, want ErrInvalidState", err)
+	}
+	if _, err := f.service.Callback(ctx, CallbackRequest{Code: code, State: state}, auth.ClientInfo{}); !errors.Is(err, ErrNoLinkedUser) {
+		t.Fatalf("identity without email: err = %v, want ErrNoLinkedUser", err)
+	}
+	// L'state ja s'ha consumit
+	if _, err := f.service.Callback(ctx, CallbackRequest{Code: code, State: state}, auth.ClientInfo{}); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("reused state: err = %v, want ErrInvalidState", err)
+	}
+}
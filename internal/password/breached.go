@@ -0,0 +1,86 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	sha1HexLength = 40
+	// Longitud del prefix amb què s'agrupen els hashos (k-anonymity)
+	rangePrefixLength = 5
+)
+
+// BreachedList és una llista local de contrasenyes filtrades, en el mateix
+// format que Have I Been Pwned: el SHA-1 en hexadecimal de cada contrasenya,
+// opcionalment seguit de ":<vegades>". Els hashos s'agrupen pel prefix de 5
+// caràcters, com a l'API de rangs, de manera que només cal cercar el sufix
+// dins del seu rang.
+type BreachedList struct {
+	ranges map[string][]string
+	size   int
+}
+
+// LoadBreachedList llegeix la llista del fitxer. Les línies buides o que
+// comencen per # s'ignoren.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening breached password list: %w", err)
+	}
+	defer file.Close()
+
+	list := &BreachedList{ranges: make(map[string][]string)}
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1HexLength {
+			return nil, fmt.Errorf("invalid hash in breached password list at line %d", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("invalid hash in breached password list at line %d", line)
+		}
+		prefix := hash[:rangePrefixLength]
+		list.ranges[prefix] = append(list.ranges[prefix], hash[rangePrefixLength:])
+		list.size++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading breached password list: %w", err)
+	}
+
+	for _, suffixes := range list.ranges {
+		sort.Strings(suffixes)
+	}
+	return list, nil
+}
+
+// Len retorna el nombre de hashos de la llista
+func (l *BreachedList) Len() int {
+	return l.size
+}
+
+// Range retorna els sufixos dels hashos que comencen pel prefix
+func (l *BreachedList) Range(prefix string) []string {
+	return l.ranges[strings.ToUpper(prefix)]
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes := l.Range(hash[:rangePrefixLength])
+	suffix := hash[rangePrefixLength:]
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
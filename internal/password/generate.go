@@ -0,0 +1,55 @@
+package password
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	lowercaseChars = "abcdefghijklmnopqrstuvwxyz"
+	uppercaseChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars     = "0123456789"
+	symbolChars    = "!#$%&*+-.:=?@^_~"
+)
+
+// Generate retorna una contrasenya aleatòria de la longitud indicada amb
+// almenys un caràcter de cada tipus, de manera que compleix qualsevol
+// política de tipus de caràcters. Per als comptes que no entren amb contrasenya.
+func Generate(length int) (string, error) {
+	classes := []string{lowercaseChars, uppercaseChars, digitChars, symbolChars}
+	if length < len(classes) {
+		length = len(classes)
+	}
+	all := lowercaseChars + uppercaseChars + digitChars + symbolChars
+
+	result := make([]byte, length)
+	for i := range result {
+		charset := all
+		if i < len(classes) {
+			charset = classes[i]
+		}
+		c, err := randomIndex(len(charset))
+		if err != nil {
+			return "", err
+		}
+		result[i] = charset[c]
+	}
+	// Barrejar perquè els primers caràcters no siguin sempre del mateix tipus
+	for i := len(result) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		result[i], result[j] = result[j], result[i]
+	}
+	return string(result), nil
+}
+
+func randomIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("error generating password: %w", err)
+	}
+	return int(v.Int64()), nil
+}
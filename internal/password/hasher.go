@@ -0,0 +1,68 @@
+// Package password calcula i verifica els hashos de les contrasenyes. Els
+// hashos nous són Argon2id; els bcrypt antics es continuen acceptant i es
+// marquen per tornar-los a calcular al següent login.
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+type Hasher interface {
+	// Hash retorna el hash de la contrasenya amb l'algorisme i els paràmetres actuals
+	Hash(password string) (string, error)
+	// Verify comprova la contrasenya contra un hash de qualsevol format suportat
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash indica si el hash s'ha calculat amb un algorisme o uns
+	// paràmetres diferents dels actuals
+	NeedsRehash(hash string) bool
+}
+
+type hasher struct {
+	params Argon2idParams
+}
+
+func NewHasher(params Argon2idParams) Hasher {
+	return &hasher{params: params}
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return hashArgon2id(password, h.params)
+}
+
+func (h *hasher) Verify(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, password)
+	case isBcrypt(hash):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+func (h *hasher) NeedsRehash(hash string) bool {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return true
+	}
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		params.KeyLength != h.params.KeyLength
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
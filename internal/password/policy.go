@@ -0,0 +1,100 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Policy són les regles que ha de complir una contrasenya nova
+type Policy struct {
+	MinLength int
+	// Nombre mínim de tipus de caràcters diferents (minúscules, majúscules,
+	// dígits i símbols) que ha de contenir. 0 no n'exigeix cap.
+	RequiredClasses int
+	// Nombre de contrasenyes anteriors (comptant l'actual) que no es poden reutilitzar
+	HistorySize int
+	// Llista de contrasenyes filtrades. nil desactiva la comprovació.
+	Breached *BreachedList
+}
+
+// PolicyError enumera totes les regles que no compleix una contrasenya
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password does not meet the policy: " + strings.Join(e.Violations, "; ")
+}
+
+// Check valida la contrasenya. personal són dades de l'usuari (nom d'usuari,
+// email) amb què la contrasenya no pot coincidir.
+func (p Policy) Check(password string, personal ...string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.RequiredClasses > 0 && characterClasses(password) < p.RequiredClasses {
+		violations = append(violations, fmt.Sprintf(
+			"must contain at least %d of: lowercase letters, uppercase letters, digits, symbols", p.RequiredClasses))
+	}
+	for _, value := range personal {
+		if value != "" && strings.EqualFold(password, value) {
+			violations = append(violations, "must not be the same as the username or email")
+			break
+		}
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, "appears in a list of breached passwords")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// CheckHistory comprova que la contrasenya no coincideixi amb cap dels
+// hashos anteriors (el primer ha de ser el de la contrasenya actual).
+func (p Policy) CheckHistory(hasher Hasher, password string, hashes []string) error {
+	if p.HistorySize <= 0 {
+		return nil
+	}
+	if len(hashes) > p.HistorySize {
+		hashes = hashes[:p.HistorySize]
+	}
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		if match, err := hasher.Verify(hash, password); err == nil && match {
+			return &PolicyError{Violations: []string{
+				fmt.Sprintf("must not be one of the last %d passwords", p.HistorySize)}}
+		}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,78 @@
+package passwordreset
+
+import (
+	"errors"
+	"frdy-api/internal/password"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ResetHandler struct {
+	service ResetService
+}
+
+func NewResetHandler(service ResetService) *ResetHandler {
+	return &ResetHandler{service: service}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Sends a single-use reset link to the user with this email. Always answers 202 so registered emails are not disclosed (Public route)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/forgot-password [post]
+func (h *ResetHandler) ForgotPassword(c *gin.Context) {
+	var request ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RequestReset(c.Request.Context(), request); err != nil {
+		if errors.Is(err, ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Sets a new password using the token received by email. The token can only be used once. The password must meet the password policy (Public route)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/reset-password [post]
+func (h *ResetHandler) ResetPassword(c *gin.Context) {
+	var request ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Reset(c.Request.Context(), request); err != nil {
+		var policyErr *password.PolicyError
+		if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrInvalidRequest) || errors.As(err, &policyErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,81 @@
+package passwordreset
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type ResetRepository interface {
+	Create(ctx context.Context, token ResetToken) error
+	// FindUser retorna l'usuari d'un token vàlid sense gastar-lo
+	FindUser(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	Consume(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	InvalidateForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type resetRepository struct {
+	db *sql.DB
+}
+
+func NewResetRepository(db *sql.DB) ResetRepository {
+	return &resetRepository{db: db}
+}
+
+func (r *resetRepository) Create(ctx context.Context, token ResetToken) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		token.ID, token.UserID, token.TokenHash, token.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting reset token: %w", err)
+	}
+	return nil
+}
+
+func (r *resetRepository) FindUser(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()`, tokenHash,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrInvalidToken
+	} else if err != nil {
+		return uuid.Nil, fmt.Errorf("error getting reset token: %w", err)
+	}
+	return userID, nil
+}
+
+// Consume marca el token com a utilitzat i retorna l'usuari. Ho fa en una
+// sola sentència perquè dues peticions simultànies no el puguin fer servir.
+func (r *resetRepository) Consume(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING user_id`, tokenHash,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrInvalidToken
+	} else if err != nil {
+		return uuid.Nil, fmt.Errorf("error consuming reset token: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *resetRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = now()
+		WHERE user_id = $1 AND used_at IS NULL`, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error invalidating reset tokens: %w", err)
+	}
+	return nil
+}
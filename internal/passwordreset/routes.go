@@ -0,0 +1,8 @@
+package passwordreset
+
+import "github.com/gin-gonic/gin"
+
+func RegisterPublicRoutes(router *gin.RouterGroup, handler *ResetHandler) {
+	router.POST("/forgot-password", handler.ForgotPassword)
+	router.POST("/reset-password", handler.ResetPassword)
+}
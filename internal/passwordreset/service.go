@@ -0,0 +1,111 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"frdy-api/internal/notify"
+	"frdy-api/internal/securetoken"
+	"frdy-api/internal/users"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ResetService interface {
+	RequestReset(ctx context.Context, request ForgotPasswordRequest) error
+	Reset(ctx context.Context, request ResetPasswordRequest) error
+}
+
+type resetService struct {
+	repo        ResetRepository
+	userRepo    users.UserRepository
+	userService users.UserService
+	notifier    notify.Notifier
+	baseURL     string
+	ttl         time.Duration
+}
+
+func NewResetService(repo ResetRepository, userRepo users.UserRepository, userService users.UserService, notifier notify.Notifier, baseURL string, ttl time.Duration) ResetService {
+	return &resetService{
+		repo:        repo,
+		userRepo:    userRepo,
+		userService: userService,
+		notifier:    notifier,
+		baseURL:     baseURL,
+		ttl:         ttl,
+	}
+}
+
+// RequestReset envia un enllaç de restabliment si l'email correspon a un
+// usuari actiu. Si no, no fa res i no retorna error, per no revelar quins
+// emails estan registrats.
+func (s *resetService) RequestReset(ctx context.Context, request ForgotPasswordRequest) error {
+	if request.Email == "" {
+		return ErrInvalidRequest
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, request.Email)
+	if errors.Is(err, users.ErrUserNotFound) || errors.Is(err, users.ErrInactiveUser) || errors.Is(err, users.ErrPendingVerification) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Només l'últim enllaç enviat és vàlid
+	if err := s.repo.InvalidateForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := securetoken.Generate()
+	if err != nil {
+		return err
+	}
+	resetToken := ResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: securetoken.Hash(token),
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.baseURL, url.QueryEscape(token))
+	return s.notifier.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello %s,\n\nUse the following link to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not request it, ignore this message.",
+			user.Username, s.ttl, link),
+	})
+}
+
+// Reset canvia la contrasenya de l'usuari al qual pertany el token. El canvi
+// revoca tots els tokens JWT que tingués.
+func (s *resetService) Reset(ctx context.Context, request ResetPasswordRequest) error {
+	if request.Token == "" || request.Password == "" {
+		return ErrInvalidRequest
+	}
+
+	tokenHash := securetoken.Hash(request.Token)
+	userID, err := s.repo.FindUser(ctx, tokenHash)
+	if err != nil {
+		return err
+	}
+	// La contrasenya es valida abans de gastar el token: si no compleix la
+	// política, l'usuari pot tornar-ho a provar amb el mateix enllaç
+	if err := s.userService.CheckNewPassword(ctx, userID, request.Password); err != nil {
+		return err
+	}
+	if userID, err = s.repo.Consume(ctx, tokenHash); err != nil {
+		return err
+	}
+
+	_, err = s.userService.ChangePassword(ctx, users.ChangePasswordRequest{
+		ID:       userID.String(),
+		Password: request.Password,
+	})
+	return err
+}
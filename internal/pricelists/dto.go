@@ -0,0 +1,40 @@
+package pricelists
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CustomerGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type PriceListRequest struct {
+	Name            string     `json:"name" binding:"required"`
+	Description     string     `json:"description"`
+	CustomerGroupID *uuid.UUID `json:"customer_group_id"`
+	Priority        int        `json:"priority"`
+	// Per defecte les tarifes noves són actives
+	IsActive *bool `json:"is_active"`
+}
+
+// ItemPriceRequest; les dates són YYYY-MM-DD i ambdues s'inclouen
+type ItemPriceRequest struct {
+	ItemID      uuid.UUID `json:"item_id" binding:"required"`
+	MinQuantity float64   `json:"min_quantity" binding:"min=0"`
+	Price       float64   `json:"price" binding:"required,gt=0"`
+	ValidFrom   *string   `json:"valid_from"`
+	ValidTo     *string   `json:"valid_to"`
+}
+
+// ResolveRequest descriu la línia per a la qual es vol el preu
+type ResolveRequest struct {
+	ItemID          string     `form:"item_id" binding:"required"`
+	CustomerGroupID *uuid.UUID `form:"customer_group_id"`
+	Unit            string     `form:"unit"`
+	Quantity        float64    `form:"quantity"`
+	// Data en què ha de ser vàlid el preu; per defecte avui
+	Date time.Time `form:"date" time_format:"2006-01-02"`
+}
@@ -0,0 +1,18 @@
+package pricelists
+
+import "errors"
+
+var (
+	ErrPriceListNotFound     = errors.New("price list not found")
+	ErrCustomerGroupNotFound = errors.New("customer group not found")
+	ErrItemPriceNotFound     = errors.New("price not found")
+	ErrItemNotFound          = errors.New("item not found")
+	ErrInvalidID             = errors.New("invalid ID")
+	ErrInvalidRequest        = errors.New("invalid request")
+	ErrInvalidDate           = errors.New("invalid date format, expected YYYY-MM-DD")
+	ErrInvalidValidity       = errors.New("valid_from must not be after valid_to")
+	ErrPriceListExists       = errors.New("a price list with this name already exists")
+	ErrCustomerGroupExists   = errors.New("a customer group with this name already exists")
+	ErrCustomerGroupInUse    = errors.New("customer group is used by price lists or sales")
+	ErrNoPrice               = errors.New("no price for this item")
+)
@@ -0,0 +1,425 @@
+package pricelists
+
+import (
+	"errors"
+	"frdy-api/internal/units"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceListHandler struct {
+	service PriceListService
+}
+
+func NewPriceListHandler(service PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrPriceListNotFound), errors.Is(err, ErrCustomerGroupNotFound), errors.Is(err, ErrItemPriceNotFound),
+		errors.Is(err, ErrItemNotFound), errors.Is(err, units.ErrItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidDate),
+		errors.Is(err, ErrInvalidValidity), errors.Is(err, units.ErrUnitNotFound), errors.Is(err, units.ErrNoConversion),
+		errors.Is(err, units.ErrFractionalQuantity), errors.Is(err, units.ErrInvalidItemID):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrPriceListExists), errors.Is(err, ErrCustomerGroupExists), errors.Is(err, ErrCustomerGroupInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateCustomerGroup godoc
+// @Summary Create a customer group
+// @Description Creates a group of customers that share price lists (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param request body CustomerGroupRequest true "Customer group data"
+// @Success 201 {object} CustomerGroup
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customer-groups [post]
+// @Security BearerAuth
+func (h *PriceListHandler) CreateCustomerGroup(c *gin.Context) {
+	var request CustomerGroupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.service.CreateCustomerGroup(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateCustomerGroup godoc
+// @Summary Update a customer group
+// @Description Renames a customer group (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer group ID"
+// @Param request body CustomerGroupRequest true "Customer group data"
+// @Success 200 {object} CustomerGroup
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customer-groups/{id} [put]
+// @Security BearerAuth
+func (h *PriceListHandler) UpdateCustomerGroup(c *gin.Context) {
+	var request CustomerGroupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.service.UpdateCustomerGroup(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteCustomerGroup godoc
+// @Summary Delete a customer group
+// @Description Deletes a customer group that no price list or sale uses (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer group ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customer-groups/{id} [delete]
+// @Security BearerAuth
+func (h *PriceListHandler) DeleteCustomerGroup(c *gin.Context) {
+	if err := h.service.DeleteCustomerGroup(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// FindCustomerGroupByID godoc
+// @Summary Get a customer group
+// @Description Retrieves a customer group by ID (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer group ID"
+// @Success 200 {object} CustomerGroup
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customer-groups/{id} [get]
+// @Security BearerAuth
+func (h *PriceListHandler) FindCustomerGroupByID(c *gin.Context) {
+	group, err := h.service.FindCustomerGroupByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// FindAllCustomerGroups godoc
+// @Summary List customer groups
+// @Description Retrieves all customer groups (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Success 200 {array} CustomerGroup
+// @Failure 500 {object} map[string]string
+// @Router /api/customer-groups [get]
+// @Security BearerAuth
+func (h *PriceListHandler) FindAllCustomerGroups(c *gin.Context) {
+	groups, err := h.service.FindAllCustomerGroups(c.Request.Context())
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// Create godoc
+// @Summary Create a price list
+// @Description Creates a named price list, optionally restricted to a customer group (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param request body PriceListRequest true "Price list data"
+// @Success 201 {object} PriceList
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists [post]
+// @Security BearerAuth
+func (h *PriceListHandler) Create(c *gin.Context) {
+	var request PriceListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.service.Create(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+// Update godoc
+// @Summary Update a price list
+// @Description Updates the name, customer group, priority or active flag of a price list (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Param request body PriceListRequest true "Price list data"
+// @Success 200 {object} PriceList
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id} [put]
+// @Security BearerAuth
+func (h *PriceListHandler) Update(c *gin.Context) {
+	var request PriceListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := h.service.Update(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Delete godoc
+// @Summary Delete a price list
+// @Description Deletes a price list and all its prices (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id} [delete]
+// @Security BearerAuth
+func (h *PriceListHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// FindByID godoc
+// @Summary Get a price list
+// @Description Retrieves a price list by ID (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Success 200 {object} PriceList
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id} [get]
+// @Security BearerAuth
+func (h *PriceListHandler) FindByID(c *gin.Context) {
+	list, err := h.service.FindByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// FindAll godoc
+// @Summary List price lists
+// @Description Retrieves all price lists ordered by priority (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Success 200 {array} PriceList
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists [get]
+// @Security BearerAuth
+func (h *PriceListHandler) FindAll(c *gin.Context) {
+	lists, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// CreateItemPrice godoc
+// @Summary Add an item price to a price list
+// @Description Adds a price per base unit for an item, with an optional minimum quantity and validity dates (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Param request body ItemPriceRequest true "Item price data"
+// @Success 201 {object} ItemPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id}/prices [post]
+// @Security BearerAuth
+func (h *PriceListHandler) CreateItemPrice(c *gin.Context) {
+	var request ItemPriceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.service.CreateItemPrice(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, price)
+}
+
+// UpdateItemPrice godoc
+// @Summary Update an item price
+// @Description Updates a price of a price list (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Param price_id path string true "Item price ID"
+// @Param request body ItemPriceRequest true "Item price data"
+// @Success 200 {object} ItemPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id}/prices/{price_id} [put]
+// @Security BearerAuth
+func (h *PriceListHandler) UpdateItemPrice(c *gin.Context) {
+	var request ItemPriceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.service.UpdateItemPrice(c.Request.Context(), c.Param("id"), c.Param("price_id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// DeleteItemPrice godoc
+// @Summary Delete an item price
+// @Description Removes a price from a price list (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Param price_id path string true "Item price ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id}/prices/{price_id} [delete]
+// @Security BearerAuth
+func (h *PriceListHandler) DeleteItemPrice(c *gin.Context) {
+	if err := h.service.DeleteItemPrice(c.Request.Context(), c.Param("id"), c.Param("price_id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// FindItemPrices godoc
+// @Summary List the prices of a price list
+// @Description Retrieves all item prices of a price list (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param id path string true "Price list ID"
+// @Success 200 {array} ItemPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/{id}/prices [get]
+// @Security BearerAuth
+func (h *PriceListHandler) FindItemPrices(c *gin.Context) {
+	prices, err := h.service.FindItemPrices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// Resolve godoc
+// @Summary Resolve the price of a sales line
+// @Description Returns the price a sales line would get: the best active price list for the customer group, date and quantity, or the item price (Protected route)
+// @Tags price-lists
+// @Accept json
+// @Produce json
+// @Param item_id query string true "Item ID"
+// @Param customer_group_id query string false "Customer group ID"
+// @Param unit query string false "Unit of the quantity (defaults to the base unit)"
+// @Param quantity query number false "Quantity (defaults to 1)"
+// @Param date query string false "Date (YYYY-MM-DD, defaults to today)"
+// @Success 200 {object} ResolvedPrice
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/price-lists/resolve [get]
+// @Security BearerAuth
+func (h *PriceListHandler) Resolve(c *gin.Context) {
+	var request ResolveRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	price, err := h.service.Resolve(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
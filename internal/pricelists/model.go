@@ -0,0 +1,64 @@
+package pricelists
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Origen del preu resolt
+const (
+	SourcePriceList = "price_list"
+	SourceItem      = "item"
+)
+
+// CustomerGroup agrupa clients que comparteixen tarifes (majoristes, socis...)
+type CustomerGroup struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// PriceList és una tarifa amb nom. Les tarifes sense grup s'apliquen a tots
+// els clients; les d'un grup, només a les vendes d'aquest grup i per davant
+// de les generals. Entre tarifes del mateix nivell guanya la de més prioritat.
+type PriceList struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Name            string     `json:"name" db:"name"`
+	Description     string     `json:"description" db:"description"`
+	CustomerGroupID *uuid.UUID `json:"customer_group_id" db:"customer_group_id"`
+	Priority        int        `json:"priority" db:"priority"`
+	IsActive        bool       `json:"is_active" db:"is_active"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ItemPrice és el preu d'un article en una tarifa, per unitat base, a partir
+// d'una quantitat mínima (en unitats base) i dins d'unes dates opcionals.
+type ItemPrice struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	PriceListID     uuid.UUID  `json:"price_list_id" db:"price_list_id"`
+	ItemID          uuid.UUID  `json:"item_id" db:"item_id"`
+	ItemCode        string     `json:"item_code" db:"item_code"`
+	ItemDescription string     `json:"item_description" db:"item_description"`
+	MinQuantity     float64    `json:"min_quantity" db:"min_quantity"`
+	Price           float64    `json:"price" db:"price"`
+	ValidFrom       *time.Time `json:"valid_from" db:"valid_from"`
+	ValidTo         *time.Time `json:"valid_to" db:"valid_to"`
+}
+
+// ResolvedPrice és el preu que toca a una línia de venda
+type ResolvedPrice struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Unit     string    `json:"unit"`
+	Quantity float64   `json:"quantity"`
+	// Preu per unitat de la línia i per unitat base
+	Price     float64 `json:"price"`
+	BasePrice float64 `json:"base_price"`
+	Source    string  `json:"source"`
+	// Tarifa i preu d'on surt, si no és el preu de l'article
+	PriceListID   *uuid.UUID `json:"price_list_id,omitempty"`
+	PriceListName string     `json:"price_list_name,omitempty"`
+	ItemPriceID   *uuid.UUID `json:"item_price_id,omitempty"`
+}
@@ -0,0 +1,347 @@
+package pricelists
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	customerGroupColumns = `id, name, COALESCE(description,''), created_at`
+	priceListColumns     = `id, name, COALESCE(description,''), customer_group_id, priority, is_active, created_at, updated_at`
+	itemPriceColumns     = `p.id, p.price_list_id, p.item_id, i.code, i.description, p.min_quantity, p.price, p.valid_from, p.valid_to`
+)
+
+type PriceListRepository interface {
+	CreateCustomerGroup(ctx context.Context, group CustomerGroup) (CustomerGroup, error)
+	UpdateCustomerGroup(ctx context.Context, group CustomerGroup) (CustomerGroup, error)
+	DeleteCustomerGroup(ctx context.Context, id uuid.UUID) error
+	FindCustomerGroupByID(ctx context.Context, id uuid.UUID) (CustomerGroup, error)
+	FindCustomerGroupByName(ctx context.Context, name string) (CustomerGroup, error)
+	FindAllCustomerGroups(ctx context.Context) ([]CustomerGroup, error)
+	CountCustomerGroupUsage(ctx context.Context, id uuid.UUID) (int, error)
+
+	Create(ctx context.Context, list PriceList) (PriceList, error)
+	Update(ctx context.Context, list PriceList) (PriceList, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (PriceList, error)
+	FindByName(ctx context.Context, name string) (PriceList, error)
+	FindAll(ctx context.Context) ([]PriceList, error)
+
+	CreateItemPrice(ctx context.Context, price ItemPrice) (ItemPrice, error)
+	UpdateItemPrice(ctx context.Context, price ItemPrice) (ItemPrice, error)
+	DeleteItemPrice(ctx context.Context, listID, id uuid.UUID) error
+	FindItemPrice(ctx context.Context, listID, id uuid.UUID) (ItemPrice, error)
+	FindItemPrices(ctx context.Context, listID uuid.UUID) ([]ItemPrice, error)
+
+	// BestPrice troba el preu aplicable a l'article per al grup de clients,
+	// la quantitat en unitats base i el dia indicats
+	BestPrice(ctx context.Context, itemID uuid.UUID, groupID *uuid.UUID, baseQuantity float64, date time.Time) (ItemPrice, PriceList, error)
+}
+
+type priceListRepository struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) PriceListRepository {
+	return &priceListRepository{db: db}
+}
+
+func customerGroupFields(g *CustomerGroup) []interface{} {
+	return []interface{}{&g.ID, &g.Name, &g.Description, &g.CreatedAt}
+}
+
+func priceListFields(l *PriceList) []interface{} {
+	return []interface{}{&l.ID, &l.Name, &l.Description, &l.CustomerGroupID, &l.Priority, &l.IsActive, &l.CreatedAt, &l.UpdatedAt}
+}
+
+func itemPriceFields(p *ItemPrice) []interface{} {
+	return []interface{}{&p.ID, &p.PriceListID, &p.ItemID, &p.ItemCode, &p.ItemDescription, &p.MinQuantity, &p.Price, &p.ValidFrom, &p.ValidTo}
+}
+
+// Grups de clients
+
+func (r *priceListRepository) CreateCustomerGroup(ctx context.Context, group CustomerGroup) (CustomerGroup, error) {
+	var created CustomerGroup
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO customer_groups (id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING `+customerGroupColumns,
+		group.ID, group.Name, group.Description,
+	).Scan(customerGroupFields(&created)...)
+	if err != nil {
+		return CustomerGroup{}, fmt.Errorf("error creating customer group: %w", err)
+	}
+	return created, nil
+}
+
+func (r *priceListRepository) UpdateCustomerGroup(ctx context.Context, group CustomerGroup) (CustomerGroup, error) {
+	var updated CustomerGroup
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE customer_groups
+		SET name = $1, description = $2
+		WHERE id = $3
+		RETURNING `+customerGroupColumns,
+		group.Name, group.Description, group.ID,
+	).Scan(customerGroupFields(&updated)...)
+	if err == sql.ErrNoRows {
+		return CustomerGroup{}, ErrCustomerGroupNotFound
+	} else if err != nil {
+		return CustomerGroup{}, fmt.Errorf("error updating customer group: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *priceListRepository) DeleteCustomerGroup(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM customer_groups WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting customer group: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCustomerGroupNotFound
+	}
+	return nil
+}
+
+func (r *priceListRepository) FindCustomerGroupByID(ctx context.Context, id uuid.UUID) (CustomerGroup, error) {
+	return r.findCustomerGroup(ctx, `SELECT `+customerGroupColumns+` FROM customer_groups WHERE id = $1`, id)
+}
+
+func (r *priceListRepository) FindCustomerGroupByName(ctx context.Context, name string) (CustomerGroup, error) {
+	return r.findCustomerGroup(ctx, `SELECT `+customerGroupColumns+` FROM customer_groups WHERE lower(name) = lower($1)`, name)
+}
+
+func (r *priceListRepository) findCustomerGroup(ctx context.Context, query string, arg interface{}) (CustomerGroup, error) {
+	var group CustomerGroup
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(customerGroupFields(&group)...)
+	if err == sql.ErrNoRows {
+		return CustomerGroup{}, ErrCustomerGroupNotFound
+	} else if err != nil {
+		return CustomerGroup{}, fmt.Errorf("error getting customer group: %w", err)
+	}
+	return group, nil
+}
+
+func (r *priceListRepository) FindAllCustomerGroups(ctx context.Context) ([]CustomerGroup, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+customerGroupColumns+` FROM customer_groups ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting customer groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []CustomerGroup
+	for rows.Next() {
+		var group CustomerGroup
+		if err := rows.Scan(customerGroupFields(&group)...); err != nil {
+			return nil, fmt.Errorf("error scanning customer group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func (r *priceListRepository) CountCustomerGroupUsage(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM price_lists WHERE customer_group_id = $1)
+			+ (SELECT count(*) FROM sales_headers WHERE customer_group_id = $1)`, id,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting customer group usage: %w", err)
+	}
+	return count, nil
+}
+
+// Tarifes
+
+func (r *priceListRepository) Create(ctx context.Context, list PriceList) (PriceList, error) {
+	var created PriceList
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO price_lists (id, name, description, customer_group_id, priority, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+priceListColumns,
+		list.ID, list.Name, list.Description, list.CustomerGroupID, list.Priority, list.IsActive,
+	).Scan(priceListFields(&created)...)
+	if err != nil {
+		return PriceList{}, fmt.Errorf("error creating price list: %w", err)
+	}
+	return created, nil
+}
+
+func (r *priceListRepository) Update(ctx context.Context, list PriceList) (PriceList, error) {
+	var updated PriceList
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE price_lists
+		SET name = $1, description = $2, customer_group_id = $3, priority = $4, is_active = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING `+priceListColumns,
+		list.Name, list.Description, list.CustomerGroupID, list.Priority, list.IsActive, list.ID,
+	).Scan(priceListFields(&updated)...)
+	if err == sql.ErrNoRows {
+		return PriceList{}, ErrPriceListNotFound
+	} else if err != nil {
+		return PriceList{}, fmt.Errorf("error updating price list: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *priceListRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM price_lists WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting price list: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPriceListNotFound
+	}
+	return nil
+}
+
+func (r *priceListRepository) FindByID(ctx context.Context, id uuid.UUID) (PriceList, error) {
+	return r.findPriceList(ctx, `SELECT `+priceListColumns+` FROM price_lists WHERE id = $1`, id)
+}
+
+func (r *priceListRepository) FindByName(ctx context.Context, name string) (PriceList, error) {
+	return r.findPriceList(ctx, `SELECT `+priceListColumns+` FROM price_lists WHERE lower(name) = lower($1)`, name)
+}
+
+func (r *priceListRepository) findPriceList(ctx context.Context, query string, arg interface{}) (PriceList, error) {
+	var list PriceList
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(priceListFields(&list)...)
+	if err == sql.ErrNoRows {
+		return PriceList{}, ErrPriceListNotFound
+	} else if err != nil {
+		return PriceList{}, fmt.Errorf("error getting price list: %w", err)
+	}
+	return list, nil
+}
+
+func (r *priceListRepository) FindAll(ctx context.Context) ([]PriceList, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+priceListColumns+` FROM price_lists ORDER BY priority DESC, name`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting price lists: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []PriceList
+	for rows.Next() {
+		var list PriceList
+		if err := rows.Scan(priceListFields(&list)...); err != nil {
+			return nil, fmt.Errorf("error scanning price list: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+// Preus
+
+func (r *priceListRepository) CreateItemPrice(ctx context.Context, price ItemPrice) (ItemPrice, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO price_list_items (id, price_list_id, item_id, min_quantity, price, valid_from, valid_to)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		price.ID, price.PriceListID, price.ItemID, price.MinQuantity, price.Price, price.ValidFrom, price.ValidTo,
+	)
+	if err != nil {
+		return ItemPrice{}, fmt.Errorf("error creating item price: %w", err)
+	}
+	return r.FindItemPrice(ctx, price.PriceListID, price.ID)
+}
+
+func (r *priceListRepository) UpdateItemPrice(ctx context.Context, price ItemPrice) (ItemPrice, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE price_list_items
+		SET item_id = $1, min_quantity = $2, price = $3, valid_from = $4, valid_to = $5
+		WHERE id = $6 AND price_list_id = $7`,
+		price.ItemID, price.MinQuantity, price.Price, price.ValidFrom, price.ValidTo, price.ID, price.PriceListID,
+	)
+	if err != nil {
+		return ItemPrice{}, fmt.Errorf("error updating item price: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ItemPrice{}, ErrItemPriceNotFound
+	}
+	return r.FindItemPrice(ctx, price.PriceListID, price.ID)
+}
+
+func (r *priceListRepository) DeleteItemPrice(ctx context.Context, listID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM price_list_items WHERE id = $1 AND price_list_id = $2`, id, listID)
+	if err != nil {
+		return fmt.Errorf("error deleting item price: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrItemPriceNotFound
+	}
+	return nil
+}
+
+func (r *priceListRepository) FindItemPrice(ctx context.Context, listID, id uuid.UUID) (ItemPrice, error) {
+	var price ItemPrice
+	err := r.db.QueryRowContext(ctx, `
+		SELECT `+itemPriceColumns+`
+		FROM price_list_items p
+			INNER JOIN items i ON i.id = p.item_id
+		WHERE p.id = $1 AND p.price_list_id = $2`, id, listID,
+	).Scan(itemPriceFields(&price)...)
+	if err == sql.ErrNoRows {
+		return ItemPrice{}, ErrItemPriceNotFound
+	} else if err != nil {
+		return ItemPrice{}, fmt.Errorf("error getting item price: %w", err)
+	}
+	return price, nil
+}
+
+func (r *priceListRepository) FindItemPrices(ctx context.Context, listID uuid.UUID) ([]ItemPrice, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemPriceColumns+`
+		FROM price_list_items p
+			INNER JOIN items i ON i.id = p.item_id
+		WHERE p.price_list_id = $1
+		ORDER BY i.code, p.min_quantity, p.valid_from NULLS FIRST`, listID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting item prices: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []ItemPrice
+	for rows.Next() {
+		var price ItemPrice
+		if err := rows.Scan(itemPriceFields(&price)...); err != nil {
+			return nil, fmt.Errorf("error scanning item price: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
+// BestPrice dona preferència a les tarifes del grup de clients sobre les
+// generals, després a la prioritat de la tarifa, després al tram de quantitat
+// més alt assolit i finalment al preu amb la data d'inici més recent.
+func (r *priceListRepository) BestPrice(ctx context.Context, itemID uuid.UUID, groupID *uuid.UUID, baseQuantity float64, date time.Time) (ItemPrice, PriceList, error) {
+	var price ItemPrice
+	var list PriceList
+	fields := append(itemPriceFields(&price), priceListFields(&list)...)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT `+itemPriceColumns+`,
+			l.id, l.name, COALESCE(l.description,''), l.customer_group_id, l.priority, l.is_active, l.created_at, l.updated_at
+		FROM price_list_items p
+			INNER JOIN price_lists l ON l.id = p.price_list_id
+			INNER JOIN items i ON i.id = p.item_id
+		WHERE p.item_id = $1
+			AND l.is_active
+			AND (l.customer_group_id IS NULL OR l.customer_group_id = $2)
+			AND p.min_quantity <= $3
+			AND (p.valid_from IS NULL OR p.valid_from <= $4::date)
+			AND (p.valid_to IS NULL OR p.valid_to >= $4::date)
+		ORDER BY (l.customer_group_id IS NOT NULL) DESC, l.priority DESC, p.min_quantity DESC, p.valid_from DESC NULLS LAST
+		LIMIT 1`, itemID, groupID, baseQuantity, date,
+	).Scan(fields...)
+	if err == sql.ErrNoRows {
+		return ItemPrice{}, PriceList{}, ErrNoPrice
+	} else if err != nil {
+		return ItemPrice{}, PriceList{}, fmt.Errorf("error resolving price: %w", err)
+	}
+	return price, list, nil
+}
@@ -0,0 +1,28 @@
+package pricelists
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *PriceListHandler) {
+	groups := router.Group("/customer-groups")
+	{
+		groups.POST("", handler.CreateCustomerGroup)
+		groups.PUT("/:id", handler.UpdateCustomerGroup)
+		groups.DELETE("/:id", handler.DeleteCustomerGroup)
+		groups.GET("/:id", handler.FindCustomerGroupByID)
+		groups.GET("", handler.FindAllCustomerGroups)
+	}
+
+	lists := router.Group("/price-lists")
+	{
+		lists.POST("", handler.Create)
+		lists.PUT("/:id", handler.Update)
+		lists.DELETE("/:id", handler.Delete)
+		lists.GET("/resolve", handler.Resolve)
+		lists.GET("/:id", handler.FindByID)
+		lists.GET("", handler.FindAll)
+		lists.POST("/:id/prices", handler.CreateItemPrice)
+		lists.PUT("/:id/prices/:price_id", handler.UpdateItemPrice)
+		lists.DELETE("/:id/prices/:price_id", handler.DeleteItemPrice)
+		lists.GET("/:id/prices", handler.FindItemPrices)
+	}
+}
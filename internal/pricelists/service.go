@@ -0,0 +1,349 @@
+package pricelists
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"frdy-api/internal/items"
+	"frdy-api/internal/units"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PriceListService interface {
+	CreateCustomerGroup(ctx context.Context, request CustomerGroupRequest) (CustomerGroup, error)
+	UpdateCustomerGroup(ctx context.Context, id string, request CustomerGroupRequest) (CustomerGroup, error)
+	DeleteCustomerGroup(ctx context.Context, id string) error
+	FindCustomerGroupByID(ctx context.Context, id string) (CustomerGroup, error)
+	FindAllCustomerGroups(ctx context.Context) ([]CustomerGroup, error)
+
+	Create(ctx context.Context, request PriceListRequest) (PriceList, error)
+	Update(ctx context.Context, id string, request PriceListRequest) (PriceList, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (PriceList, error)
+	FindAll(ctx context.Context) ([]PriceList, error)
+
+	CreateItemPrice(ctx context.Context, listID string, request ItemPriceRequest) (ItemPrice, error)
+	UpdateItemPrice(ctx context.Context, listID, id string, request ItemPriceRequest) (ItemPrice, error)
+	DeleteItemPrice(ctx context.Context, listID, id string) error
+	FindItemPrices(ctx context.Context, listID string) ([]ItemPrice, error)
+
+	// Resolve retorna el preu per unitat de la línia: el de la millor tarifa
+	// aplicable o, si no n'hi ha cap, el preu de l'article
+	Resolve(ctx context.Context, request ResolveRequest) (ResolvedPrice, error)
+}
+
+type priceListService struct {
+	repo  PriceListRepository
+	items items.ItemRepository
+	units units.UnitService
+}
+
+func NewPriceListService(repo PriceListRepository, items items.ItemRepository, units units.UnitService) PriceListService {
+	return &priceListService{repo: repo, items: items, units: units}
+}
+
+// Grups de clients
+
+func (s *priceListService) CreateCustomerGroup(ctx context.Context, request CustomerGroupRequest) (CustomerGroup, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return CustomerGroup{}, ErrInvalidRequest
+	}
+	if err := s.checkGroupName(ctx, uuid.Nil, name); err != nil {
+		return CustomerGroup{}, err
+	}
+	return s.repo.CreateCustomerGroup(ctx, CustomerGroup{ID: uuid.New(), Name: name, Description: request.Description})
+}
+
+func (s *priceListService) UpdateCustomerGroup(ctx context.Context, id string, request CustomerGroupRequest) (CustomerGroup, error) {
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return CustomerGroup{}, ErrInvalidID
+	}
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return CustomerGroup{}, ErrInvalidRequest
+	}
+	if err := s.checkGroupName(ctx, groupID, name); err != nil {
+		return CustomerGroup{}, err
+	}
+	return s.repo.UpdateCustomerGroup(ctx, CustomerGroup{ID: groupID, Name: name, Description: request.Description})
+}
+
+func (s *priceListService) DeleteCustomerGroup(ctx context.Context, id string) error {
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if _, err := s.repo.FindCustomerGroupByID(ctx, groupID); err != nil {
+		return err
+	}
+	count, err := s.repo.CountCustomerGroupUsage(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCustomerGroupInUse
+	}
+	return s.repo.DeleteCustomerGroup(ctx, groupID)
+}
+
+func (s *priceListService) FindCustomerGroupByID(ctx context.Context, id string) (CustomerGroup, error) {
+	groupID, err := uuid.Parse(id)
+	if err != nil {
+		return CustomerGroup{}, ErrInvalidID
+	}
+	return s.repo.FindCustomerGroupByID(ctx, groupID)
+}
+
+func (s *priceListService) FindAllCustomerGroups(ctx context.Context) ([]CustomerGroup, error) {
+	return s.repo.FindAllCustomerGroups(ctx)
+}
+
+func (s *priceListService) checkGroupName(ctx context.Context, id uuid.UUID, name string) error {
+	existing, err := s.repo.FindCustomerGroupByName(ctx, name)
+	if errors.Is(err, ErrCustomerGroupNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != id {
+		return ErrCustomerGroupExists
+	}
+	return nil
+}
+
+// Tarifes
+
+func (s *priceListService) Create(ctx context.Context, request PriceListRequest) (PriceList, error) {
+	list, err := s.priceListFromRequest(ctx, uuid.New(), request)
+	if err != nil {
+		return PriceList{}, err
+	}
+	return s.repo.Create(ctx, list)
+}
+
+func (s *priceListService) Update(ctx context.Context, id string, request PriceListRequest) (PriceList, error) {
+	listID, err := uuid.Parse(id)
+	if err != nil {
+		return PriceList{}, ErrInvalidID
+	}
+	if _, err := s.repo.FindByID(ctx, listID); err != nil {
+		return PriceList{}, err
+	}
+	list, err := s.priceListFromRequest(ctx, listID, request)
+	if err != nil {
+		return PriceList{}, err
+	}
+	return s.repo.Update(ctx, list)
+}
+
+func (s *priceListService) priceListFromRequest(ctx context.Context, id uuid.UUID, request PriceListRequest) (PriceList, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return PriceList{}, ErrInvalidRequest
+	}
+	existing, err := s.repo.FindByName(ctx, name)
+	if err == nil && existing.ID != id {
+		return PriceList{}, ErrPriceListExists
+	} else if err != nil && !errors.Is(err, ErrPriceListNotFound) {
+		return PriceList{}, err
+	}
+	if request.CustomerGroupID != nil {
+		if _, err := s.repo.FindCustomerGroupByID(ctx, *request.CustomerGroupID); err != nil {
+			return PriceList{}, err
+		}
+	}
+
+	list := PriceList{
+		ID:              id,
+		Name:            name,
+		Description:     request.Description,
+		CustomerGroupID: request.CustomerGroupID,
+		Priority:        request.Priority,
+		IsActive:        true,
+	}
+	if request.IsActive != nil {
+		list.IsActive = *request.IsActive
+	}
+	return list, nil
+}
+
+// Delete elimina la tarifa amb tots els seus preus
+func (s *priceListService) Delete(ctx context.Context, id string) error {
+	listID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	return s.repo.Delete(ctx, listID)
+}
+
+func (s *priceListService) FindByID(ctx context.Context, id string) (PriceList, error) {
+	listID, err := uuid.Parse(id)
+	if err != nil {
+		return PriceList{}, ErrInvalidID
+	}
+	return s.repo.FindByID(ctx, listID)
+}
+
+func (s *priceListService) FindAll(ctx context.Context) ([]PriceList, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Preus
+
+func (s *priceListService) CreateItemPrice(ctx context.Context, listID string, request ItemPriceRequest) (ItemPrice, error) {
+	price, err := s.itemPriceFromRequest(ctx, listID, uuid.New(), request)
+	if err != nil {
+		return ItemPrice{}, err
+	}
+	return s.repo.CreateItemPrice(ctx, price)
+}
+
+func (s *priceListService) UpdateItemPrice(ctx context.Context, listID, id string, request ItemPriceRequest) (ItemPrice, error) {
+	priceID, err := uuid.Parse(id)
+	if err != nil {
+		return ItemPrice{}, ErrInvalidID
+	}
+	price, err := s.itemPriceFromRequest(ctx, listID, priceID, request)
+	if err != nil {
+		return ItemPrice{}, err
+	}
+	return s.repo.UpdateItemPrice(ctx, price)
+}
+
+func (s *priceListService) itemPriceFromRequest(ctx context.Context, listID string, id uuid.UUID, request ItemPriceRequest) (ItemPrice, error) {
+	parsedListID, err := uuid.Parse(listID)
+	if err != nil {
+		return ItemPrice{}, ErrInvalidID
+	}
+	if request.Price <= 0 || request.MinQuantity < 0 {
+		return ItemPrice{}, ErrInvalidRequest
+	}
+	if _, err := s.repo.FindByID(ctx, parsedListID); err != nil {
+		return ItemPrice{}, err
+	}
+	if _, err := s.items.FindByID(request.ItemID); errors.Is(err, sql.ErrNoRows) {
+		return ItemPrice{}, ErrItemNotFound
+	} else if err != nil {
+		return ItemPrice{}, err
+	}
+	validFrom, err := parseDate(request.ValidFrom)
+	if err != nil {
+		return ItemPrice{}, err
+	}
+	validTo, err := parseDate(request.ValidTo)
+	if err != nil {
+		return ItemPrice{}, err
+	}
+	if validFrom != nil && validTo != nil && validFrom.After(*validTo) {
+		return ItemPrice{}, ErrInvalidValidity
+	}
+
+	return ItemPrice{
+		ID:          id,
+		PriceListID: parsedListID,
+		ItemID:      request.ItemID,
+		MinQuantity: units.RoundQuantity(request.MinQuantity),
+		Price:       request.Price,
+		ValidFrom:   validFrom,
+		ValidTo:     validTo,
+	}, nil
+}
+
+func (s *priceListService) DeleteItemPrice(ctx context.Context, listID, id string) error {
+	parsedListID, err := uuid.Parse(listID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	priceID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	return s.repo.DeleteItemPrice(ctx, parsedListID, priceID)
+}
+
+func (s *priceListService) FindItemPrices(ctx context.Context, listID string) ([]ItemPrice, error) {
+	parsedListID, err := uuid.Parse(listID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if _, err := s.repo.FindByID(ctx, parsedListID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindItemPrices(ctx, parsedListID)
+}
+
+// Resolució de preus
+
+func (s *priceListService) Resolve(ctx context.Context, request ResolveRequest) (ResolvedPrice, error) {
+	itemID, err := uuid.Parse(request.ItemID)
+	if err != nil {
+		return ResolvedPrice{}, ErrInvalidID
+	}
+	quantity := request.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	date := request.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	// Els preus de les tarifes són per unitat base, i els trams de quantitat
+	// també es comparen en unitats base
+	conversion, err := s.units.ToBase(ctx, request.ItemID, request.Unit, quantity)
+	if err != nil {
+		return ResolvedPrice{}, err
+	}
+
+	resolved := ResolvedPrice{
+		ItemID:   itemID,
+		Unit:     conversion.Unit,
+		Quantity: quantity,
+	}
+	price, list, err := s.repo.BestPrice(ctx, itemID, request.CustomerGroupID, conversion.BaseQuantity, date)
+	switch {
+	case err == nil:
+		resolved.BasePrice = price.Price
+		resolved.Source = SourcePriceList
+		resolved.PriceListID = &list.ID
+		resolved.PriceListName = list.Name
+		resolved.ItemPriceID = &price.ID
+	case errors.Is(err, ErrNoPrice):
+		item, err := s.items.FindByID(itemID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ResolvedPrice{}, ErrItemNotFound
+		} else if err != nil {
+			return ResolvedPrice{}, err
+		}
+		resolved.BasePrice = item.Price
+		resolved.Source = SourceItem
+	default:
+		return ResolvedPrice{}, err
+	}
+
+	resolved.Price = roundPrice(resolved.BasePrice * conversion.Factor)
+	return resolved, nil
+}
+
+func parseDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(time.DateOnly, *value)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	return &date, nil
+}
+
+// roundPrice arrodoneix a cèntims
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
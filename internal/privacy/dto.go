@@ -0,0 +1,15 @@
+package privacy
+
+// CustomerRequest identifica un client pel nom (sense distingir majúscules)
+// o pel telèfon. Es tenen en compte les vendes que coincideixen amb qualsevol
+// dels dos.
+type CustomerRequest struct {
+	CustomerName  string `json:"customer_name" form:"customer_name"`
+	CustomerPhone string `json:"customer_phone" form:"customer_phone"`
+}
+
+// AnonymizeResult compta els registres modificats
+type AnonymizeResult struct {
+	SalesHeaders int64 `json:"sales_headers"`
+	ActionLogs   int64 `json:"action_logs"`
+}
@@ -0,0 +1,11 @@
+package privacy
+
+import "errors"
+
+var (
+	ErrInvalidID         = errors.New("invalid user ID")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrInvalidRequest    = errors.New("customer name or phone is required")
+	ErrSelfAnonymization = errors.New("cannot anonymize your own account")
+	ErrAlreadyAnonymized = errors.New("user has already been anonymized")
+)
@@ -0,0 +1,145 @@
+package privacy
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler struct {
+	service PrivacyService
+}
+
+func NewPrivacyHandler(service PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrSelfAnonymization):
+		return http.StatusForbidden
+	case errors.Is(err, ErrAlreadyAnonymized):
+		return http.StatusConflict
+	case errors.Is(err, identity.ErrNoIdentity):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ExportUser godoc
+// @Summary Export a user's personal data
+// @Description Returns everything stored about a user: profile, linked identities, sessions, API keys, invitations, password resets and action logs (Protected route, admin only)
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} UserExport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/privacy/users/{id}/export [get]
+// @Security BearerAuth
+func (h *PrivacyHandler) ExportUser(c *gin.Context) {
+	export, err := h.service.ExportUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// AnonymizeUser godoc
+// @Summary Anonymize a user
+// @Description Replaces the user's email and username, removes credentials, linked identities and session details, deactivates the account and scrubs the user's personal data from the action logs. The action history is kept (Protected route, admin only)
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} AnonymizeResult
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string "Own account"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Already anonymized"
+// @Failure 500 {object} map[string]string
+// @Router /api/privacy/users/{id}/anonymize [post]
+// @Security BearerAuth
+func (h *PrivacyHandler) AnonymizeUser(c *gin.Context) {
+	requestedBy, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.AnonymizeUser(c.Request.Context(), requestedBy, c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportCustomer godoc
+// @Summary Export a customer's personal data
+// @Description Returns the sales (with their lines) whose customer matches the name (case-insensitive) or the phone, and the action logs that mention them (Protected route, admin only)
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param customer_name query string false "Customer name"
+// @Param customer_phone query string false "Customer phone"
+// @Success 200 {object} CustomerExport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/privacy/customers/export [get]
+// @Security BearerAuth
+func (h *PrivacyHandler) ExportCustomer(c *gin.Context) {
+	var request CustomerRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	export, err := h.service.ExportCustomer(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// AnonymizeCustomer godoc
+// @Summary Anonymize a customer
+// @Description Replaces the customer name and removes the phone from the matching sales, and scrubs them from the action logs. Sales lines and stock movements are not changed (Protected route, admin only)
+// @Tags privacy
+// @Accept json
+// @Produce json
+// @Param customer body CustomerRequest true "Customer name and/or phone"
+// @Success 200 {object} AnonymizeResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/privacy/customers/anonymize [post]
+// @Security BearerAuth
+func (h *PrivacyHandler) AnonymizeCustomer(c *gin.Context) {
+	var request CustomerRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.AnonymizeCustomer(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,102 @@
+package privacy
+
+import (
+	"encoding/json"
+	"frdy-api/internal/sales"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Text amb què se substitueixen les dades personals
+const (
+	anonymizedValue        = "[anonymized]"
+	anonymizedCustomerName = "Anonymized customer"
+)
+
+// UserExport conté tot el que es guarda d'un usuari
+type UserExport struct {
+	ExportedAt     time.Time       `json:"exported_at"`
+	User           PersonalUser    `json:"user"`
+	Identities     []Identity      `json:"identities"`
+	Sessions       []Session       `json:"sessions"`
+	APIKeys        []APIKey        `json:"api_keys"`
+	Invitations    []Invitation    `json:"invitations"`
+	PasswordResets []PasswordReset `json:"password_resets"`
+	ActionLogs     []ActionLog     `json:"action_logs"`
+}
+
+type PersonalUser struct {
+	ID              uuid.UUID       `json:"id"`
+	Email           string          `json:"email"`
+	Username        string          `json:"username"`
+	IsActive        bool            `json:"is_active"`
+	Role            string          `json:"role"`
+	EmailVerifiedAt *time.Time      `json:"email_verified_at"`
+	PendingEmail    string          `json:"pending_email,omitempty"`
+	Preferences     json.RawMessage `json:"preferences"`
+	TOTPEnabled     bool            `json:"totp_enabled"`
+	CreatedAt       time.Time       `json:"created_at"`
+	AnonymizedAt    *time.Time      `json:"anonymized_at,omitempty"`
+}
+
+type Identity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKey struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+type Invitation struct {
+	ID         uuid.UUID  `json:"id"`
+	Email      string     `json:"email"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
+
+type PasswordReset struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+type ActionLog struct {
+	ActionType     string          `json:"action_type"`
+	Metadata       json.RawMessage `json:"metadata"`
+	PerformedAt    time.Time       `json:"performed_at"`
+	ImpersonatorID uuid.NullUUID   `json:"impersonator_id"`
+}
+
+// CustomerExport conté les vendes i les accions registrades d'un client
+type CustomerExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Customer   CustomerRequest `json:"customer"`
+	Sales      []CustomerSale  `json:"sales"`
+	ActionLogs []ActionLog     `json:"action_logs"`
+}
+
+type CustomerSale struct {
+	Header  sales.SalesHeader   `json:"header"`
+	Details []sales.SalesDetail `json:"details"`
+}
@@ -0,0 +1,370 @@
+package privacy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"frdy-api/internal/sales"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PrivacyRepository interface {
+	FindUser(ctx context.Context, id uuid.UUID) (PersonalUser, error)
+	ExportUser(ctx context.Context, user PersonalUser) (UserExport, error)
+	AnonymizeUser(ctx context.Context, user PersonalUser) (int64, error)
+	ExportCustomer(ctx context.Context, customer CustomerRequest) (CustomerExport, error)
+	AnonymizeCustomer(ctx context.Context, customer CustomerRequest) (AnonymizeResult, error)
+}
+
+type privacyRepository struct {
+	db *sql.DB
+}
+
+func NewPrivacyRepository(db *sql.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+// queryer permet fer servir les mateixes consultes dins i fora d'una transacció
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// FindUser retorna l'usuari en qualsevol estat (actiu, desactivat o anonimitzat)
+func (r *privacyRepository) FindUser(ctx context.Context, id uuid.UUID) (PersonalUser, error) {
+	var user PersonalUser
+	err := r.db.QueryRowContext(ctx, `
+		SELECT u.id, u.email, u.username, u.is_active, COALESCE(ro.name, ''), u.email_verified_at,
+			COALESCE(u.pending_email, ''), u.preferences, u.totp_enabled, u.created_at, u.anonymized_at
+		FROM users u
+		LEFT JOIN roles ro ON ro.id = u.role_id
+		WHERE u.id = $1`, id,
+	).Scan(&user.ID, &user.Email, &user.Username, &user.IsActive, &user.Role, &user.EmailVerifiedAt,
+		&user.PendingEmail, &user.Preferences, &user.TOTPEnabled, &user.CreatedAt, &user.AnonymizedAt)
+	if err == sql.ErrNoRows {
+		return PersonalUser{}, ErrUserNotFound
+	}
+	if err != nil {
+		return PersonalUser{}, fmt.Errorf("error getting user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *privacyRepository) ExportUser(ctx context.Context, user PersonalUser) (UserExport, error) {
+	export := UserExport{
+		User:           user,
+		Identities:     []Identity{},
+		Sessions:       []Session{},
+		APIKeys:        []APIKey{},
+		Invitations:    []Invitation{},
+		PasswordResets: []PasswordReset{},
+	}
+
+	err := r.collect(ctx, `
+		SELECT issuer, subject, email, created_at FROM user_identities
+		WHERE user_id = $1 ORDER BY created_at`,
+		[]interface{}{user.ID}, func(rows *sql.Rows) error {
+			var i Identity
+			if err := rows.Scan(&i.Issuer, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+				return err
+			}
+			export.Identities = append(export.Identities, i)
+			return nil
+		})
+	if err != nil {
+		return UserExport{}, err
+	}
+
+	err = r.collect(ctx, `
+		SELECT id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at FROM sessions
+		WHERE user_id = $1 ORDER BY created_at`,
+		[]interface{}{user.ID}, func(rows *sql.Rows) error {
+			var s Session
+			if err := rows.Scan(&s.ID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt); err != nil {
+				return err
+			}
+			export.Sessions = append(export.Sessions, s)
+			return nil
+		})
+	if err != nil {
+		return UserExport{}, err
+	}
+
+	err = r.collect(ctx, `
+		SELECT id, name, prefix, permissions, created_at, expires_at, last_used_at, revoked_at FROM api_keys
+		WHERE created_by = $1 ORDER BY created_at`,
+		[]interface{}{user.ID}, func(rows *sql.Rows) error {
+			var k APIKey
+			if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Permissions), &k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+				return err
+			}
+			export.APIKeys = append(export.APIKeys, k)
+			return nil
+		})
+	if err != nil {
+		return UserExport{}, err
+	}
+
+	err = r.collect(ctx, `
+		SELECT id, email, created_at, accepted_at FROM invitations
+		WHERE user_id = $1 OR lower(email) = lower($2) ORDER BY created_at`,
+		[]interface{}{user.ID, user.Email}, func(rows *sql.Rows) error {
+			var i Invitation
+			if err := rows.Scan(&i.ID, &i.Email, &i.CreatedAt, &i.AcceptedAt); err != nil {
+				return err
+			}
+			export.Invitations = append(export.Invitations, i)
+			return nil
+		})
+	if err != nil {
+		return UserExport{}, err
+	}
+
+	err = r.collect(ctx, `
+		SELECT created_at, expires_at, used_at FROM password_reset_tokens
+		WHERE user_id = $1 ORDER BY created_at`,
+		[]interface{}{user.ID}, func(rows *sql.Rows) error {
+			var p PasswordReset
+			if err := rows.Scan(&p.CreatedAt, &p.ExpiresAt, &p.UsedAt); err != nil {
+				return err
+			}
+			export.PasswordResets = append(export.PasswordResets, p)
+			return nil
+		})
+	if err != nil {
+		return UserExport{}, err
+	}
+
+	// Les accions fetes per l'usuari i les que el mencionen (p.ex. la seva invitació)
+	export.ActionLogs, err = r.actionLogs(ctx, `user_id = $1 OR impersonator_id = $1 OR `+mentionsCondition("$2"),
+		user.ID, pq.Array(personalValues(user.Email, user.Username, user.PendingEmail)))
+	if err != nil {
+		return UserExport{}, err
+	}
+	return export, nil
+}
+
+// AnonymizeUser substitueix les dades personals de l'usuari, en desactiva el
+// compte i n'elimina les credencials. Les accions i vendes es conserven.
+func (r *privacyRepository) AnonymizeUser(ctx context.Context, user PersonalUser) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	placeholder := "deleted-" + user.ID.String()
+	anonymizedEmail := placeholder + "@anonymized.invalid"
+	values := personalValues(user.Email, user.Username, user.PendingEmail)
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE users
+			SET email = $2, username = $3, password = '', is_active = false,
+				totp_secret = NULL, totp_enabled = false, totp_recovery_codes = '{}', totp_last_step = 0,
+				pending_email = NULL, preferences = '{}',
+				email_verified_at = COALESCE(email_verified_at, now()), anonymized_at = now()
+			WHERE id = $1`, []interface{}{user.ID, anonymizedEmail, placeholder}},
+		{`DELETE FROM user_identities WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM password_reset_tokens WHERE user_id = $1`, []interface{}{user.ID}},
+		{`DELETE FROM password_history WHERE user_id = $1`, []interface{}{user.ID}},
+		{`UPDATE sessions SET user_agent = '', ip = '', revoked_at = COALESCE(revoked_at, now())
+			WHERE user_id = $1`, []interface{}{user.ID}},
+		{`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE created_by = $1`, []interface{}{user.ID}},
+		{`UPDATE invitations SET email = $2 WHERE user_id = $1 OR lower(email) = lower($3)`,
+			[]interface{}{user.ID, anonymizedEmail, user.Email}},
+		{`DELETE FROM login_throttles WHERE scope = 'user' AND lower(key) = lower($1)`, []interface{}{user.Username}},
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement.query, statement.args...); err != nil {
+			return 0, fmt.Errorf("error anonymizing user: %w", err)
+		}
+	}
+
+	logs, err := scrubActionLogs(ctx, tx, values)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return logs, nil
+}
+
+func (r *privacyRepository) ExportCustomer(ctx context.Context, customer CustomerRequest) (CustomerExport, error) {
+	export := CustomerExport{Customer: customer, Sales: []CustomerSale{}}
+	index := map[string]int{}
+
+	err := r.collect(ctx, `
+		SELECT id, code, customer_name, COALESCE(customer_phone, ''), created_at, sent
+		FROM sales_headers
+		WHERE `+customerCondition+`
+		ORDER BY created_at`,
+		[]interface{}{customer.CustomerName, customer.CustomerPhone}, func(rows *sql.Rows) error {
+			var sale CustomerSale
+			h := &sale.Header
+			if err := rows.Scan(&h.ID, &h.Code, &h.CustomerName, &h.CustomerPhone, &h.CreatedAt, &h.Sent); err != nil {
+				return err
+			}
+			sale.Details = []sales.SalesDetail{}
+			index[h.ID.String()] = len(export.Sales)
+			export.Sales = append(export.Sales, sale)
+			return nil
+		})
+	if err != nil {
+		return CustomerExport{}, err
+	}
+
+	err = r.collect(ctx, `
+		SELECT sd.id, sd.sales_header_id, sd.item_id, i.code, i.description, sd.quantity, sd.unit, sd.base_quantity, sd.price, sd.amount
+		FROM sales_details sd
+		JOIN sales_headers sh ON sh.id = sd.sales_header_id
+		JOIN items i ON i.id = sd.item_id
+		WHERE `+strings.ReplaceAll(customerCondition, "customer_", "sh.customer_"),
+		[]interface{}{customer.CustomerName, customer.CustomerPhone}, func(rows *sql.Rows) error {
+			var d sales.SalesDetail
+			if err := rows.Scan(&d.ID, &d.SalesHeaderID, &d.ItemID, &d.ItemCode, &d.ItemDescription, &d.Quantity, &d.Unit, &d.BaseQuantity, &d.Price, &d.Amount); err != nil {
+				return err
+			}
+			if i, ok := index[d.SalesHeaderID]; ok {
+				export.Sales[i].Details = append(export.Sales[i].Details, d)
+			}
+			return nil
+		})
+	if err != nil {
+		return CustomerExport{}, err
+	}
+
+	export.ActionLogs, err = r.actionLogs(ctx, mentionsCondition("$1"),
+		pq.Array(personalValues(customer.CustomerName, customer.CustomerPhone)))
+	if err != nil {
+		return CustomerExport{}, err
+	}
+	return export, nil
+}
+
+// AnonymizeCustomer treu el nom i el telèfon de les vendes del client. Les
+// vendes, les línies i els moviments d'estoc no es modifiquen.
+func (r *privacyRepository) AnonymizeCustomer(ctx context.Context, customer CustomerRequest) (AnonymizeResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return AnonymizeResult{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE sales_headers
+		SET customer_name = $3, customer_phone = NULL
+		WHERE `+customerCondition,
+		customer.CustomerName, customer.CustomerPhone, anonymizedCustomerName)
+	if err != nil {
+		return AnonymizeResult{}, fmt.Errorf("error anonymizing sales: %w", err)
+	}
+	headers, err := result.RowsAffected()
+	if err != nil {
+		return AnonymizeResult{}, err
+	}
+
+	logs, err := scrubActionLogs(ctx, tx, personalValues(customer.CustomerName, customer.CustomerPhone))
+	if err != nil {
+		return AnonymizeResult{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return AnonymizeResult{}, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return AnonymizeResult{SalesHeaders: headers, ActionLogs: logs}, nil
+}
+
+// customerCondition selecciona les vendes pel nom ($1) o pel telèfon ($2)
+const customerCondition = `((customer_name <> '' AND lower(customer_name) = lower(NULLIF($1, '')))
+	OR (customer_phone <> '' AND customer_phone = NULLIF($2, '')))`
+
+// personalKeys són les claus de les metadades que poden contenir dades
+// personals. Els altres camps (codis d'article, descripcions...) no es
+// toquen encara que coincideixin amb un nom.
+const personalKeys = `ARRAY['email', 'username', 'customer_name', 'customer_phone']`
+
+// personalPath és l'única ruta que porta una dada personal, el nom d'usuari,
+// com a últim segment
+const personalPath = `action_type ~ '^GET /api/users/username/[^/]+$'`
+
+// mentionsCondition selecciona els registres d'accions que contenen algun
+// dels valors (en minúscules) de l'array param en una de les personalKeys,
+// a qualsevol nivell de les metadades, o a personalPath.
+func mentionsCondition(param string) string {
+	return `(
+		EXISTS (
+			SELECT 1 FROM jsonb_path_query(metadata, 'strict $.** ? (@.type() == "object")') o, jsonb_each(o) e
+			WHERE e.key = ANY(` + personalKeys + `) AND jsonb_typeof(e.value) = 'string'
+				AND lower(e.value #>> '{}') = ANY(` + param + `))
+		OR (` + personalPath + ` AND lower(substring(action_type from '[^/]*$')) = ANY(` + param + `)))`
+}
+
+// scrubActionLogs substitueix els valors personals de les metadades i de les
+// rutes dels registres d'accions, sense eliminar els registres. Les
+// metadades es recorren amb scrub_personal_values (migració 024).
+func scrubActionLogs(ctx context.Context, db queryer, values []string) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	result, err := db.ExecContext(ctx, `
+		UPDATE action_logs
+		SET metadata = scrub_personal_values(metadata, `+personalKeys+`, $1, $2),
+			action_type = CASE WHEN `+personalPath+` AND lower(substring(action_type from '[^/]*$')) = ANY($1)
+				THEN regexp_replace(action_type, '[^/]*$', $2) ELSE action_type END
+		WHERE `+mentionsCondition("$1"),
+		pq.Array(values), anonymizedValue)
+	if err != nil {
+		return 0, fmt.Errorf("error anonymizing action logs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *privacyRepository) actionLogs(ctx context.Context, condition string, args ...interface{}) ([]ActionLog, error) {
+	logs := []ActionLog{}
+	err := r.collect(ctx, `
+		SELECT action_type, metadata, performed_at, impersonator_id FROM action_logs
+		WHERE `+condition+`
+		ORDER BY performed_at`,
+		args, func(rows *sql.Rows) error {
+			var l ActionLog
+			if err := rows.Scan(&l.ActionType, &l.Metadata, &l.PerformedAt, &l.ImpersonatorID); err != nil {
+				return err
+			}
+			logs = append(logs, l)
+			return nil
+		})
+	return logs, err
+}
+
+// collect executa la consulta i crida scan per a cada fila
+func (r *privacyRepository) collect(ctx context.Context, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error exporting personal data: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Errorf("error scanning personal data: %w", err)
+		}
+	}
+	return rows.Err()
+}
+
+// personalValues retorna els valors no buits en minúscules
+func personalValues(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,13 @@
+package privacy
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *PrivacyHandler) {
+	privacy := router.Group("/privacy")
+	{
+		privacy.GET("/users/:id/export", handler.ExportUser)
+		privacy.POST("/users/:id/anonymize", handler.AnonymizeUser)
+		privacy.GET("/customers/export", handler.ExportCustomer)
+		privacy.POST("/customers/anonymize", handler.AnonymizeCustomer)
+	}
+}
@@ -0,0 +1,109 @@
+package privacy
+
+import (
+	"context"
+	"frdy-api/internal/revocation"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PrivacyService interface {
+	ExportUser(ctx context.Context, id string) (UserExport, error)
+	// AnonymizeUser esborra les dades personals d'un usuari. requestedBy és
+	// l'administrador que ho demana, que no es pot anonimitzar a si mateix.
+	AnonymizeUser(ctx context.Context, requestedBy uuid.UUID, id string) (AnonymizeResult, error)
+	ExportCustomer(ctx context.Context, request CustomerRequest) (CustomerExport, error)
+	AnonymizeCustomer(ctx context.Context, request CustomerRequest) (AnonymizeResult, error)
+}
+
+type privacyService struct {
+	repo        PrivacyRepository
+	revocations revocation.Store
+}
+
+func NewPrivacyService(repo PrivacyRepository, revocations revocation.Store) PrivacyService {
+	return &privacyService{repo: repo, revocations: revocations}
+}
+
+func (s *privacyService) ExportUser(ctx context.Context, id string) (UserExport, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return UserExport{}, ErrInvalidID
+	}
+	user, err := s.repo.FindUser(ctx, userID)
+	if err != nil {
+		return UserExport{}, err
+	}
+	export, err := s.repo.ExportUser(ctx, user)
+	if err != nil {
+		return UserExport{}, err
+	}
+	export.ExportedAt = time.Now()
+	return export, nil
+}
+
+func (s *privacyService) AnonymizeUser(ctx context.Context, requestedBy uuid.UUID, id string) (AnonymizeResult, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return AnonymizeResult{}, ErrInvalidID
+	}
+	if userID == requestedBy {
+		return AnonymizeResult{}, ErrSelfAnonymization
+	}
+	user, err := s.repo.FindUser(ctx, userID)
+	if err != nil {
+		return AnonymizeResult{}, err
+	}
+	if user.AnonymizedAt != nil {
+		return AnonymizeResult{}, ErrAlreadyAnonymized
+	}
+
+	logs, err := s.repo.AnonymizeUser(ctx, user)
+	if err != nil {
+		return AnonymizeResult{}, err
+	}
+	// Els tokens que encara tingui deixen de ser vàlids
+	if err := s.revocations.RevokeUser(ctx, userID); err != nil {
+		return AnonymizeResult{}, err
+	}
+	return AnonymizeResult{ActionLogs: logs}, nil
+}
+
+func (s *privacyService) ExportCustomer(ctx context.Context, request CustomerRequest) (CustomerExport, error) {
+	request, err := normalizeCustomer(request)
+	if err != nil {
+		return CustomerExport{}, err
+	}
+	export, err := s.repo.ExportCustomer(ctx, request)
+	if err != nil {
+		return CustomerExport{}, err
+	}
+	export.ExportedAt = time.Now()
+	return export, nil
+}
+
+func (s *privacyService) AnonymizeCustomer(ctx context.Context, request CustomerRequest) (AnonymizeResult, error) {
+	request, err := normalizeCustomer(request)
+	if err != nil {
+		return AnonymizeResult{}, err
+	}
+	return s.repo.AnonymizeCustomer(ctx, request)
+}
+
+func normalizeCustomer(request CustomerRequest) (CustomerRequest, error) {
+	request.CustomerName = strings.TrimSpace(request.CustomerName)
+	request.CustomerPhone = strings.TrimSpace(request.CustomerPhone)
+	if request.CustomerName == "" && request.CustomerPhone == "" {
+		return CustomerRequest{}, ErrInvalidRequest
+	}
+	// Les vendes ja anonimitzades no són d'un client concret
+	if request.CustomerName == anonymizedCustomerName {
+		request.CustomerName = ""
+		if request.CustomerPhone == "" {
+			return CustomerRequest{}, ErrInvalidRequest
+		}
+	}
+	return request, nil
+}
@@ -13,8 +13,10 @@ type PurchaseHeaderRequest struct {
 // PurchaseDetailRequest represents the request payload for creating/updating a purchase detail
 type PurchaseDetailRequest struct {
 	PurchaseHeaderID string  `json:"purchase_header_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174000" description:"Purchase header ID (UUID)"`
-	ItemID          string  `json:"item_id" binding:"required" example:"123e4567-e89b-12d3-a456-426614174001" description:"Item ID (UUID)"`
-	Quantity        int     `json:"quantity" binding:"required,min=1" example:"10" description:"Quantity of items"`
-	Cost            float64 `json:"cost" binding:"required,min=0" example:"15.50" description:"Cost per unit"`
+	ItemID          string  `json:"item_id" example:"123e4567-e89b-12d3-a456-426614174001" description:"Item ID (UUID), required unless barcode is given"`
+	Barcode         string  `json:"barcode" example:"4006381333931" description:"Scanned barcode or alternative code of the item"`
+	Quantity        float64 `json:"quantity" binding:"required,gt=0" example:"10" description:"Quantity in the given unit"`
+	Unit            string  `json:"unit" example:"BOX" description:"Unit of the quantity (defaults to the item's base unit)"`
+	Cost            float64 `json:"cost" binding:"required,min=0" example:"15.50" description:"Cost per unit of the line"`
 	Amount          float64 `json:"amount" binding:"required,min=0" example:"155.00" description:"Total amount (quantity * cost)"`
 }
\ No newline at end of file
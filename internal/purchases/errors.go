@@ -0,0 +1,8 @@
+package purchases
+
+import "errors"
+
+var (
+	ErrPurchaseNotFound = errors.New("purchase header not found")
+	ErrAlreadyReceived  = errors.New("purchase has already been received")
+)
@@ -1,6 +1,9 @@
 package purchases
 
 import (
+	"errors"
+	"frdy-api/internal/barcodes"
+	"frdy-api/internal/units"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +17,23 @@ func NewPurchasesHandler(service PurchaseService) *PurchasesHandler {
 	return &PurchasesHandler{service: service}
 }
 
+// statusFromError retorna 400 per als errors de conversió d'unitats, causats
+// per la petició, 404 si el codi de barres no és de cap article o la compra no
+// existeix, 409 si la compra ja s'ha rebut i 500 per a la resta
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, barcodes.ErrCodeNotFound), errors.Is(err, ErrPurchaseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadyReceived):
+		return http.StatusConflict
+	case errors.Is(err, barcodes.ErrInvalidRequest), errors.Is(err, units.ErrUnitNotFound), errors.Is(err, units.ErrNoConversion), errors.Is(err, units.ErrFractionalQuantity),
+		errors.Is(err, units.ErrInvalidItemID), errors.Is(err, units.ErrItemNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // CreatePurchaseHeader godoc
 // @Summary Create a new purchase header
 // @Description Creates a new purchase header with the provided information
@@ -138,7 +158,7 @@ func (h *PurchasesHandler) DeletePurchaseByID(c *gin.Context) {
 
 // CreatePurchaseDetail godoc
 // @Summary Create a new purchase detail
-// @Description Creates a new purchase detail with the provided information
+// @Description Creates a new purchase detail from an item_id or a scanned barcode/supplier code, in any unit of the item
 // @Tags purchases
 // @Accept json
 // @Produce json
@@ -157,7 +177,7 @@ func (h *PurchasesHandler) CreatePurchaseDetail(c *gin.Context) {
 
 	detail, err := h.service.CreatePurchaseDetail(request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -187,7 +207,7 @@ func (h *PurchasesHandler) UpdatePurchaseDetail(c *gin.Context) {
 
 	detail, err := h.service.UpdatePurchaseDetail(id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -241,13 +261,15 @@ func (h *PurchasesHandler) DeletePurchaseDetailByID(c *gin.Context) {
 
 // ReceivePurchaseHeader godoc
 // @Summary Receive purchase header
-// @Description Marks a purchase header as received by its ID
+// @Description Marks a purchase header as received by its ID, adds the lines to stock and updates each item's weighted average cost and last purchase cost
 // @Tags purchase-headers
 // @Accept json
 // @Produce json
 // @Param id path string true "Purchase Header ID"
 // @Success 200 {object} PurchaseHeader "Purchase header received successfully"
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Purchase header not found"
+// @Failure 409 {object} map[string]string "Purchase already received"
 // @Failure 500 {object} map[string]string
 // @Router /api/purchases/headers/received/{id} [get]
 // @Security BearerAuth
@@ -255,7 +277,7 @@ func(h *PurchasesHandler) ReceivePurchaseHeader(c *gin.Context) {
 	id := c.Param("id")
 	header, err := h.service.ReceivePurchaseHeader(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -18,7 +18,9 @@ type PurchaseDetail struct {
 	ItemID          string  `json:"item_id" example:"123e4567-e89b-12d3-a456-426614174001" description:"Item ID (UUID)"`
 	ItemCode        string  `json:"item_code" example:"ITEM-001" description:"Item code from joined table"`
 	ItemDescription string  `json:"item_description" example:"Sample Item Description" description:"Item description from joined table"`
-	Quantity        int     `json:"quantity" example:"10" description:"Quantity of items"`
-	Cost            float64 `json:"cost" example:"15.50" description:"Cost per unit"`
+	Quantity        float64 `json:"quantity" example:"10" description:"Quantity in the line unit"`
+	Unit            string  `json:"unit" example:"BOX" description:"Unit of the quantity"`
+	BaseQuantity    float64 `json:"base_quantity" example:"120" description:"Quantity in the item's base unit"`
+	Cost            float64 `json:"cost" example:"15.50" description:"Cost per line unit"`
 	Amount          float64 `json:"amount" example:"155.00" description:"Total amount (quantity * cost)"`
 }
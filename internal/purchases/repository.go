@@ -2,7 +2,12 @@ package purchases
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"frdy-api/internal/items"
+	"frdy-api/internal/stock"
+
+	"github.com/google/uuid"
 )
 
 type PurchaseRepository interface {
@@ -105,9 +110,9 @@ func (r *purchaseRepository) DeletePurchaseByID(id string) error {
 
 func (r *purchaseRepository) CreatePurchaseDetail(detail PurchaseDetail) (PurchaseDetail, error) {
 	_, err := r.db.Exec(`
-		INSERT INTO purchase_details (id, item_id, purchase_header_id, quantity, cost, amount)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
-		detail.ID, detail.ItemID, detail.PurchaseHeaderID, detail.Quantity, detail.Cost, detail.Amount,
+		INSERT INTO purchase_details (id, item_id, purchase_header_id, quantity, unit, base_quantity, cost, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		detail.ID, detail.ItemID, detail.PurchaseHeaderID, detail.Quantity, detail.Unit, detail.BaseQuantity, detail.Cost, detail.Amount,
 	)
 	if err != nil {
 		return PurchaseDetail{}, fmt.Errorf("error inserting purchase detail: %w", err)
@@ -118,9 +123,9 @@ func (r *purchaseRepository) CreatePurchaseDetail(detail PurchaseDetail) (Purcha
 func (r *purchaseRepository) UpdatePurchaseDetail(detail PurchaseDetail) (PurchaseDetail, error) {
 	_, err := r.db.Exec(`
 		UPDATE purchase_details
-		SET item_id = $1, quantity = $2, cost = $3, amount = $4
-		WHERE id = $5`,
-		detail.ItemID, detail.Quantity, detail.Cost, detail.Amount, detail.ID,
+		SET item_id = $1, quantity = $2, unit = $3, base_quantity = $4, cost = $5, amount = $6
+		WHERE id = $7`,
+		detail.ItemID, detail.Quantity, detail.Unit, detail.BaseQuantity, detail.Cost, detail.Amount, detail.ID,
 	)
 	if err != nil {
 		return PurchaseDetail{}, fmt.Errorf("error updating purchase detail: %w", err)
@@ -129,9 +134,18 @@ func (r *purchaseRepository) UpdatePurchaseDetail(detail PurchaseDetail) (Purcha
 }
 
 func (r *purchaseRepository) FindDetailsByPurchaseID(headerID string) ([]PurchaseDetail, error) {
-	rows, err := r.db.Query(`
-		SELECT pd.id, pd.item_id, i.code AS item_code, i.description AS item_description,
-		       pd.quantity, pd.cost, pd.amount
+	return findDetails(r.db, headerID)
+}
+
+// querier és el que tenen en comú *sql.DB i *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func findDetails(db querier, headerID string) ([]PurchaseDetail, error) {
+	rows, err := db.Query(`
+		SELECT pd.id, pd.purchase_header_id, pd.item_id, i.code AS item_code, i.description AS item_description,
+		       pd.quantity, pd.unit, pd.base_quantity, pd.cost, pd.amount
 		FROM purchase_details pd
 		INNER JOIN items i ON pd.item_id = i.id
 		WHERE pd.purchase_header_id  = $1
@@ -144,13 +158,13 @@ func (r *purchaseRepository) FindDetailsByPurchaseID(headerID string) ([]Purchas
 	var details []PurchaseDetail
 	for rows.Next() {
 		var detail PurchaseDetail
-		if err := rows.Scan(&detail.ID, &detail.ItemID, &detail.ItemCode, &detail.ItemDescription,
-			&detail.Quantity, &detail.Cost, &detail.Amount); err != nil {
+		if err := rows.Scan(&detail.ID, &detail.PurchaseHeaderID, &detail.ItemID, &detail.ItemCode, &detail.ItemDescription,
+			&detail.Quantity, &detail.Unit, &detail.BaseQuantity, &detail.Cost, &detail.Amount); err != nil {
 			return nil, fmt.Errorf("error scanning purchase detail: %w", err)
 		}
 		details = append(details, detail)
 	}
-	return details, nil
+	return details, rows.Err()
 }
 
 func (r *purchaseRepository) DeletePurchaseDetailByID(id string) error {
@@ -163,14 +177,44 @@ func (r *purchaseRepository) DeletePurchaseDetailByID(id string) error {
 	return nil
 }
 
+// ReceivePurchaseHeader marca la compra com a rebuda i, a la mateixa
+// transacció, actualitza el cost mitjà i l'estoc de cada línia: o es rep
+// sencera o no es rep, i si falla es pot tornar a intentar.
 func (r *purchaseRepository) ReceivePurchaseHeader(id string) (PurchaseHeader, error) {
-	_, err := r.db.Exec(`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return PurchaseHeader{}, err
+	}
+	defer tx.Rollback()
+
+	// Només es rep una vegada: tornar-la a rebre duplicaria l'estoc i el cost
+	res, err := tx.Exec(`
 		UPDATE purchase_headers
 		SET received = TRUE
-		WHERE id = $1`, id)
+		WHERE id = $1 AND NOT received`, id)
 	if err != nil {
 		return PurchaseHeader{}, fmt.Errorf("error receiving purchase header: %w", err)
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.FindPurchaseByID(id); err != nil {
+			return PurchaseHeader{}, ErrPurchaseNotFound
+		}
+		return PurchaseHeader{}, ErrAlreadyReceived
+	}
+
+	details, err := findDetails(tx, id)
+	if err != nil {
+		return PurchaseHeader{}, err
+	}
+	// El cost mitjà es calcula amb l'estoc d'abans de sumar-hi la línia
+	for _, detail := range details {
+		if err := receiveDetail(tx, detail); err != nil {
+			return PurchaseHeader{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return PurchaseHeader{}, err
+	}
 
 	header, err := r.FindPurchaseByID(id)
 	if err != nil {
@@ -179,6 +223,39 @@ func (r *purchaseRepository) ReceivePurchaseHeader(id string) (PurchaseHeader, e
 	return header, nil
 }
 
+// receiveDetail aplica el cost i l'estoc d'una línia rebuda. El cost de la
+// línia és per unitat de compra (p. ex. la caixa), així que es passa a cost
+// per unitat base.
+func receiveDetail(tx *sql.Tx, detail PurchaseDetail) error {
+	if detail.BaseQuantity <= 0 {
+		return stock.AddQuantity(tx, detail.ItemID, detail.BaseQuantity)
+	}
+	itemID, err := uuid.Parse(detail.ItemID)
+	if err != nil {
+		return errors.New("invalid item ID format")
+	}
+	headerID, err := uuid.Parse(detail.PurchaseHeaderID)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+	detailID, err := uuid.Parse(detail.ID)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+
+	_, err = items.ApplyPurchaseCost(tx, items.CostEntry{
+		ItemID:           itemID,
+		PurchaseHeaderID: headerID,
+		PurchaseDetailID: detailID,
+		Quantity:         detail.BaseQuantity,
+		UnitCost:         detail.Cost * detail.Quantity / detail.BaseQuantity,
+	})
+	if err != nil {
+		return err
+	}
+	return stock.AddQuantity(tx, detail.ItemID, detail.BaseQuantity)
+}
+
 func(r *purchaseRepository) GetNextNumber()(string, error){
 	var nextCounter string
 	err := r.db.QueryRow(`
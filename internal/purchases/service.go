@@ -1,8 +1,11 @@
 package purchases
 
 import (
+	"context"
 	"errors"
+	"frdy-api/internal/barcodes"
 	"frdy-api/internal/stock"
+	"frdy-api/internal/units"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,10 +28,12 @@ type PurchaseService interface {
 type purchaseService struct {
 	repo PurchaseRepository
 	stock stock.StockService
+	units units.UnitService
+	barcodes barcodes.BarcodeService
 }
 
-func NewPurchaseService(repo PurchaseRepository, stock stock.StockService) PurchaseService {
-	return &purchaseService{repo: repo, stock: stock}
+func NewPurchaseService(repo PurchaseRepository, stock stock.StockService, units units.UnitService, barcodes barcodes.BarcodeService) PurchaseService {
+	return &purchaseService{repo: repo, stock: stock, units: units, barcodes: barcodes}
 }
 
 // Header methods
@@ -89,23 +94,38 @@ func (s *purchaseService) DeletePurchaseByID(id string) error {
 // Detail methods
 
 func (s *purchaseService) CreatePurchaseDetail(request PurchaseDetailRequest) (PurchaseDetail, error) {
+	if err := s.resolveItem(&request); err != nil {
+		return PurchaseDetail{}, err
+	}
 	if request.ItemID == "" || request.Quantity <= 0 || request.Cost <= 0 {
 		return PurchaseDetail{}, errors.New("invalid purchase detail request")
 	}
 
+	// Es compra en qualsevol unitat de l'article (p. ex. caixes); l'estoc es
+	// mou sempre en unitats base
+	conversion, err := s.units.ToBase(context.Background(), request.ItemID, request.Unit, request.Quantity)
+	if err != nil {
+		return PurchaseDetail{}, err
+	}
+
 	detail := PurchaseDetail{
 		ID:              uuid.New().String(),
 		PurchaseHeaderID: request.PurchaseHeaderID,
 		ItemID:          request.ItemID,
 		Quantity:        request.Quantity,
+		Unit:            conversion.Unit,
+		BaseQuantity:    conversion.BaseQuantity,
 		Cost:            request.Cost,
-		Amount:          float64(request.Quantity) * request.Cost,
+		Amount:          request.Quantity * request.Cost,
 	}
 
 	return s.repo.CreatePurchaseDetail(detail)
 }
 
 func (s *purchaseService) UpdatePurchaseDetail(id string, request PurchaseDetailRequest) (PurchaseDetail, error) {
+	if err := s.resolveItem(&request); err != nil {
+		return PurchaseDetail{}, err
+	}
 	if id == "" || request.ItemID == "" || request.Quantity <= 0 || request.Cost <= 0 {
 		return PurchaseDetail{}, errors.New("invalid purchase detail request")
 	}
@@ -115,12 +135,21 @@ func (s *purchaseService) UpdatePurchaseDetail(id string, request PurchaseDetail
 		return PurchaseDetail{}, errors.New("invalid ID format")
 	}
 
+	// Es compra en qualsevol unitat de l'article (p. ex. caixes); l'estoc es
+	// mou sempre en unitats base
+	conversion, err := s.units.ToBase(context.Background(), request.ItemID, request.Unit, request.Quantity)
+	if err != nil {
+		return PurchaseDetail{}, err
+	}
+
 	detail := PurchaseDetail{
 		ID:       id,
 		ItemID:   request.ItemID,
-		Quantity: request.Quantity,
-		Cost:     request.Cost,
-		Amount:   float64(request.Quantity) * request.Cost,
+		Quantity:     request.Quantity,
+		Unit:         conversion.Unit,
+		BaseQuantity: conversion.BaseQuantity,
+		Cost:         request.Cost,
+		Amount:       request.Quantity * request.Cost,
 	}
 
 	return s.repo.UpdatePurchaseDetail(detail)
@@ -145,19 +174,23 @@ func (s *purchaseService) ReceivePurchaseHeader(id string) (PurchaseHeader, erro
 		return PurchaseHeader{}, errors.New("id is required")
 	}
 
-	header, err := s.repo.ReceivePurchaseHeader(id)
-	if err != nil {
-		return PurchaseHeader{}, err
+	return s.repo.ReceivePurchaseHeader(id)
+}
+
+// resolveItem troba l'article de la línia pel codi de barres (o el codi del
+// proveïdor) quan no porta item_id. Si el codi és d'una altra unitat (p. ex.
+// una caixa) i la línia no n'indica cap, la quantitat s'entén en aquesta unitat.
+func (s *purchaseService) resolveItem(request *PurchaseDetailRequest) error {
+	if request.ItemID != "" || request.Barcode == "" {
+		return nil
 	}
-	details, err := s.repo.FindDetailsByPurchaseID(header.ID)
+	match, err := s.barcodes.Lookup(context.Background(), request.Barcode)
 	if err != nil {
-		return PurchaseHeader{}, err
+		return err
 	}
-	for _, detail := range details {
-		if err := s.stock.UpdateStockQuantity(detail.ItemID, detail.Quantity); err != nil {
-			return PurchaseHeader{}, err
-		}
+	request.ItemID = match.Item.ID.String()
+	if request.Unit == "" {
+		request.Unit = match.Unit
 	}
-
-	return header, nil
-}
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,46 @@
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IsClaimsRevoked comprova la revocació a partir de les claims d'un JWT:
+// "jti" identifica la família de tokens i "auth_time" el moment del login.
+func IsClaimsRevoked(ctx context.Context, store Store, claims map[string]interface{}) (bool, error) {
+	jti, _ := claims["jti"].(string)
+
+	userID := uuid.Nil
+	if id, ok := claims["id"].(string); ok {
+		if parsed, err := uuid.Parse(id); err == nil {
+			userID = parsed
+		}
+	}
+
+	authTime, ok := unixClaim(claims["auth_time"])
+	if !ok {
+		// Tokens emesos abans que existís auth_time
+		authTime, _ = unixClaim(claims["orig_iat"])
+	}
+
+	return store.IsRevoked(ctx, jti, userID, authTime)
+}
+
+func unixClaim(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(n, 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,84 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RevocationRepository interface {
+	RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	RevokeUser(ctx context.Context, userID uuid.UUID, before time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	FindUserRevocation(ctx context.Context, userID uuid.UUID) (time.Time, bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type revocationRepository struct {
+	db *sql.DB
+}
+
+func NewRevocationRepository(db *sql.DB) RevocationRepository {
+	return &revocationRepository{db: db}
+}
+
+func (r *revocationRepository) RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`,
+		jti, userID, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error revoking token: %w", err)
+	}
+	return nil
+}
+
+func (r *revocationRepository) RevokeUser(ctx context.Context, userID uuid.UUID, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_token_revocations (user_id, revoked_before)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_before = EXCLUDED.revoked_before`,
+		userID, before,
+	)
+	if err != nil {
+		return fmt.Errorf("error revoking user tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *revocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`, jti,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking revoked token: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *revocationRepository) FindUserRevocation(ctx context.Context, userID uuid.UUID) (time.Time, bool, error) {
+	var before time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT revoked_before FROM user_token_revocations WHERE user_id = $1`, userID,
+	).Scan(&before)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		return time.Time{}, false, fmt.Errorf("error checking user revocation: %w", err)
+	}
+	return before, true, nil
+}
+
+func (r *revocationRepository) DeleteExpired(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < now()`)
+	if err != nil {
+		return fmt.Errorf("error deleting expired revocations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// negativeTTL és el temps durant el qual es recorda que un token NO està
+// revocat. Les revocacions fetes per altres instàncies es veuen, com a molt,
+// passat aquest temps.
+const negativeTTL = 30 * time.Second
+
+// Store guarda les revocacions a Postgres i en manté una còpia en memòria
+// per no consultar la base de dades a cada petició.
+type Store interface {
+	// RevokeToken revoca un token (i tots els que se n'han refrescat, que
+	// comparteixen el mateix jti) fins a expiresAt.
+	RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	// RevokeUser revoca tots els tokens de l'usuari emesos fins ara.
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+	IsRevoked(ctx context.Context, jti string, userID uuid.UUID, authTime time.Time) (bool, error)
+}
+
+type cacheEntry struct {
+	revoked    bool
+	before     time.Time
+	validUntil time.Time
+}
+
+type store struct {
+	repo   RevocationRepository
+	mu     sync.RWMutex
+	tokens map[string]cacheEntry
+	users  map[uuid.UUID]cacheEntry
+}
+
+func NewStore(repo RevocationRepository) Store {
+	return &store{
+		repo:   repo,
+		tokens: make(map[string]cacheEntry),
+		users:  make(map[uuid.UUID]cacheEntry),
+	}
+}
+
+func (s *store) RevokeToken(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	if err := s.repo.RevokeToken(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.tokens[jti] = cacheEntry{revoked: true, validUntil: expiresAt}
+	s.evictExpired(time.Now())
+	s.mu.Unlock()
+
+	return s.repo.DeleteExpired(ctx)
+}
+
+func (s *store) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	before := time.Now().Truncate(time.Second)
+	if err := s.repo.RevokeUser(ctx, userID, before); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.users[userID] = cacheEntry{revoked: true, before: before, validUntil: time.Now().Add(negativeTTL)}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *store) IsRevoked(ctx context.Context, jti string, userID uuid.UUID, authTime time.Time) (bool, error) {
+	now := time.Now()
+
+	if jti != "" {
+		revoked, err := s.tokenRevoked(ctx, jti, now)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	if userID == uuid.Nil {
+		return false, nil
+	}
+	before, found, err := s.userRevokedBefore(ctx, userID, now)
+	if err != nil || !found {
+		return false, err
+	}
+	return authTime.Before(before), nil
+}
+
+func (s *store) tokenRevoked(ctx context.Context, jti string, now time.Time) (bool, error) {
+	s.mu.RLock()
+	entry, ok := s.tokens[jti]
+	s.mu.RUnlock()
+	if ok && (entry.revoked || now.Before(entry.validUntil)) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := s.repo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+	// Els tokens revocats es recorden fins que caduca la revocació; els no
+	// revocats només durant negativeTTL.
+	entry = cacheEntry{revoked: revoked, validUntil: now.Add(negativeTTL)}
+	s.mu.Lock()
+	s.tokens[jti] = entry
+	s.mu.Unlock()
+	return revoked, nil
+}
+
+func (s *store) userRevokedBefore(ctx context.Context, userID uuid.UUID, now time.Time) (time.Time, bool, error) {
+	s.mu.RLock()
+	entry, ok := s.users[userID]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.validUntil) {
+		return entry.before, entry.revoked, nil
+	}
+
+	before, found, err := s.repo.FindUserRevocation(ctx, userID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	s.mu.Lock()
+	s.users[userID] = cacheEntry{revoked: found, before: before, validUntil: now.Add(negativeTTL)}
+	s.mu.Unlock()
+	return before, found, nil
+}
+
+// evictExpired elimina de la memòria les entrades que ja no calen. S'ha de
+// cridar amb el mutex bloquejat.
+func (s *store) evictExpired(now time.Time) {
+	for jti, entry := range s.tokens {
+		if now.After(entry.validUntil) {
+			delete(s.tokens, jti)
+		}
+	}
+	for userID, entry := range s.users {
+		if now.After(entry.validUntil) {
+			delete(s.users, userID)
+		}
+	}
+}
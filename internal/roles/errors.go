@@ -0,0 +1,13 @@
+package roles
+
+import "errors"
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrInvalidID         = errors.New("invalid role ID")
+	ErrRoleNameTaken     = errors.New("role name already taken")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrRoleInUse         = errors.New("role is assigned to users")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrRoleNotGrantable  = errors.New("cannot grant a role with permissions you do not have")
+)
@@ -0,0 +1,181 @@
+package roles
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	service RoleService
+}
+
+func NewRoleHandler(service RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrUnknownPermission):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrRoleNotGrantable):
+		return http.StatusForbidden
+	case errors.Is(err, ErrRoleNameTaken), errors.Is(err, ErrRoleInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create godoc
+// @Summary Create a new role
+// @Description Creates a role with the given permissions. The caller must hold every permission of the role (Protected route, admin only)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body RoleRequest true "Role data"
+// @Success 201 {object} Role
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/roles [post]
+// @Security BearerAuth
+func (h *RoleHandler) Create(c *gin.Context) {
+	var request RoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.Create(c.Request.Context(), identity.Permissions(c), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+// Update godoc
+// @Summary Update a role
+// @Description Updates a role name, description and permissions. The caller must hold every permission the role has now and every one it is given (Protected route, admin only)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param request body RoleRequest true "Role data"
+// @Success 200 {object} Role
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/roles/{id} [put]
+// @Security BearerAuth
+func (h *RoleHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	var request RoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.Update(c.Request.Context(), id, identity.Permissions(c), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// Delete godoc
+// @Summary Delete a role
+// @Description Deletes a role that is not assigned to any user (Protected route, admin only)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/roles/{id} [delete]
+// @Security BearerAuth
+func (h *RoleHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// FindByID godoc
+// @Summary Get a role by ID
+// @Description Retrieves a role and its permissions (Protected route, admin only)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} Role
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/roles/{id} [get]
+// @Security BearerAuth
+func (h *RoleHandler) FindByID(c *gin.Context) {
+	id := c.Param("id")
+	role, err := h.service.FindByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// FindAll godoc
+// @Summary Get all roles
+// @Description Retrieves all roles and their permissions (Protected route, admin only)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Success 200 {array} Role
+// @Failure 500 {object} map[string]string
+// @Router /api/roles [get]
+// @Security BearerAuth
+func (h *RoleHandler) FindAll(c *gin.Context) {
+	roles, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// FindAllPermissions godoc
+// @Summary Get all permissions
+// @Description Retrieves every permission code that can be assigned to a role (Protected route, admin only)
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Success 200 {array} Permission
+// @Failure 500 {object} map[string]string
+// @Router /api/roles/permissions [get]
+// @Security BearerAuth
+func (h *RoleHandler) FindAllPermissions(c *gin.Context) {
+	permissions, err := h.service.FindAllPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, permissions)
+}
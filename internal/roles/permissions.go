@@ -0,0 +1,64 @@
+package roles
+
+// Permission codes. Es guarden a la taula permissions i s'assignen als rols
+// mitjançant role_permissions.
+const (
+	PermAll = "*"
+
+	PermItemsRead  = "items:read"
+	PermItemsWrite = "items:write"
+
+	PermStockRead  = "stock:read"
+	PermStockWrite = "stock:write"
+
+	PermSalesRead  = "sales:read"
+	PermSalesWrite = "sales:write"
+	PermSalesSend  = "sales:send"
+
+	PermPurchasesRead    = "purchases:read"
+	PermPurchasesWrite   = "purchases:write"
+	PermPurchasesReceive = "purchases:receive"
+
+	PermPricesRead  = "prices:read"
+	PermPricesWrite = "prices:write"
+
+	PermUsersManage      = "users:manage"
+	PermUsersImpersonate = "users:impersonate"
+	PermRolesManage      = "roles:manage"
+
+	PermAPIKeysManage = "apikeys:manage"
+
+	PermPrivacyManage = "privacy:manage"
+)
+
+// Built-in role names created by the roles migration.
+const (
+	RoleAdmin       = "admin"
+	RoleManager     = "manager"
+	RoleSalesClerk  = "sales_clerk"
+	RoleWarehouse   = "warehouse"
+	RoleReadOnly    = "read_only"
+	DefaultRoleName = RoleReadOnly
+)
+
+// HasPermission indica si els permisos concedits inclouen permission,
+// directament o amb el comodí PermAll
+func HasPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission || p == PermAll {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers indica si qui té els permisos granted pot concedir tots els de
+// requested: ningú pot donar més permisos dels que té
+func Covers(granted, requested []string) bool {
+	for _, permission := range requested {
+		if !HasPermission(granted, permission) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package roles
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role Role) (Role, error)
+	Update(ctx context.Context, role Role) (Role, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (Role, error)
+	FindByName(ctx context.Context, name string) (Role, error)
+	FindAll(ctx context.Context) ([]Role, error)
+	FindAllPermissions(ctx context.Context) ([]Permission, error)
+	CountUsers(ctx context.Context, id uuid.UUID) (int, error)
+}
+
+type roleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+const selectRoles = `
+	SELECT r.id, r.name, COALESCE(r.description, ''), r.created_at,
+		COALESCE(array_agg(rp.permission_code ORDER BY rp.permission_code) FILTER (WHERE rp.permission_code IS NOT NULL), '{}')
+	FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id`
+
+func (r *roleRepository) Create(ctx context.Context, role Role) (Role, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Role{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO roles (id, name, description)
+		VALUES ($1, $2, $3)`,
+		role.ID, role.Name, role.Description,
+	)
+	if err != nil {
+		return Role{}, fmt.Errorf("error inserting role: %w", err)
+	}
+	if err := insertPermissions(ctx, tx, role.ID, role.Permissions); err != nil {
+		return Role{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Role{}, fmt.Errorf("error committing role: %w", err)
+	}
+	return r.FindByID(ctx, role.ID)
+}
+
+func (r *roleRepository) Update(ctx context.Context, role Role) (Role, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Role{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE roles
+		SET name = $1, description = $2
+		WHERE id = $3`,
+		role.Name, role.Description, role.ID,
+	)
+	if err != nil {
+		return Role{}, fmt.Errorf("error updating role: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, role.ID); err != nil {
+		return Role{}, fmt.Errorf("error clearing role permissions: %w", err)
+	}
+	if err := insertPermissions(ctx, tx, role.ID, role.Permissions); err != nil {
+		return Role{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Role{}, fmt.Errorf("error committing role: %w", err)
+	}
+	return r.FindByID(ctx, role.ID)
+}
+
+func insertPermissions(ctx context.Context, tx *sql.Tx, roleID uuid.UUID, permissions []string) error {
+	for _, code := range permissions {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO role_permissions (role_id, permission_code)
+			VALUES ($1, $2)`,
+			roleID, code,
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting role permission: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *roleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM roles WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting role: %w", err)
+	}
+	return nil
+}
+
+func (r *roleRepository) FindByID(ctx context.Context, id uuid.UUID) (Role, error) {
+	row := r.db.QueryRowContext(ctx, selectRoles+`
+		WHERE r.id = $1
+		GROUP BY r.id`, id)
+	return scanRole(row)
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, name string) (Role, error) {
+	row := r.db.QueryRowContext(ctx, selectRoles+`
+		WHERE r.name = $1
+		GROUP BY r.id`, name)
+	return scanRole(row)
+}
+
+func scanRole(row *sql.Row) (Role, error) {
+	var role Role
+	err := row.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt, pq.Array(&role.Permissions))
+	if err == sql.ErrNoRows {
+		return Role{}, ErrRoleNotFound
+	} else if err != nil {
+		return Role{}, fmt.Errorf("error getting role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *roleRepository) FindAll(ctx context.Context) ([]Role, error) {
+	rows, err := r.db.QueryContext(ctx, selectRoles+`
+		GROUP BY r.id
+		ORDER BY r.name`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []Role
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt, pq.Array(&role.Permissions)); err != nil {
+			return nil, fmt.Errorf("error scanning role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *roleRepository) FindAllPermissions(ctx context.Context) ([]Permission, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT code, COALESCE(description, '')
+		FROM permissions
+		ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var permissions []Permission
+	for rows.Next() {
+		var permission Permission
+		if err := rows.Scan(&permission.Code, &permission.Description); err != nil {
+			return nil, fmt.Errorf("error scanning permission: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, rows.Err()
+}
+
+func (r *roleRepository) CountUsers(ctx context.Context, id uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role_id = $1`, id).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting role users: %w", err)
+	}
+	return count, nil
+}
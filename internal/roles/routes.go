@@ -0,0 +1,15 @@
+package roles
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *RoleHandler) {
+	roles := router.Group("/roles")
+	{
+		roles.POST("", handler.Create)
+		roles.PUT("/:id", handler.Update)
+		roles.DELETE("/:id", handler.Delete)
+		roles.GET("/permissions", handler.FindAllPermissions)
+		roles.GET("/:id", handler.FindByID)
+		roles.GET("", handler.FindAll)
+	}
+}
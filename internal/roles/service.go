@@ -0,0 +1,150 @@
+package roles
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type RoleService interface {
+	// Create i Update reben els permisos de qui fa el canvi: un rol només pot
+	// tenir permisos que aquest ja tingui
+	Create(ctx context.Context, callerPermissions []string, request RoleRequest) (Role, error)
+	Update(ctx context.Context, id string, callerPermissions []string, request RoleRequest) (Role, error)
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (Role, error)
+	FindAll(ctx context.Context) ([]Role, error)
+	FindAllPermissions(ctx context.Context) ([]Permission, error)
+}
+
+type roleService struct {
+	repo RoleRepository
+}
+
+func NewRoleService(repo RoleRepository) RoleService {
+	return &roleService{repo: repo}
+}
+
+func (s *roleService) Create(ctx context.Context, callerPermissions []string, request RoleRequest) (Role, error) {
+	if request.Name == "" {
+		return Role{}, ErrInvalidRequest
+	}
+	if !Covers(callerPermissions, request.Permissions) {
+		return Role{}, ErrRoleNotGrantable
+	}
+	if err := s.checkName(ctx, uuid.Nil, request.Name); err != nil {
+		return Role{}, err
+	}
+	if err := s.checkPermissions(ctx, request.Permissions); err != nil {
+		return Role{}, err
+	}
+
+	role := Role{
+		ID:          uuid.New(),
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: request.Permissions,
+	}
+	return s.repo.Create(ctx, role)
+}
+
+func (s *roleService) Update(ctx context.Context, id string, callerPermissions []string, request RoleRequest) (Role, error) {
+	roleID, err := uuid.Parse(id)
+	if err != nil {
+		return Role{}, ErrInvalidID
+	}
+	if request.Name == "" {
+		return Role{}, ErrInvalidRequest
+	}
+	current, err := s.repo.FindByID(ctx, roleID)
+	if err != nil {
+		return Role{}, err
+	}
+	// Tampoc es pot editar un rol amb més permisos que els propis (p. ex.
+	// per treure'ls a un administrador)
+	if !Covers(callerPermissions, current.Permissions) || !Covers(callerPermissions, request.Permissions) {
+		return Role{}, ErrRoleNotGrantable
+	}
+	if err := s.checkName(ctx, roleID, request.Name); err != nil {
+		return Role{}, err
+	}
+	if err := s.checkPermissions(ctx, request.Permissions); err != nil {
+		return Role{}, err
+	}
+
+	role := Role{
+		ID:          roleID,
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: request.Permissions,
+	}
+	return s.repo.Update(ctx, role)
+}
+
+func (s *roleService) Delete(ctx context.Context, id string) error {
+	roleID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if _, err := s.repo.FindByID(ctx, roleID); err != nil {
+		return err
+	}
+	count, err := s.repo.CountUsers(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+	return s.repo.Delete(ctx, roleID)
+}
+
+func (s *roleService) FindByID(ctx context.Context, id string) (Role, error) {
+	roleID, err := uuid.Parse(id)
+	if err != nil {
+		return Role{}, ErrInvalidID
+	}
+	return s.repo.FindByID(ctx, roleID)
+}
+
+func (s *roleService) FindAll(ctx context.Context) ([]Role, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *roleService) FindAllPermissions(ctx context.Context) ([]Permission, error) {
+	return s.repo.FindAllPermissions(ctx)
+}
+
+// checkName comprova que cap altre rol no tingui el mateix nom
+func (s *roleService) checkName(ctx context.Context, id uuid.UUID, name string) error {
+	existing, err := s.repo.FindByName(ctx, name)
+	if errors.Is(err, ErrRoleNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != id {
+		return ErrRoleNameTaken
+	}
+	return nil
+}
+
+// checkPermissions comprova que tots els codis existeixin a la taula permissions
+func (s *roleService) checkPermissions(ctx context.Context, codes []string) error {
+	known, err := s.repo.FindAllPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	valid := make(map[string]bool, len(known))
+	for _, permission := range known {
+		valid[permission.Code] = true
+	}
+	for _, code := range codes {
+		if !valid[code] {
+			return ErrUnknownPermission
+		}
+	}
+	return nil
+}
@@ -1,15 +1,25 @@
 package sales
 
+import "github.com/google/uuid"
+
 type SalesHeaderRequest struct {
 	Code          string `json:"code"`
 	CustomerName  string `json:"customer_name" binding:"required"`
 	CustomerPhone string `json:"customer_phone"`
+	CustomerGroupID *uuid.UUID `json:"customer_group_id"`
 }
 
 type SalesDetailRequest struct {
 	SalesHeaderID string  `json:"sales_header_id" binding:"required"`
-	ItemID        string  `json:"item_id" binding:"required"`
-	Quantity      int     `json:"quantity" binding:"required"`
-	Price         float64 `json:"price" binding:"required"`
-	Amount        float64 `json:"amount" binding:"required"`
+	// Cal item_id o barcode; amb barcode l'article (i la unitat, si el codi
+	// és d'una caixa) es troba pel codi escanejat
+	ItemID        string  `json:"item_id"`
+	Barcode       string  `json:"barcode"`
+	Quantity      float64 `json:"quantity" binding:"required"`
+	// Unitat de la quantitat; si és buida, la unitat base de l'article
+	Unit          string  `json:"unit"`
+	// Si no s'envia, el preu es resol a partir de les tarifes; un 0 explícit
+	// és una línia sense cost
+	Price         *float64 `json:"price"`
+	Amount        float64 `json:"amount"`
 }
\ No newline at end of file
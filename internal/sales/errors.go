@@ -0,0 +1,9 @@
+package sales
+
+import "errors"
+
+var (
+	ErrInvalidDate  = errors.New("invalid date format, expected YYYY-MM-DD")
+	ErrSaleNotFound = errors.New("sales header not found")
+	ErrAlreadySent  = errors.New("sale has already been sent")
+)
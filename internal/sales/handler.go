@@ -1,6 +1,10 @@
 package sales
 
 import (
+	"errors"
+	"frdy-api/internal/barcodes"
+	"frdy-api/internal/pricelists"
+	"frdy-api/internal/units"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +18,23 @@ func NewSalesHandler(service SalesService) *SalesHandler {
 	return &SalesHandler{service: service}
 }
 
+// statusFromError retorna 400 per als errors causats per la petició (dates,
+// unitats, conversions o grups de clients inexistents), 404 si el codi de barres no és de cap
+// article o la venda no existeix, 409 si la venda ja s'ha enviat i 500 per a la resta
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, barcodes.ErrCodeNotFound), errors.Is(err, ErrSaleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAlreadySent):
+		return http.StatusConflict
+	case errors.Is(err, barcodes.ErrInvalidRequest), errors.Is(err, pricelists.ErrItemNotFound), errors.Is(err, pricelists.ErrCustomerGroupNotFound), errors.Is(err, ErrInvalidDate), errors.Is(err, units.ErrUnitNotFound), errors.Is(err, units.ErrNoConversion),
+		errors.Is(err, units.ErrFractionalQuantity), errors.Is(err, units.ErrInvalidItemID), errors.Is(err, units.ErrItemNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // CreateSalesHeader godoc
 // @Summary Create a new sales header
 // @Description Create a new sales header with the provided information (Protected route)
@@ -35,7 +56,7 @@ func (h *SalesHandler) CreateSalesHeader(c *gin.Context) {
 
 	header, err := h.service.CreateSalesHeader(request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -65,7 +86,7 @@ func (h *SalesHandler) UpdateSalesHeader(c *gin.Context) {
 
 	header, err := h.service.UpdateSalesHeader(id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -204,12 +225,14 @@ func (h *SalesHandler) DeleteSalesByHeaderID(c *gin.Context) {
 
 // SendSalesHeader godoc
 // @Summary Send sales header
-// @Description Send/confirm a sales header by ID (Protected route)
+// @Description Send/confirm a sales header by ID and subtract its lines from stock; a sale can only be sent once (Protected route)
 // @Tags sales-headers
 // @Accept json
 // @Produce json
 // @Param id path string true "Sales Header ID"
 // @Success 200 {object} SalesHeader
+// @Failure 404 {object} map[string]string "Sales header not found"
+// @Failure 409 {object} map[string]string "Sale already sent"
 // @Failure 500 {object} map[string]string
 // @Router /api/sales/headers/send/{id} [post]
 // @Security BearerAuth
@@ -217,7 +240,7 @@ func (h *SalesHandler) SendSalesHeader(c *gin.Context) {
 	id := c.Param("id")
 	header, err := h.service.SendSalesHeader(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -226,7 +249,7 @@ func (h *SalesHandler) SendSalesHeader(c *gin.Context) {
 
 // CreateSalesDetail godoc
 // @Summary Create a new sales detail
-// @Description Create a new sales detail with the provided information (Protected route)
+// @Description Create a new sales detail from an item_id or a scanned barcode; the quantity may be given in any unit of the item and is converted to base units for stock. Without a price, the line gets the price resolved from the price lists (Protected route)
 // @Tags sales-details
 // @Accept json
 // @Produce json
@@ -245,7 +268,7 @@ func (h *SalesHandler) CreateSalesDetail(c *gin.Context) {
 
 	detail, err := h.service.CreateSalesDetail(request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -275,7 +298,7 @@ func (h *SalesHandler) UpdateSalesDetail(c *gin.Context) {
 
 	detail, err := h.service.UpdateSalesDetail(id, request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -324,4 +347,27 @@ func (h *SalesHandler) FindSalesDetailsByHeaderID(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, details)
-}
\ No newline at end of file
+}
+
+// GetSalesByCategory godoc
+// @Summary Get sales grouped by category
+// @Description Retrieve sold quantities and amounts per item category, both for the items directly in each category and including its subcategories (Protected route)
+// @Tags sales-headers
+// @Accept json
+// @Produce json
+// @Param from query string false "First sales date (YYYY-MM-DD)"
+// @Param to query string false "Last sales date (YYYY-MM-DD)"
+// @Success 200 {array} CategorySales
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/sales/by-category [get]
+// @Security BearerAuth
+func (h *SalesHandler) GetSalesByCategory(c *gin.Context) {
+	report, err := h.service.GetSalesByCategory(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
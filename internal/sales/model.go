@@ -9,6 +9,8 @@ type SalesHeader struct {
 	CustomerPhone string `json:"customer_phone"`
 	CreatedAt    string    `json:"created_at" binding:"required"`
 	Sent         bool      `json:"sent" binding:"required"`
+	// Grup de clients que decideix quines tarifes s'apliquen a les línies
+	CustomerGroupID *uuid.UUID `json:"customer_group_id"`
 }
 
 type SalesDetail struct {
@@ -17,7 +19,23 @@ type SalesDetail struct {
 	ItemID          string  `json:"item_id" binding:"required"`
 	ItemCode        string  `json:"item_code" binding:"required"`
 	ItemDescription string  `json:"item_description" binding:"required"`
-	Quantity        int     `json:"quantity" binding:"required"`
+	Quantity        float64 `json:"quantity" binding:"required"`
+	Unit            string  `json:"unit"`
+	BaseQuantity    float64 `json:"base_quantity"`
 	Price           float64 `json:"price" binding:"required"`
 	Amount          float64 `json:"amount" binding:"required"`
-}
\ No newline at end of file
+}
+
+// CategorySales són les vendes agrupades per categoria d'article, amb les
+// quantitats en unitats base. Quantity i Amount només compten els articles assignats directament a la categoria;
+// TotalQuantity i TotalAmount hi sumen els de totes les subcategories. La fila
+// sense CategoryID agrupa els articles sense categoria.
+type CategorySales struct {
+	CategoryID    *string `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	ParentID      *string `json:"parent_id"`
+	Quantity      float64 `json:"quantity"`
+	Amount        float64 `json:"amount"`
+	TotalQuantity float64 `json:"total_quantity"`
+	TotalAmount   float64 `json:"total_amount"`
+}
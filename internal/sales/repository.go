@@ -3,6 +3,9 @@ package sales
 import (
 	"database/sql"
 	"fmt"
+	"frdy-api/internal/categories"
+	"frdy-api/internal/stock"
+	"time"
 )
 
 type SalesRepository interface {
@@ -20,6 +23,7 @@ type SalesRepository interface {
 	DeleteSalesDetailByID(id string) error
 	SendSalesHeader(id string) (SalesHeader, error)
 	GetNextNumber()(string, error)
+	GetSalesByCategory(from, to *time.Time) ([]CategorySales, error)
 }
 
 type salesRepository struct {
@@ -31,9 +35,9 @@ func NewSalesRepository(db *sql.DB) SalesRepository {
 }
 func (r *salesRepository) CreateSalesHeader(header SalesHeader) (SalesHeader, error) {
 	_, err := r.db.Exec(`
-		INSERT INTO sales_headers (id, code, customer_name, customer_phone, created_at, sent)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
-		header.ID, header.Code, header.CustomerName, header.CustomerPhone, header.CreatedAt, header.Sent,
+		INSERT INTO sales_headers (id, code, customer_name, customer_phone, created_at, sent, customer_group_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		header.ID, header.Code, header.CustomerName, header.CustomerPhone, header.CreatedAt, header.Sent, header.CustomerGroupID,
 	)
 	if err != nil {
 		return SalesHeader{}, fmt.Errorf("error inserting sales header: %w", err)
@@ -44,9 +48,9 @@ func (r *salesRepository) CreateSalesHeader(header SalesHeader) (SalesHeader, er
 func (r *salesRepository) UpdateSalesHeader(header SalesHeader) (SalesHeader, error) {
 	_, err := r.db.Exec(`
 		UPDATE sales_headers
-		SET code = $1, customer_name = $2, customer_phone= $3, sent = $4
-		WHERE id = $5`,
-		header.Code, header.CustomerName,header.CustomerPhone, header.Sent, header.ID,
+		SET code = $1, customer_name = $2, customer_phone= $3, sent = $4, customer_group_id = $5
+		WHERE id = $6`,
+		header.Code, header.CustomerName,header.CustomerPhone, header.Sent, header.CustomerGroupID, header.ID,
 	)
 	if err != nil {
 		return SalesHeader{}, fmt.Errorf("error updating sales header: %w", err)
@@ -55,12 +59,12 @@ func (r *salesRepository) UpdateSalesHeader(header SalesHeader) (SalesHeader, er
 }
 func (r *salesRepository) FindSalesByHeaderID(id string) (SalesHeader, error) {
 	row := r.db.QueryRow(`
-		SELECT id, code, customer_name,COALESCE(customer_phone,'') as customer_phone, created_at, sent
+		SELECT id, code, customer_name,COALESCE(customer_phone,'') as customer_phone, created_at, sent, customer_group_id
 		FROM sales_headers
 		WHERE id = $1`, id)
 
 	var header SalesHeader
-	if err := row.Scan(&header.ID, &header.Code, &header.CustomerName,&header.CustomerPhone, &header.CreatedAt, &header.Sent); err != nil {
+	if err := row.Scan(&header.ID, &header.Code, &header.CustomerName,&header.CustomerPhone, &header.CreatedAt, &header.Sent, &header.CustomerGroupID); err != nil {
 		if err == sql.ErrNoRows {
 			return SalesHeader{}, fmt.Errorf("sales header not found: %w", err)
 		}
@@ -70,12 +74,12 @@ func (r *salesRepository) FindSalesByHeaderID(id string) (SalesHeader, error) {
 }
 func (r *salesRepository) FindSalesByHeaderCode(code string) (SalesHeader, error) {
 	row := r.db.QueryRow(`
-		SELECT id, code, customer_name, COALESCE(customer_phone,'') as customer_phone, created_at, sent
+		SELECT id, code, customer_name, COALESCE(customer_phone,'') as customer_phone, created_at, sent, customer_group_id
 		FROM sales_headers
 		WHERE code = $1`, code)
 
 	var header SalesHeader
-	if err := row.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent); err != nil {
+	if err := row.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent, &header.CustomerGroupID); err != nil {
 		if err == sql.ErrNoRows {
 			return SalesHeader{}, fmt.Errorf("sales header not found: %w", err)
 		}
@@ -85,7 +89,7 @@ func (r *salesRepository) FindSalesByHeaderCode(code string) (SalesHeader, error
 }
 func (r *salesRepository) FindSalesByItemCode(itemCode string) ([]SalesHeader, error) {
 	rows, err := r.db.Query(`
-		SELECT sh.id, sh.code, sh.customer_name, COALESCE(sh.customer_phone,'') as customer_phone, sh.created_at, sh.sent
+		SELECT sh.id, sh.code, sh.customer_name, COALESCE(sh.customer_phone,'') as customer_phone, sh.created_at, sh.sent, sh.customer_group_id
 		FROM sales_headers sh
 		JOIN sales_details sd ON sh.id = sd.sales_header_id
 		WHERE sd.item_code = $1`, itemCode)
@@ -97,7 +101,7 @@ func (r *salesRepository) FindSalesByItemCode(itemCode string) ([]SalesHeader, e
 	var headers []SalesHeader
 	for rows.Next() {
 		var header SalesHeader
-		if err := rows.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent); err != nil {
+		if err := rows.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent, &header.CustomerGroupID); err != nil {
 			return nil, fmt.Errorf("error scanning sales header: %w", err)
 		}
 		headers = append(headers, header)
@@ -106,7 +110,7 @@ func (r *salesRepository) FindSalesByItemCode(itemCode string) ([]SalesHeader, e
 }
 func (r *salesRepository) FindSalesByCustomerName(customerName string) ([]SalesHeader, error) {
 	rows, err := r.db.Query(`
-		SELECT id, code, customer_name, COALESCE(customer_phone,'') as customer_phone, created_at, sent
+		SELECT id, code, customer_name, COALESCE(customer_phone,'') as customer_phone, created_at, sent, customer_group_id
 		FROM sales_headers
 		WHERE customer_name ILIKE $1`, "%"+customerName+"%")
 	if err != nil {
@@ -117,7 +121,7 @@ func (r *salesRepository) FindSalesByCustomerName(customerName string) ([]SalesH
 	var headers []SalesHeader
 	for rows.Next() {
 		var header SalesHeader
-		if err := rows.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent); err != nil {
+		if err := rows.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent, &header.CustomerGroupID); err != nil {
 			return nil, fmt.Errorf("error scanning sales header: %w", err)
 		}
 		headers = append(headers, header)
@@ -126,7 +130,7 @@ func (r *salesRepository) FindSalesByCustomerName(customerName string) ([]SalesH
 }
 func (r *salesRepository) FindAllSales() ([]SalesHeader, error) {
 	rows, err := r.db.Query(`
-		SELECT id, code, customer_name, COALESCE(customer_phone,'') as customer_phone,  created_at, sent
+		SELECT id, code, customer_name, COALESCE(customer_phone,'') as customer_phone,  created_at, sent, customer_group_id
 		FROM sales_headers`)
 	if err != nil {
 		return nil, fmt.Errorf("error querying all sales: %w", err)
@@ -136,7 +140,7 @@ func (r *salesRepository) FindAllSales() ([]SalesHeader, error) {
 	var headers []SalesHeader
 	for rows.Next() {
 		var header SalesHeader
-		if err := rows.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent); err != nil {
+		if err := rows.Scan(&header.ID, &header.Code, &header.CustomerName, &header.CustomerPhone, &header.CreatedAt, &header.Sent, &header.CustomerGroupID); err != nil {
 			return nil, fmt.Errorf("error scanning sales header: %w", err)
 		}
 		headers = append(headers, header)
@@ -155,10 +159,10 @@ func (r *salesRepository) DeleteSalesByHeaderID(id string) error {
 }
 func (r *salesRepository) CreateSalesDetail(detail SalesDetail) (SalesDetail, error) {
 	_, err := r.db.Exec(`
-		INSERT INTO sales_details (id, sales_header_id, item_id, quantity, price, amount)
-		VALUES ($1, $2, $3, $4, $5, $6)`,
+		INSERT INTO sales_details (id, sales_header_id, item_id, quantity, unit, base_quantity, price, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 		detail.ID, detail.SalesHeaderID, detail.ItemID,
-		detail.Quantity, detail.Price, detail.Amount,
+		detail.Quantity, detail.Unit, detail.BaseQuantity, detail.Price, detail.Amount,
 	)
 	if err != nil {
 		return SalesDetail{}, fmt.Errorf("error inserting sales detail: %w", err)
@@ -169,9 +173,9 @@ func (r *salesRepository) CreateSalesDetail(detail SalesDetail) (SalesDetail, er
 func (r *salesRepository) UpdateSalesDetail(detail SalesDetail) (SalesDetail, error) {
 	_, err := r.db.Exec(`
 		UPDATE sales_details
-		SET item_id = $1, quantity = $2, price = $3, amount = $4
-		WHERE id = $5`,
-		detail.ItemID, detail.Quantity, detail.Price, detail.Amount, detail.ID,
+		SET item_id = $1, quantity = $2, unit = $3, base_quantity = $4, price = $5, amount = $6
+		WHERE id = $7`,
+		detail.ItemID, detail.Quantity, detail.Unit, detail.BaseQuantity, detail.Price, detail.Amount, detail.ID,
 	)
 	if err != nil {
 		return SalesDetail{}, fmt.Errorf("error updating sales detail: %w", err)
@@ -180,8 +184,17 @@ func (r *salesRepository) UpdateSalesDetail(detail SalesDetail) (SalesDetail, er
 }
 
 func (r *salesRepository) FindSalesDetailsByHeaderID(headerID string) ([]SalesDetail, error) {
-	rows, err := r.db.Query(`
-		SELECT sd.id, sd.sales_header_id, sd.item_id, i.code as item_code, i.description as item_description, sd.quantity, sd.price, sd.amount
+	return findDetails(r.db, headerID)
+}
+
+// querier és el que tenen en comú *sql.DB i *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func findDetails(db querier, headerID string) ([]SalesDetail, error) {
+	rows, err := db.Query(`
+		SELECT sd.id, sd.sales_header_id, sd.item_id, i.code as item_code, i.description as item_description, sd.quantity, sd.unit, sd.base_quantity, sd.price, sd.amount
 		FROM sales_details sd
 		INNER JOIN items i ON sd.item_id = i.id
 		WHERE sales_header_id = $1`, headerID)
@@ -194,8 +207,8 @@ func (r *salesRepository) FindSalesDetailsByHeaderID(headerID string) ([]SalesDe
 	for rows.Next() {
 		var detail SalesDetail
 		if err := rows.Scan(&detail.ID, &detail.SalesHeaderID, &detail.ItemID,
-			&detail.ItemCode, &detail.ItemDescription, &detail.Quantity,
-			&detail.Price, &detail.Amount); err != nil {
+			&detail.ItemCode, &detail.ItemDescription, &detail.Quantity, &detail.Unit,
+			&detail.BaseQuantity, &detail.Price, &detail.Amount); err != nil {
 			return nil, fmt.Errorf("error scanning sales detail: %w", err)
 		}
 		details = append(details, detail)
@@ -214,13 +227,39 @@ func (r *salesRepository) DeleteSalesDetailByID(id string) error {
 }
 
 func (r *salesRepository) SendSalesHeader(id string) (SalesHeader, error) {
-	_, err := r.db.Exec(`
+	tx, err := r.db.Begin()
+	if err != nil {
+		return SalesHeader{}, err
+	}
+	defer tx.Rollback()
+
+	// Només s'envia una vegada: tornar-la a enviar descomptaria l'estoc de nou
+	res, err := tx.Exec(`
 		UPDATE sales_headers
 		SET sent = true
-		WHERE id = $1`, id)
+		WHERE id = $1 AND NOT sent`, id)
 	if err != nil {
 		return SalesHeader{}, fmt.Errorf("error sending sales header: %w", err)
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := r.FindSalesByHeaderID(id); err != nil {
+			return SalesHeader{}, ErrSaleNotFound
+		}
+		return SalesHeader{}, ErrAlreadySent
+	}
+
+	details, err := findDetails(tx, id)
+	if err != nil {
+		return SalesHeader{}, err
+	}
+	for _, detail := range details {
+		if err := stock.AddQuantity(tx, detail.ItemID, -detail.BaseQuantity); err != nil {
+			return SalesHeader{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return SalesHeader{}, err
+	}
 
 	header, err := r.FindSalesByHeaderID(id)
 	if err != nil {
@@ -246,4 +285,49 @@ func (r *salesRepository) GetNextNumber()(string, error){
 		return "", err
 	}
 	return nextCounter, nil
-}
\ No newline at end of file
+}
+
+// GetSalesByCategory agrupa les línies de venda per categoria. from i to
+// (exclusiu) limiten les capçaleres per data de creació si no són nil.
+func (r *salesRepository) GetSalesByCategory(from, to *time.Time) ([]CategorySales, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE `+categories.ClosureCTE+`,
+		lines AS (
+			SELECT sd.item_id, sd.base_quantity AS quantity, sd.amount
+			FROM sales_details sd
+				INNER JOIN sales_headers sh ON sh.id = sd.sales_header_id
+			WHERE ($1::timestamptz IS NULL OR sh.created_at >= $1)
+				AND ($2::timestamptz IS NULL OR sh.created_at < $2)
+		)
+		SELECT cat.id, cat.name, cat.parent_id,
+			COALESCE(SUM(l.quantity) FILTER (WHERE i.category_id = cat.id), 0) as quantity,
+			COALESCE(SUM(l.amount) FILTER (WHERE i.category_id = cat.id), 0) as amount,
+			COALESCE(SUM(l.quantity), 0) as total_quantity,
+			COALESCE(SUM(l.amount), 0) as total_amount
+		FROM categories cat
+			INNER JOIN closure cl ON cl.root_id = cat.id
+			LEFT JOIN items i ON i.category_id = cl.id
+			LEFT JOIN lines l ON l.item_id = i.id
+		GROUP BY cat.id, cat.name, cat.parent_id
+		UNION ALL
+		SELECT NULL, '', NULL, COALESCE(SUM(l.quantity), 0), COALESCE(SUM(l.amount), 0),
+			COALESCE(SUM(l.quantity), 0), COALESCE(SUM(l.amount), 0)
+		FROM lines l
+			INNER JOIN items i ON l.item_id = i.id
+		WHERE i.category_id IS NULL
+		ORDER BY 2`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error querying sales by category: %w", err)
+	}
+	defer rows.Close()
+
+	var report []CategorySales
+	for rows.Next() {
+		var row CategorySales
+		if err := rows.Scan(&row.CategoryID, &row.CategoryName, &row.ParentID, &row.Quantity, &row.Amount, &row.TotalQuantity, &row.TotalAmount); err != nil {
+			return nil, fmt.Errorf("error scanning sales by category row: %w", err)
+		}
+		report = append(report, row)
+	}
+	return report, rows.Err()
+}
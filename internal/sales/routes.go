@@ -16,4 +16,5 @@ func RegisterRoutes(router *gin.RouterGroup, handler *SalesHandler) {
 	router.GET("/sales/details/:headerID", handler.FindSalesDetailsByHeaderID)
 	router.DELETE("/sales/details/:id", handler.DeleteSalesDetailByID)
 	router.GET("/sales/headers/send/:id", handler.SendSalesHeader)
+	router.GET("/sales/by-category", handler.GetSalesByCategory)
 }
@@ -1,8 +1,12 @@
 package sales
 
 import (
+	"context"
 	"errors"
+	"frdy-api/internal/barcodes"
+	"frdy-api/internal/pricelists"
 	"frdy-api/internal/stock"
+	"frdy-api/internal/units"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,15 +26,19 @@ type SalesService interface {
 	FindSalesDetailsByHeaderID(headerID string) ([]SalesDetail, error)
 	DeleteSalesDetailByID(id string) error
 	SendSalesHeader(id string) (SalesHeader, error)
+	GetSalesByCategory(from, to string) ([]CategorySales, error)
 }
 
 type salesService struct {
 	repo SalesRepository
 	stock stock.StockService
+	units units.UnitService
+	barcodes barcodes.BarcodeService
+	prices pricelists.PriceListService
 }
 
-func NewSalesService(repo SalesRepository, stock stock.StockService) SalesService {
-	return &salesService{repo: repo, stock:stock}
+func NewSalesService(repo SalesRepository, stock stock.StockService, units units.UnitService, barcodes barcodes.BarcodeService, prices pricelists.PriceListService) SalesService {
+	return &salesService{repo: repo, stock:stock, units: units, barcodes: barcodes, prices: prices}
 }
 
 func (s *salesService) CreateSalesHeader(request SalesHeaderRequest) (SalesHeader, error) {
@@ -38,6 +46,10 @@ func (s *salesService) CreateSalesHeader(request SalesHeaderRequest) (SalesHeade
 		return SalesHeader{}, errors.New("invalid request")
 	}
 
+	if err := s.checkCustomerGroup(request.CustomerGroupID); err != nil {
+		return SalesHeader{}, err
+	}
+
 	counter, err := s.repo.GetNextNumber()
 	if err != nil {
 		return SalesHeader{}, errors.New("cannot get counter")
@@ -48,6 +60,7 @@ func (s *salesService) CreateSalesHeader(request SalesHeaderRequest) (SalesHeade
 		Code:         counter,
 		CustomerName: request.CustomerName,
 		CustomerPhone: request.CustomerPhone,
+		CustomerGroupID: request.CustomerGroupID,
 		CreatedAt:    time.Now().Format(time.RFC3339),
 		Sent:         false, // Default to not sent
 	}
@@ -65,11 +78,16 @@ func (s *salesService) UpdateSalesHeader(id string, request SalesHeaderRequest)
 		return SalesHeader{}, errors.New("invalid ID format")
 	}
 
+	if err := s.checkCustomerGroup(request.CustomerGroupID); err != nil {
+		return SalesHeader{}, err
+	}
+
 	header := SalesHeader{
 		ID:           headerID,
 		Code:         request.Code,
 		CustomerName: request.CustomerName,
 		CustomerPhone: request.CustomerPhone,
+		CustomerGroupID: request.CustomerGroupID,
 	}
 
 	return s.repo.UpdateSalesHeader(header)
@@ -124,24 +142,43 @@ func (s *salesService) DeleteSalesByHeaderID(id string) error {
 }
 
 func (s *salesService) CreateSalesDetail(request SalesDetailRequest) (SalesDetail, error) {
-	if request.SalesHeaderID == "" || request.ItemID == "" || request.Quantity <= 0 || request.Price <= 0 {
+	if err := s.resolveItem(&request); err != nil {
+		return SalesDetail{}, err
+	}
+	if request.SalesHeaderID == "" || request.ItemID == "" || request.Quantity <= 0 || (request.Price != nil && *request.Price < 0) {
 		return SalesDetail{}, errors.New("invalid request")
 	}
 
+	// La quantitat es pot indicar en qualsevol unitat de l'article; l'estoc es
+	// mou sempre en unitats base
+	conversion, err := s.units.ToBase(context.Background(), request.ItemID, request.Unit, request.Quantity)
+	if err != nil {
+		return SalesDetail{}, err
+	}
+	price, err := s.linePrice(request, conversion.Unit)
+	if err != nil {
+		return SalesDetail{}, err
+	}
+
 	detail := SalesDetail{
 		ID:              uuid.New(),
 		SalesHeaderID:   request.SalesHeaderID,
 		ItemID:          request.ItemID,
 		Quantity:        request.Quantity,
-		Price:           request.Price,
-		Amount:          float64(request.Quantity) * request.Price, // Calculate amount
+		Unit:            conversion.Unit,
+		BaseQuantity:    conversion.BaseQuantity,
+		Price:           price,
+		Amount:          request.Quantity * price, // Calculate amount
 	}
 
 	return s.repo.CreateSalesDetail(detail)
 }
 
 func (s *salesService) UpdateSalesDetail(id string, request SalesDetailRequest) (SalesDetail, error) {
-	if request.SalesHeaderID == "" || request.ItemID == "" || request.Quantity <= 0 || request.Price <= 0 {
+	if err := s.resolveItem(&request); err != nil {
+		return SalesDetail{}, err
+	}
+	if request.SalesHeaderID == "" || request.ItemID == "" || request.Quantity <= 0 || (request.Price != nil && *request.Price < 0) {
 		return SalesDetail{}, errors.New("invalid request")
 	}
 
@@ -150,13 +187,26 @@ func (s *salesService) UpdateSalesDetail(id string, request SalesDetailRequest)
 		return SalesDetail{}, errors.New("invalid ID format")
 	}
 
+	// La quantitat es pot indicar en qualsevol unitat de l'article; l'estoc es
+	// mou sempre en unitats base
+	conversion, err := s.units.ToBase(context.Background(), request.ItemID, request.Unit, request.Quantity)
+	if err != nil {
+		return SalesDetail{}, err
+	}
+	price, err := s.linePrice(request, conversion.Unit)
+	if err != nil {
+		return SalesDetail{}, err
+	}
+
 	detail := SalesDetail{
 		ID:              detailID,
 		SalesHeaderID:   request.SalesHeaderID,
 		ItemID:          request.ItemID,
 		Quantity:        request.Quantity,
-		Price:           request.Price,
-		Amount:          float64(request.Quantity) * request.Price, // Calculate amount
+		Unit:            conversion.Unit,
+		BaseQuantity:    conversion.BaseQuantity,
+		Price:           price,
+		Amount:          request.Quantity * price, // Calculate amount
 	}
 
 	return s.repo.UpdateSalesDetail(detail)
@@ -186,20 +236,81 @@ func (s *salesService) SendSalesHeader(id string) (SalesHeader, error) {
 		return SalesHeader{}, errors.New("invalid ID")
 	}
 
-	header, err := s.repo.SendSalesHeader(id)
-	if err != nil {
-		return SalesHeader{}, err
+	return s.repo.SendSalesHeader(id)
+}
+
+// GetSalesByCategory retorna les vendes per categoria entre les dates from i
+// to (format 2006-01-02, ambdues incloses); qualsevol de les dues pot ser buida.
+func (s *salesService) GetSalesByCategory(from, to string) ([]CategorySales, error) {
+	var fromDate, toDate *time.Time
+	if from != "" {
+		date, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		fromDate = &date
+	}
+	if to != "" {
+		date, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return nil, ErrInvalidDate
+		}
+		// to és inclusiu: es compara amb l'inici del dia següent
+		date = date.AddDate(0, 0, 1)
+		toDate = &date
+	}
+	return s.repo.GetSalesByCategory(fromDate, toDate)
+}
+
+// resolveItem troba l'article de la línia pel codi de barres quan no porta
+// item_id. Si el codi és d'una altra unitat (p. ex. una caixa) i la línia no
+// n'indica cap, la quantitat s'entén en aquesta unitat.
+func (s *salesService) resolveItem(request *SalesDetailRequest) error {
+	if request.ItemID != "" || request.Barcode == "" {
+		return nil
 	}
-	details, err := s.repo.FindSalesDetailsByHeaderID(id)
+	match, err := s.barcodes.Lookup(context.Background(), request.Barcode)
 	if err != nil {
-		return SalesHeader{}, err
+		return err
 	}
-	for _, detail := range details {
-		qty := (-1) * detail.Quantity
-		if err := s.stock.UpdateStockQuantity(detail.ItemID, qty); err != nil {
-			return SalesHeader{}, err
-		}
+	request.ItemID = match.Item.ID.String()
+	if request.Unit == "" {
+		request.Unit = match.Unit
 	}
+	return nil
+}
 
-	return header, nil
-}
\ No newline at end of file
+// checkCustomerGroup comprova que el grup de clients de la venda existeix
+func (s *salesService) checkCustomerGroup(id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+	_, err := s.prices.FindCustomerGroupByID(context.Background(), id.String())
+	return err
+}
+
+// linePrice retorna el preu que envia el client o, si no n'envia cap, el de
+// la tarifa que toca segons el grup de clients de la venda, la quantitat i
+// el dia d'avui
+func (s *salesService) linePrice(request SalesDetailRequest, unit string) (float64, error) {
+	if request.Price != nil {
+		return *request.Price, nil
+	}
+	header, err := s.repo.FindSalesByHeaderID(request.SalesHeaderID)
+	if err != nil {
+		return 0, err
+	}
+	price, err := s.prices.Resolve(context.Background(), pricelists.ResolveRequest{
+		ItemID:          request.ItemID,
+		CustomerGroupID: header.CustomerGroupID,
+		Unit:            unit,
+		Quantity:        request.Quantity,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if price.Price <= 0 {
+		return 0, errors.New("invalid request: item has no price")
+	}
+	return price.Price, nil
+}
@@ -0,0 +1,79 @@
+// Package securetoken genera tokens aleatoris d'un sol ús, el hash amb què
+// es guarden a la base de dades i tokens signats que no cal guardar.
+package securetoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tokenBytes = 32
+
+var (
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpired          = errors.New("token has expired")
+)
+
+// Generate retorna un token aleatori codificat en base64 apte per a URLs
+func Generate() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash retorna el SHA-256 del token en hexadecimal. Només es guarda aquest
+// valor, mai el token en clar.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign genera un token signat amb HMAC-SHA256 que lliga un subjecte (p.ex.
+// l'ID d'un usuari) a un propòsit i una data de caducitat. No cal guardar-lo
+// a la base de dades: la signatura garanteix que no s'ha modificat.
+func Sign(secret []byte, purpose, subject string, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString(
+		[]byte(purpose + "|" + subject + "|" + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return payload + "." + signature(secret, payload)
+}
+
+// Verify comprova la signatura, el propòsit i la caducitat d'un token generat
+// amb Sign i en retorna el subjecte.
+func Verify(secret []byte, purpose, token string) (string, error) {
+	payload, sig, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(sig), []byte(signature(secret, payload))) {
+		return "", ErrInvalidSignature
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] != purpose {
+		return "", ErrInvalidSignature
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if time.Now().Unix() > expiresAt {
+		return "", ErrExpired
+	}
+	return parts[1], nil
+}
+
+func signature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
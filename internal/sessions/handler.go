@@ -0,0 +1,144 @@
+package sessions
+
+import (
+	"errors"
+	"frdy-api/internal/identity"
+	"frdy-api/internal/users"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	service SessionService
+}
+
+func NewSessionHandler(service SessionService) *SessionHandler {
+	return &SessionHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, users.ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, identity.ErrNoIdentity):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListMine godoc
+// @Summary Get my sessions
+// @Description Lists the devices where the current user is logged in. The session of this request is marked as current (Protected route)
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Success 200 {array} Session
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/sessions [get]
+// @Security BearerAuth
+func (h *SessionHandler) ListMine(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	sessions, err := h.service.ListForUser(c.Request.Context(), userID, identity.TokenFamily(c))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeMine godoc
+// @Summary Revoke one of my sessions
+// @Description Logs the current user out of a session and invalidates its tokens (Protected route)
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me/sessions/{id} [delete]
+// @Security BearerAuth
+func (h *SessionHandler) RevokeMine(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), userID, c.Param("id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ListForUser godoc
+// @Summary Get a user's sessions
+// @Description Lists the devices where a user is logged in (Protected route, admin only)
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} Session
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{id}/sessions [get]
+// @Security BearerAuth
+func (h *SessionHandler) ListForUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": users.ErrInvalidID.Error()})
+		return
+	}
+
+	sessions, err := h.service.ListForUser(c.Request.Context(), userID, identity.TokenFamily(c))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeForUser godoc
+// @Summary Revoke a user's session
+// @Description Logs a user out of a session and invalidates its tokens (Protected route, admin only)
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{id}/sessions/{sessionId} [delete]
+// @Security BearerAuth
+func (h *SessionHandler) RevokeForUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": users.ErrInvalidID.Error()})
+		return
+	}
+
+	if err := h.service.Revoke(c.Request.Context(), userID, c.Param("sessionId")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,23 @@
+package sessions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session és un login d'un usuari des d'un dispositiu. Correspon a una
+// família de tokens: el jti que es manté quan el token es refresca.
+type Session struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenFamily string     `json:"-" db:"token_family"`
+	UserAgent   string     `json:"user_agent" db:"user_agent"`
+	IP          string     `json:"ip" db:"ip"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at" db:"revoked_at"`
+	// Current indica la sessió amb què s'ha fet la petició
+	Current bool `json:"current" db:"-"`
+}
@@ -0,0 +1,108 @@
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const sessionColumns = `id, user_id, token_family, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+type SessionRepository interface {
+	Create(ctx context.Context, session Session) error
+	FindByID(ctx context.Context, id uuid.UUID) (Session, error)
+	FindByFamily(ctx context.Context, family string) (Session, error)
+	FindActiveByUser(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	Touch(ctx context.Context, family string, expiresAt time.Time) error
+	MarkRevoked(ctx context.Context, id uuid.UUID) error
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func sessionFields(s *Session) []interface{} {
+	return []interface{}{&s.ID, &s.UserID, &s.TokenFamily, &s.UserAgent, &s.IP,
+		&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session Session) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO sessions (id, user_id, token_family, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7)`,
+		session.ID, session.UserID, session.TokenFamily, session.UserAgent, session.IP, session.CreatedAt, session.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating session: %w", err)
+	}
+	return nil
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id uuid.UUID) (Session, error) {
+	return r.findOne(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id)
+}
+
+func (r *sessionRepository) FindByFamily(ctx context.Context, family string) (Session, error) {
+	return r.findOne(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE token_family = $1`, family)
+}
+
+func (r *sessionRepository) findOne(ctx context.Context, query string, arg interface{}) (Session, error) {
+	var session Session
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(sessionFields(&session)...)
+	if err == sql.ErrNoRows {
+		return Session{}, ErrSessionNotFound
+	} else if err != nil {
+		return Session{}, fmt.Errorf("error getting session: %w", err)
+	}
+	return session, nil
+}
+
+// FindActiveByUser retorna les sessions no revocades que encara poden tenir
+// tokens vàlids, de la més recent a la més antiga
+func (r *sessionRepository) FindActiveByUser(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(sessionFields(&session)...); err != nil {
+			return nil, fmt.Errorf("error scanning session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, family string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET last_seen_at = now(), expires_at = GREATEST(expires_at, $2)
+		WHERE token_family = $1 AND revoked_at IS NULL`, family, expiresAt)
+	if err != nil {
+		return fmt.Errorf("error updating session: %w", err)
+	}
+	return nil
+}
+
+func (r *sessionRepository) MarkRevoked(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+	return nil
+}
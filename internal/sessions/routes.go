@@ -0,0 +1,10 @@
+package sessions
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *SessionHandler) {
+	router.GET("/me/sessions", handler.ListMine)
+	router.DELETE("/me/sessions/:id", handler.RevokeMine)
+	router.GET("/users/:id/sessions", handler.ListForUser)
+	router.DELETE("/users/:id/sessions/:sessionId", handler.RevokeForUser)
+}
@@ -0,0 +1,141 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"frdy-api/internal/revocation"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// touchInterval és cada quant s'actualitza last_seen_at d'una sessió com a
+// molt, per no escriure a la base de dades a cada petició
+const touchInterval = time.Minute
+
+type SessionService interface {
+	// Start registra la sessió d'un login. authTime és la claim auth_time
+	Start(ctx context.Context, userID uuid.UUID, family string, authTime time.Time, ip, userAgent string) error
+	// Touch apunta que la sessió s'ha fet servir ara (petició o refresc)
+	Touch(ctx context.Context, family string) error
+	ListForUser(ctx context.Context, userID uuid.UUID, currentFamily string) ([]Session, error)
+	// Revoke tanca una sessió de l'usuari i invalida tots els seus tokens
+	Revoke(ctx context.Context, userID uuid.UUID, sessionID string) error
+	RevokeFamily(ctx context.Context, userID uuid.UUID, family string) error
+}
+
+type sessionService struct {
+	repo        SessionRepository
+	revocations revocation.Store
+	// ttl és el temps màxim que un token de la sessió pot ser vàlid o
+	// refrescable des de l'últim ús
+	ttl time.Duration
+
+	mu      sync.Mutex
+	touched map[string]time.Time
+}
+
+func NewSessionService(repo SessionRepository, revocations revocation.Store, ttl time.Duration) SessionService {
+	return &sessionService{
+		repo:        repo,
+		revocations: revocations,
+		ttl:         ttl,
+		touched:     make(map[string]time.Time),
+	}
+}
+
+func (s *sessionService) Start(ctx context.Context, userID uuid.UUID, family string, authTime time.Time, ip, userAgent string) error {
+	return s.repo.Create(ctx, Session{
+		ID:          uuid.New(),
+		UserID:      userID,
+		TokenFamily: family,
+		UserAgent:   userAgent,
+		IP:          ip,
+		CreatedAt:   authTime,
+		ExpiresAt:   authTime.Add(s.ttl),
+	})
+}
+
+func (s *sessionService) Touch(ctx context.Context, family string) error {
+	now := time.Now()
+	s.mu.Lock()
+	last, ok := s.touched[family]
+	if ok && now.Sub(last) < touchInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.touched[family] = now
+	s.evictStale(now)
+	s.mu.Unlock()
+
+	return s.repo.Touch(ctx, family, now.Add(s.ttl))
+}
+
+// ListForUser retorna les sessions actives. Les que s'han invalidat d'una
+// altra manera (canvi de contrasenya, usuari eliminat...) no es mostren.
+func (s *sessionService) ListForUser(ctx context.Context, userID uuid.UUID, currentFamily string) ([]Session, error) {
+	all, err := s.repo.FindActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(all))
+	for _, session := range all {
+		revoked, err := s.revocations.IsRevoked(ctx, session.TokenFamily, userID, session.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			continue
+		}
+		session.Current = session.TokenFamily == currentFamily
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *sessionService) Revoke(ctx context.Context, userID uuid.UUID, sessionID string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	session, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// No revelar les sessions d'altres usuaris
+	if session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	return s.RevokeFamily(ctx, userID, session.TokenFamily)
+}
+
+func (s *sessionService) RevokeFamily(ctx context.Context, userID uuid.UUID, family string) error {
+	// Passat aquest temps cap token de la família no pot ser vàlid ni refrescable
+	if err := s.revocations.RevokeToken(ctx, family, userID, time.Now().Add(s.ttl)); err != nil {
+		return err
+	}
+	session, err := s.repo.FindByFamily(ctx, family)
+	if errors.Is(err, ErrSessionNotFound) {
+		// Tokens emesos abans que es registressin les sessions
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.MarkRevoked(ctx, session.ID); err != nil {
+		log.Printf("Error marking session %s as revoked: %v", session.ID, err)
+	}
+	return nil
+}
+
+// evictStale elimina les famílies que no cal recordar. S'ha de cridar amb el
+// mutex bloquejat.
+func (s *sessionService) evictStale(now time.Time) {
+	for family, last := range s.touched {
+		if now.Sub(last) >= touchInterval {
+			delete(s.touched, family)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package spreadsheet llegeix i escriu taules en CSV i XLSX. L'XLSX es fa a
+// mà (és un zip amb XML) perquè només cal la primera full i valors simples.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// MaxRows és quantes files (la capçalera inclosa) es llegeixen com a màxim
+const MaxRows = 100000
+
+var (
+	ErrUnknownFormat = errors.New("unknown file format, expected csv or xlsx")
+	ErrInvalidFile   = errors.New("the file cannot be read as the given format")
+
+	errTooManyRows = fmt.Errorf("%w: more than %d rows", ErrInvalidFile, MaxRows)
+)
+
+// Format retorna el format demanat o, si és buit, el de l'extensió del fitxer
+func Format(requested, filename string) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(requested))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+	switch format {
+	case FormatCSV, FormatXLSX:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType retorna el tipus MIME de cada format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Read retorna les files del fitxer, la capçalera inclosa, com a text
+func Read(format string, data []byte) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(data)
+	case FormatXLSX:
+		return readXLSX(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Write escriu la capçalera i les files. Les cel·les poden ser string,
+// float64, int o bool; a l'XLSX els números i els booleans es guarden com a
+// tals i la resta com a text (un codi "00123" no passa a ser 123).
+func Write(format string, w io.Writer, header []string, rows [][]interface{}) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, header, rows)
+	case FormatXLSX:
+		return writeXLSX(w, header, rows)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if len(rows) == MaxRows {
+			return nil, errTooManyRows
+		}
+		rows = append(rows, row)
+	}
+}
+
+// detectDelimiter tria el separador que més apareix a la primera línia: els
+// fulls de càlcul en català o castellà exporten amb punt i coma
+func detectDelimiter(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	best, count := ',', bytes.Count(line, []byte{','})
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(line, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]interface{}) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		if err := writer.Write(record[:len(row)]); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,325 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Límits d'Excel: un fitxer que en parla de més és malmès o malintencionat
+const (
+	xlsxMaxRows    = 1048576
+	xlsxMaxColumns = 16384
+)
+
+// maxXMLSize és la mida màxima de cada XML descomprimit, perquè un zip petit
+// no pugui ocupar tota la memòria en descomprimir-se. maxCells limita les
+// cel·les, les buides incloses, que surten de files amb referències disperses.
+const (
+	maxXMLSize = 64 << 20
+	maxCells   = 5000000
+)
+
+// Estructures mínimes de l'XML d'un llibre SpreadsheetML
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxString `xml:"si"`
+}
+
+// xlsxString és un text que pot venir sencer (t) o en trossos amb format (r)
+type xlsxString struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s xlsxString) text() string {
+	if len(s.Runs) == 0 {
+		return s.T
+	}
+	var b strings.Builder
+	for _, r := range s.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string     `xml:"r,attr"`
+			T      string     `xml:"t,attr"`
+			V      string     `xml:"v"`
+			Inline xlsxString `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidFile, sheetPath)
+	}
+	var sheet xlsxSheet
+	if err := decodeXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	totalCells := 0
+	for i, row := range sheet.Rows {
+		// Les files i cel·les buides no hi són; es recol·loquen per la referència
+		index := row.R - 1
+		if index < 0 {
+			index = i
+		}
+		if index >= xlsxMaxRows {
+			return nil, fmt.Errorf("%w: bad row number %d", ErrInvalidFile, row.R)
+		}
+		if index >= MaxRows {
+			return nil, errTooManyRows
+		}
+		for len(rows) <= index {
+			rows = append(rows, nil)
+		}
+		var cells []string
+		for j, cell := range row.Cells {
+			col := j
+			if cell.R != "" {
+				if col, err = columnIndex(cell.R); err != nil {
+					return nil, err
+				}
+			}
+			if col >= xlsxMaxColumns {
+				return nil, fmt.Errorf("%w: bad cell reference %q", ErrInvalidFile, cell.R)
+			}
+			if col >= len(cells) {
+				if totalCells += col + 1 - len(cells); totalCells > maxCells {
+					return nil, fmt.Errorf("%w: too many cells", ErrInvalidFile)
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			switch cell.T {
+			case "s":
+				n, err := strconv.Atoi(cell.V)
+				if err != nil || n < 0 || n >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: bad shared string in %s", ErrInvalidFile, cell.R)
+				}
+				cells[col] = shared.Items[n].text()
+			case "inlineStr":
+				cells[col] = cell.Inline.text()
+			default:
+				cells[col] = cell.V
+			}
+		}
+		rows[index] = cells
+	}
+	return rows, nil
+}
+
+// firstSheetPath troba el fitxer de la primera full a partir del llibre i
+// les seves relacions
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	f, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", fmt.Errorf("%w: missing workbook", ErrInvalidFile)
+	}
+	if err := decodeXML(f, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: the workbook has no sheets", ErrInvalidFile)
+	}
+
+	var rels xlsxRelationships
+	if f, ok := files["xl/_rels/workbook.xml.rels"]; ok {
+		if err := decodeXML(f, &rels); err != nil {
+			return "", err
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer r.Close()
+	limited := &io.LimitedReader{R: r, N: maxXMLSize + 1}
+	if err := xml.NewDecoder(limited).Decode(v); err != nil {
+		if limited.N <= 0 {
+			return fmt.Errorf("%w: %s is too large", ErrInvalidFile, f.Name)
+		}
+		return fmt.Errorf("%w: %s: %v", ErrInvalidFile, f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex passa la referència d'una cel·la (p. ex. "AB12") a l'índex de
+// la columna començant per 0
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for _, r := range ref {
+		// Més enllà de l'última columna ja no cal seguir (ni desbordar)
+		if col > xlsxMaxColumns {
+			break
+		}
+		if r >= 'A' && r <= 'Z' {
+			col = col*26 + int(r-'A'+1)
+		} else if r >= 'a' && r <= 'z' {
+			col = col*26 + int(r-'a'+1)
+		} else {
+			break
+		}
+	}
+	if col == 0 {
+		return 0, fmt.Errorf("%w: bad cell reference %q", ErrInvalidFile, ref)
+	}
+	return col - 1, nil
+}
+
+// columnName és la inversa de columnIndex: 0 és "A", 26 és "AA"
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+func writeXLSX(w io.Writer, header []string, rows [][]interface{}) error {
+	archive := zip.NewWriter(w)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbookXML},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	headerRow := make([]interface{}, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	if err := writeXLSXRow(f, 1, headerRow); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if err := writeXLSXRow(f, i+2, row); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(f, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeXLSXRow(w io.Writer, number int, row []interface{}) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, number)
+	for i, cell := range row {
+		ref := columnName(i) + strconv.Itoa(number)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case float64, int:
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, formatCell(v))
+		case bool:
+			value := "0"
+			if v {
+				value = "1"
+			}
+			fmt.Fprintf(&b, `<c r="%s" t="b"><v>%s</v></c>`, ref, value)
+		default:
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(&b, []byte(formatCell(v)))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString(`</row>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -15,8 +15,9 @@ func NewStockHandler(service StockService) *StockHandler {
 }
 
 // UpdateStockQuantityRequest represents the request body for updating stock quantity
+// (in the item's base unit; decimals are allowed for weight-based units)
 type UpdateStockQuantityRequest struct {
-	Quantity int `json:"quantity" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required"`
 }
 
 // GetStockByItemID godoc
@@ -78,20 +79,41 @@ func (h *StockHandler) UpdateStockQuantity(c *gin.Context) {
 
 // GetAllStocks godoc
 // @Summary Get all stocks
-// @Description Retrieve all stock information (Protected route)
+// @Description Retrieve all stock information, optionally only for a category and its subcategories (Protected route)
 // @Tags stock
 // @Accept json
 // @Produce json
+// @Param category_id query string false "Category ID"
 // @Success 200 {array} Stock
 // @Failure 500 {object} map[string]string
 // @Router /api/stock [get]
 // @Security BearerAuth
 func (h *StockHandler) GetAllStocks(c *gin.Context) {
-	stocks, err := h.service.GetAllStocks()
+	stocks, err := h.service.GetAllStocks(c.Query("category_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, stocks)
-}
\ No newline at end of file
+}
+
+// GetStockByCategory godoc
+// @Summary Get stock grouped by category
+// @Description Retrieve stock quantities per category, both for the items directly in each category and including its subcategories (Protected route)
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Success 200 {array} CategoryStock
+// @Failure 500 {object} map[string]string
+// @Router /api/stock/by-category [get]
+// @Security BearerAuth
+func (h *StockHandler) GetStockByCategory(c *gin.Context) {
+	report, err := h.service.GetStockByCategory()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
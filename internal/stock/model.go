@@ -5,5 +5,19 @@ type Stock struct {
 	ItemID          string `json:"item_id" db:"item_id"`
 	ItemCode        string `json:"item_code" db:"item_code"`
 	ItemDescription string `json:"item_description" db:"item_description"`
-	Quantity        int    `json:"quantity" db:"quantity"`
+	Quantity        float64 `json:"quantity" db:"quantity"`
+	BaseUnit        string  `json:"base_unit" db:"base_unit"`
+	CategoryID      *string `json:"category_id" db:"category_id"`
+}
+
+// CategoryStock és l'estoc agrupat per categoria. Quantity només compta els
+// articles assignats directament a la categoria i TotalQuantity hi suma els de
+// totes les subcategories. La fila sense CategoryID agrupa els articles sense
+// categoria.
+type CategoryStock struct {
+	CategoryID    *string `json:"category_id" db:"category_id"`
+	CategoryName  string  `json:"category_name" db:"category_name"`
+	ParentID      *string `json:"parent_id" db:"parent_id"`
+	Quantity      float64 `json:"quantity" db:"quantity"`
+	TotalQuantity float64 `json:"total_quantity" db:"total_quantity"`
 }
\ No newline at end of file
@@ -3,12 +3,14 @@ package stock
 import (
 	"database/sql"
 	"fmt"
+	"frdy-api/internal/categories"
 )
 
 type StockRepository interface {
 	GetStockByItemID(itemID string) (*Stock, error)
-	UpdateStockQuantity(itemID string, quantity int) error
-	GetAllStocks() ([]Stock, error)
+	UpdateStockQuantity(itemID string, quantity float64) error
+	GetAllStocks(categoryID string) ([]Stock, error)
+	GetStockByCategory() ([]CategoryStock, error)
 }
 
 type stockRepository struct {
@@ -22,10 +24,10 @@ func NewStockRepository(db *sql.DB) StockRepository {
 func (r *stockRepository) GetStockByItemID(itemID string) (*Stock, error) {
 	var stock Stock
 	err := r.db.QueryRow(`
-		SELECT s.id, s.item_id, i.code as item_code,i.description as item_description, s.quantity
+		SELECT s.id, s.item_id, i.code as item_code,i.description as item_description, s.quantity, i.base_unit, i.category_id
 		FROM stocks s
 			INNER JOIN items i ON s.item_id = i.id
-		WHERE i.item_id = $1`, itemID).Scan(&stock.ID, &stock.ItemID, &stock.ItemCode, &stock.ItemDescription, &stock.Quantity)
+		WHERE s.item_id = $1`, itemID).Scan(&stock.ID, &stock.ItemID, &stock.ItemCode, &stock.ItemDescription, &stock.Quantity, &stock.BaseUnit, &stock.CategoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No stock found for this item
@@ -35,8 +37,20 @@ func (r *stockRepository) GetStockByItemID(itemID string) (*Stock, error) {
 	return &stock, nil
 }
 
-func (r *stockRepository) UpdateStockQuantity(itemID string, quantity int) error {	
-	_, err := r.db.Exec(`
+func (r *stockRepository) UpdateStockQuantity(itemID string, quantity float64) error {	
+	return AddQuantity(r.db, itemID, quantity)
+}
+
+// execer és el que tenen en comú *sql.DB i *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// AddQuantity suma quantity a l'estoc de l'article. Amb una *sql.Tx el
+// moviment es desa o es descarta amb la resta de la transacció (p. ex. la
+// recepció d'una compra).
+func AddQuantity(db execer, itemID string, quantity float64) error {
+	_, err := db.Exec(`
 		INSERT INTO stocks (item_id, quantity)
 		VALUES ($1, $2)
 			ON CONFLICT (item_id) DO UPDATE SET
@@ -47,12 +61,15 @@ func (r *stockRepository) UpdateStockQuantity(itemID string, quantity int) error
 	return nil
 }
 
-func (r *stockRepository) GetAllStocks() ([]Stock, error) {
+// GetAllStocks retorna l'estoc de tots els articles o, si s'indica una
+// categoria, el dels articles d'aquesta categoria i de les seves subcategories
+func (r *stockRepository) GetAllStocks(categoryID string) ([]Stock, error) {
 	var stocks []Stock
 	rows, err := r.db.Query(`
-		SELECT s.id, s.item_id, i.code as item_code, i.description as item_description, s.quantity
+		SELECT s.id, s.item_id, i.code as item_code, i.description as item_description, s.quantity, i.base_unit, i.category_id
 		FROM stocks s
-			INNER JOIN items i ON s.item_id = i.id`)
+			INNER JOIN items i ON s.item_id = i.id
+		WHERE NULLIF($1, '') IS NULL OR i.category_id IN `+categories.SubtreeIDs("NULLIF($1, '')::uuid"), categoryID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching all stocks: %w", err)
 	}
@@ -60,7 +77,7 @@ func (r *stockRepository) GetAllStocks() ([]Stock, error) {
 
 	for rows.Next() {
 		var stock Stock
-		if err := rows.Scan(&stock.ID, &stock.ItemID, &stock.ItemCode, &stock.ItemDescription, &stock.Quantity); err != nil {
+		if err := rows.Scan(&stock.ID, &stock.ItemID, &stock.ItemCode, &stock.ItemDescription, &stock.Quantity, &stock.BaseUnit, &stock.CategoryID); err != nil {
 			return nil, fmt.Errorf("error scanning stock row: %w", err)
 		}
 		stocks = append(stocks, stock)
@@ -71,4 +88,41 @@ func (r *stockRepository) GetAllStocks() ([]Stock, error) {
 	}
 
 	return stocks, nil
-}
\ No newline at end of file
+}
+func (r *stockRepository) GetStockByCategory() ([]CategoryStock, error) {
+	rows, err := r.db.Query(`
+		WITH RECURSIVE ` + categories.ClosureCTE + `
+		SELECT cat.id, cat.name, cat.parent_id,
+			COALESCE(SUM(s.quantity) FILTER (WHERE i.category_id = cat.id), 0) as quantity,
+			COALESCE(SUM(s.quantity), 0) as total_quantity
+		FROM categories cat
+			INNER JOIN closure cl ON cl.root_id = cat.id
+			LEFT JOIN items i ON i.category_id = cl.id
+			LEFT JOIN stocks s ON s.item_id = i.id
+		GROUP BY cat.id, cat.name, cat.parent_id
+		UNION ALL
+		SELECT NULL, '', NULL, COALESCE(SUM(s.quantity), 0), COALESCE(SUM(s.quantity), 0)
+		FROM stocks s
+			INNER JOIN items i ON s.item_id = i.id
+		WHERE i.category_id IS NULL
+		ORDER BY 2`)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching stock by category: %w", err)
+	}
+	defer rows.Close()
+
+	var report []CategoryStock
+	for rows.Next() {
+		var row CategoryStock
+		if err := rows.Scan(&row.CategoryID, &row.CategoryName, &row.ParentID, &row.Quantity, &row.TotalQuantity); err != nil {
+			return nil, fmt.Errorf("error scanning stock by category row: %w", err)
+		}
+		report = append(report, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stock by category rows: %w", err)
+	}
+
+	return report, nil
+}
@@ -1,11 +1,16 @@
 package stock
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
 
 type StockService interface {
 	GetStockByItemID(itemID string) (Stock, error)
-	UpdateStockQuantity(itemID string, quantity int) error
-	GetAllStocks() ([]Stock, error)
+	UpdateStockQuantity(itemID string, quantity float64) error
+	GetAllStocks(categoryID string) ([]Stock, error)
+	GetStockByCategory() ([]CategoryStock, error)
 }
 
 type stockService struct {
@@ -26,16 +31,24 @@ func (s *stockService) GetStockByItemID(itemID string) (Stock, error) {
 	}
 	return *stock, nil
 }
-func (s *stockService) UpdateStockQuantity(itemID string, quantity int) error {
+func (s *stockService) UpdateStockQuantity(itemID string, quantity float64) error {
 	if itemID == "" || quantity == 0 {
 		return errors.New("invalid item ID or quantity")
 	}
 	return s.repo.UpdateStockQuantity(itemID, quantity)
 }
-func (s *stockService) GetAllStocks() ([]Stock, error) {
-	stocks, err := s.repo.GetAllStocks()
+func (s *stockService) GetAllStocks(categoryID string) ([]Stock, error) {
+	if categoryID != "" {
+		if _, err := uuid.Parse(categoryID); err != nil {
+			return nil, errors.New("invalid category ID format")
+		}
+	}
+	stocks, err := s.repo.GetAllStocks(categoryID)
 	if err != nil {
 		return nil, err
 	}
 	return stocks, nil
-}
\ No newline at end of file
+}
+func (s *stockService) GetStockByCategory() ([]CategoryStock, error) {
+	return s.repo.GetStockByCategory()
+}
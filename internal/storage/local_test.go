@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoragePutGetDelete(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := "items/42/photo.jpg"
+	data := []byte("jpeg bytes")
+
+	if err := store.Put(ctx, key, "image/jpeg", data); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(root, "items", "42", "photo.jpg")); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("file on disk = %q, %v; want %q", got, err, data)
+	}
+	// No queda cap fitxer temporal al costat
+	if entries, _ := os.ReadDir(filepath.Join(root, "items", "42")); len(entries) != 1 {
+		t.Fatalf("got %d files in the item directory, want 1", len(entries))
+	}
+
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Get = %q, want %q", got, data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func TestLocalStorageKeysStayInsideRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "files")
+	store, err := NewLocalStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := store.(*localStorage)
+
+	for _, key := range []string{"", "/", "..", "../secret", "items/../../secret", "items/..", `items\..\..\secret`} {
+		if path, err := s.path(key); err == nil {
+			t.Errorf("path(%q) = %q, want an error", key, path)
+		}
+	}
+
+	// Les claus absolutes o amb trossos buits queden dins de l'arrel
+	tests := map[string]string{
+		"items/42/photo.jpg":   filepath.Join(root, "items", "42", "photo.jpg"),
+		"/etc/passwd":          filepath.Join(root, "etc", "passwd"),
+		"items//42/./file.txt": filepath.Join(root, "items", "42", "file.txt"),
+	}
+	for key, want := range tests {
+		if got, err := s.path(key); err != nil || got != want {
+			t.Errorf("path(%q) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+
+	if err := store.Put(context.Background(), "../outside.txt", "text/plain", []byte("x")); err == nil {
+		t.Fatal("Put with a key outside the root succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "outside.txt")); !os.IsNotExist(err) {
+		t.Fatalf("a file was written outside the root: %v", err)
+	}
+}
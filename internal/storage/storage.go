@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage guarda fitxers per clau (p. ex. "items/<id>/<fitxer>"). Els
+// serveis no saben si són al disc local o a un servei compatible amb S3.
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get retorna el contingut; qui el crida l'ha de tancar
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete no falla si l'objecte ja no existeix
+	Delete(ctx context.Context, key string) error
+}
+
+const (
+	KindLocal = "local"
+	KindS3    = "s3"
+)
+
+// New crea l'emmagatzematge configurat. "local" desa els fitxers dins de
+// localDir i "s3" en un bucket d'S3 o d'un servei compatible (MinIO...).
+func New(kind, localDir string, s3 S3Config) (Storage, error) {
+	switch kind {
+	case "", KindLocal:
+		return NewLocalStorage(localDir)
+	case KindS3:
+		return NewS3Storage(s3)
+	default:
+		return nil, fmt.Errorf("unknown storage %q", kind)
+	}
+}
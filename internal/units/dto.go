@@ -0,0 +1,11 @@
+package units
+
+type UnitRequest struct {
+	Code          string `json:"code" binding:"required"`
+	Name          string `json:"name" binding:"required"`
+	AllowDecimals bool   `json:"allow_decimals"`
+}
+
+type ItemUnitRequest struct {
+	Factor float64 `json:"factor" binding:"required,gt=0"`
+}
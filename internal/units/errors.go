@@ -0,0 +1,15 @@
+package units
+
+import "errors"
+
+var (
+	ErrUnitNotFound       = errors.New("unit of measure not found")
+	ErrItemNotFound       = errors.New("item not found")
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrInvalidItemID      = errors.New("invalid item ID")
+	ErrUnitExists         = errors.New("a unit with this code already exists")
+	ErrUnitInUse          = errors.New("unit is used by items or conversions")
+	ErrBaseUnit           = errors.New("the base unit of an item always has factor 1")
+	ErrNoConversion       = errors.New("no conversion from this unit to the item's base unit")
+	ErrFractionalQuantity = errors.New("this unit does not allow decimal quantities")
+)
@@ -0,0 +1,230 @@
+package units
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UnitHandler struct {
+	service UnitService
+}
+
+func NewUnitHandler(service UnitService) *UnitHandler {
+	return &UnitHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrUnitNotFound), errors.Is(err, ErrItemNotFound), errors.Is(err, ErrNoConversion):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidItemID), errors.Is(err, ErrBaseUnit),
+		errors.Is(err, ErrFractionalQuantity):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnitExists), errors.Is(err, ErrUnitInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Create godoc
+// @Summary Create a unit of measure
+// @Description Creates a unit of measure; codes are stored in upper case (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param request body UnitRequest true "Unit data"
+// @Success 201 {object} Unit
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/units [post]
+// @Security BearerAuth
+func (h *UnitHandler) Create(c *gin.Context) {
+	var request UnitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.service.Create(c.Request.Context(), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, unit)
+}
+
+// Update godoc
+// @Summary Update a unit of measure
+// @Description Updates the name of a unit and whether it allows decimal quantities (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param code path string true "Unit code"
+// @Param request body UnitRequest true "Unit data"
+// @Success 200 {object} Unit
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/units/{code} [put]
+// @Security BearerAuth
+func (h *UnitHandler) Update(c *gin.Context) {
+	var request UnitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.service.Update(c.Request.Context(), c.Param("code"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+// Delete godoc
+// @Summary Delete a unit of measure
+// @Description Deletes a unit that is not the base unit of any item nor used in conversions (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param code path string true "Unit code"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/units/{code} [delete]
+// @Security BearerAuth
+func (h *UnitHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("code")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// FindByCode godoc
+// @Summary Get a unit of measure
+// @Description Retrieves a unit of measure by its code (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param code path string true "Unit code"
+// @Success 200 {object} Unit
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/units/{code} [get]
+// @Security BearerAuth
+func (h *UnitHandler) FindByCode(c *gin.Context) {
+	unit, err := h.service.FindByCode(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, unit)
+}
+
+// FindAll godoc
+// @Summary List units of measure
+// @Description Retrieves all units of measure (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Success 200 {array} Unit
+// @Failure 500 {object} map[string]string
+// @Router /api/units [get]
+// @Security BearerAuth
+func (h *UnitHandler) FindAll(c *gin.Context) {
+	units, err := h.service.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, units)
+}
+
+// FindItemUnits godoc
+// @Summary List the unit conversions of an item
+// @Description Retrieves the units an item can be bought or sold in and how many base units each one holds (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {array} ItemUnit
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/units [get]
+// @Security BearerAuth
+func (h *UnitHandler) FindItemUnits(c *gin.Context) {
+	itemUnits, err := h.service.FindItemUnits(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, itemUnits)
+}
+
+// SetItemUnit godoc
+// @Summary Set a unit conversion for an item
+// @Description Creates or updates how many base units of the item one unit holds, e.g. 1 BOX = 12 UNIT (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param code path string true "Unit code"
+// @Param request body ItemUnitRequest true "Conversion factor"
+// @Success 200 {object} ItemUnit
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/units/{code} [put]
+// @Security BearerAuth
+func (h *UnitHandler) SetItemUnit(c *gin.Context) {
+	var request ItemUnitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	itemUnit, err := h.service.SetItemUnit(c.Request.Context(), c.Param("id"), c.Param("code"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, itemUnit)
+}
+
+// DeleteItemUnit godoc
+// @Summary Delete a unit conversion of an item
+// @Description Removes a unit from the units an item can be bought or sold in (Protected route)
+// @Tags units
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param code path string true "Unit code"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/units/{code} [delete]
+// @Security BearerAuth
+func (h *UnitHandler) DeleteItemUnit(c *gin.Context) {
+	if err := h.service.DeleteItemUnit(c.Request.Context(), c.Param("id"), c.Param("code")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
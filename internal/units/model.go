@@ -0,0 +1,32 @@
+package units
+
+import "github.com/google/uuid"
+
+// DefaultUnit és la unitat base dels articles que no n'indiquen cap
+const DefaultUnit = "UNIT"
+
+// Unit és una unitat de mesura. Les unitats de pes, volum o longitud admeten
+// quantitats decimals; les de recompte (unitats, caixes...) no.
+type Unit struct {
+	Code          string `json:"code" db:"code"`
+	Name          string `json:"name" db:"name"`
+	AllowDecimals bool   `json:"allow_decimals" db:"allow_decimals"`
+}
+
+// ItemUnit indica quantes unitats base d'un article hi ha en una altra unitat
+// (p. ex. 1 BOX = 12 UNIT).
+type ItemUnit struct {
+	ItemID   uuid.UUID `json:"item_id" db:"item_id"`
+	UnitCode string    `json:"unit" db:"unit_code"`
+	Factor   float64   `json:"factor" db:"factor"`
+}
+
+// Conversion és el resultat de passar una quantitat a la unitat base de l'article
+type Conversion struct {
+	Unit         string  `json:"unit"`
+	Quantity     float64 `json:"quantity"`
+	BaseUnit     string  `json:"base_unit"`
+	BaseQuantity float64 `json:"base_quantity"`
+	// Unitats base que hi ha en una unitat de la conversió
+	Factor float64 `json:"factor"`
+}
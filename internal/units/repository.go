@@ -0,0 +1,189 @@
+package units
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type UnitRepository interface {
+	Create(ctx context.Context, unit Unit) (Unit, error)
+	Update(ctx context.Context, unit Unit) (Unit, error)
+	Delete(ctx context.Context, code string) error
+	FindByCode(ctx context.Context, code string) (Unit, error)
+	FindAll(ctx context.Context) ([]Unit, error)
+	CountUsage(ctx context.Context, code string) (int, error)
+
+	ItemBaseUnit(ctx context.Context, itemID uuid.UUID) (string, error)
+	FindItemUnits(ctx context.Context, itemID uuid.UUID) ([]ItemUnit, error)
+	FindItemUnit(ctx context.Context, itemID uuid.UUID, code string) (ItemUnit, error)
+	SaveItemUnit(ctx context.Context, itemUnit ItemUnit) (ItemUnit, error)
+	DeleteItemUnit(ctx context.Context, itemID uuid.UUID, code string) error
+}
+
+type unitRepository struct {
+	db *sql.DB
+}
+
+func NewUnitRepository(db *sql.DB) UnitRepository {
+	return &unitRepository{db: db}
+}
+
+func (r *unitRepository) Create(ctx context.Context, unit Unit) (Unit, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO units (code, name, allow_decimals)
+		VALUES ($1, $2, $3)`,
+		unit.Code, unit.Name, unit.AllowDecimals,
+	)
+	if err != nil {
+		return Unit{}, fmt.Errorf("error creating unit: %w", err)
+	}
+	return unit, nil
+}
+
+func (r *unitRepository) Update(ctx context.Context, unit Unit) (Unit, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE units
+		SET name = $1, allow_decimals = $2
+		WHERE code = $3`,
+		unit.Name, unit.AllowDecimals, unit.Code,
+	)
+	if err != nil {
+		return Unit{}, fmt.Errorf("error updating unit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return Unit{}, ErrUnitNotFound
+	}
+	return unit, nil
+}
+
+func (r *unitRepository) Delete(ctx context.Context, code string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM units WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("error deleting unit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUnitNotFound
+	}
+	return nil
+}
+
+func (r *unitRepository) FindByCode(ctx context.Context, code string) (Unit, error) {
+	var unit Unit
+	err := r.db.QueryRowContext(ctx, `
+		SELECT code, name, allow_decimals
+		FROM units
+		WHERE code = $1`, code,
+	).Scan(&unit.Code, &unit.Name, &unit.AllowDecimals)
+	if err == sql.ErrNoRows {
+		return Unit{}, ErrUnitNotFound
+	} else if err != nil {
+		return Unit{}, fmt.Errorf("error getting unit: %w", err)
+	}
+	return unit, nil
+}
+
+func (r *unitRepository) FindAll(ctx context.Context) ([]Unit, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT code, name, allow_decimals FROM units ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting units: %w", err)
+	}
+	defer rows.Close()
+
+	var units []Unit
+	for rows.Next() {
+		var unit Unit
+		if err := rows.Scan(&unit.Code, &unit.Name, &unit.AllowDecimals); err != nil {
+			return nil, fmt.Errorf("error scanning unit: %w", err)
+		}
+		units = append(units, unit)
+	}
+	return units, rows.Err()
+}
+
+// CountUsage compta els articles que tenen la unitat com a base o com a conversió
+func (r *unitRepository) CountUsage(ctx context.Context, code string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM items WHERE base_unit = $1)
+			+ (SELECT count(*) FROM item_units WHERE unit_code = $1)`, code,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting unit usage: %w", err)
+	}
+	return count, nil
+}
+
+func (r *unitRepository) ItemBaseUnit(ctx context.Context, itemID uuid.UUID) (string, error) {
+	var code string
+	err := r.db.QueryRowContext(ctx, `SELECT base_unit FROM items WHERE id = $1`, itemID).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", ErrItemNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("error getting item base unit: %w", err)
+	}
+	return code, nil
+}
+
+func (r *unitRepository) FindItemUnits(ctx context.Context, itemID uuid.UUID) ([]ItemUnit, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT item_id, unit_code, factor
+		FROM item_units
+		WHERE item_id = $1
+		ORDER BY factor`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting item units: %w", err)
+	}
+	defer rows.Close()
+
+	var itemUnits []ItemUnit
+	for rows.Next() {
+		var itemUnit ItemUnit
+		if err := rows.Scan(&itemUnit.ItemID, &itemUnit.UnitCode, &itemUnit.Factor); err != nil {
+			return nil, fmt.Errorf("error scanning item unit: %w", err)
+		}
+		itemUnits = append(itemUnits, itemUnit)
+	}
+	return itemUnits, rows.Err()
+}
+
+func (r *unitRepository) FindItemUnit(ctx context.Context, itemID uuid.UUID, code string) (ItemUnit, error) {
+	var itemUnit ItemUnit
+	err := r.db.QueryRowContext(ctx, `
+		SELECT item_id, unit_code, factor
+		FROM item_units
+		WHERE item_id = $1 AND unit_code = $2`, itemID, code,
+	).Scan(&itemUnit.ItemID, &itemUnit.UnitCode, &itemUnit.Factor)
+	if err == sql.ErrNoRows {
+		return ItemUnit{}, ErrNoConversion
+	} else if err != nil {
+		return ItemUnit{}, fmt.Errorf("error getting item unit: %w", err)
+	}
+	return itemUnit, nil
+}
+
+func (r *unitRepository) SaveItemUnit(ctx context.Context, itemUnit ItemUnit) (ItemUnit, error) {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO item_units (item_id, unit_code, factor)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (item_id, unit_code) DO UPDATE SET factor = EXCLUDED.factor`,
+		itemUnit.ItemID, itemUnit.UnitCode, itemUnit.Factor,
+	)
+	if err != nil {
+		return ItemUnit{}, fmt.Errorf("error saving item unit: %w", err)
+	}
+	return itemUnit, nil
+}
+
+func (r *unitRepository) DeleteItemUnit(ctx context.Context, itemID uuid.UUID, code string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM item_units WHERE item_id = $1 AND unit_code = $2`, itemID, code)
+	if err != nil {
+		return fmt.Errorf("error deleting item unit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoConversion
+	}
+	return nil
+}
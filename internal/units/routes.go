@@ -0,0 +1,22 @@
+package units
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *UnitHandler) {
+	units := router.Group("/units")
+	{
+		units.POST("", handler.Create)
+		units.PUT("/:code", handler.Update)
+		units.DELETE("/:code", handler.Delete)
+		units.GET("/:code", handler.FindByCode)
+		units.GET("", handler.FindAll)
+	}
+
+	// Conversions de cada article, dins de /items perquè segueixin els seus permisos
+	items := router.Group("/items/:id/units")
+	{
+		items.GET("", handler.FindItemUnits)
+		items.PUT("/:code", handler.SetItemUnit)
+		items.DELETE("/:code", handler.DeleteItemUnit)
+	}
+}
@@ -0,0 +1,187 @@
+package units
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// quantityScale és el nombre de decimals amb què es guarden les quantitats
+const quantityScale = 1000
+
+type UnitService interface {
+	Create(ctx context.Context, request UnitRequest) (Unit, error)
+	Update(ctx context.Context, code string, request UnitRequest) (Unit, error)
+	Delete(ctx context.Context, code string) error
+	FindByCode(ctx context.Context, code string) (Unit, error)
+	FindAll(ctx context.Context) ([]Unit, error)
+
+	FindItemUnits(ctx context.Context, itemID string) ([]ItemUnit, error)
+	SetItemUnit(ctx context.Context, itemID, code string, request ItemUnitRequest) (ItemUnit, error)
+	DeleteItemUnit(ctx context.Context, itemID, code string) error
+
+	// ToBase passa una quantitat expressada en unit a la unitat base de
+	// l'article. Si unit és buida, la quantitat ja és en la unitat base.
+	ToBase(ctx context.Context, itemID, unit string, quantity float64) (Conversion, error)
+}
+
+type unitService struct {
+	repo UnitRepository
+}
+
+func NewUnitService(repo UnitRepository) UnitService {
+	return &unitService{repo: repo}
+}
+
+// NormalizeCode posa els codis d'unitat en majúscules i sense espais
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *unitService) Create(ctx context.Context, request UnitRequest) (Unit, error) {
+	code := NormalizeCode(request.Code)
+	if code == "" || strings.TrimSpace(request.Name) == "" {
+		return Unit{}, ErrInvalidRequest
+	}
+	if _, err := s.repo.FindByCode(ctx, code); err == nil {
+		return Unit{}, ErrUnitExists
+	} else if !errors.Is(err, ErrUnitNotFound) {
+		return Unit{}, err
+	}
+
+	unit := Unit{
+		Code:          code,
+		Name:          strings.TrimSpace(request.Name),
+		AllowDecimals: request.AllowDecimals,
+	}
+	return s.repo.Create(ctx, unit)
+}
+
+// Update canvia el nom i si admet decimals; el codi no es pot canviar
+func (s *unitService) Update(ctx context.Context, code string, request UnitRequest) (Unit, error) {
+	code = NormalizeCode(code)
+	if strings.TrimSpace(request.Name) == "" || NormalizeCode(request.Code) != code {
+		return Unit{}, ErrInvalidRequest
+	}
+
+	unit := Unit{
+		Code:          code,
+		Name:          strings.TrimSpace(request.Name),
+		AllowDecimals: request.AllowDecimals,
+	}
+	return s.repo.Update(ctx, unit)
+}
+
+func (s *unitService) Delete(ctx context.Context, code string) error {
+	code = NormalizeCode(code)
+	if _, err := s.repo.FindByCode(ctx, code); err != nil {
+		return err
+	}
+	count, err := s.repo.CountUsage(ctx, code)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUnitInUse
+	}
+	return s.repo.Delete(ctx, code)
+}
+
+func (s *unitService) FindByCode(ctx context.Context, code string) (Unit, error) {
+	return s.repo.FindByCode(ctx, NormalizeCode(code))
+}
+
+func (s *unitService) FindAll(ctx context.Context) ([]Unit, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *unitService) FindItemUnits(ctx context.Context, itemID string) ([]ItemUnit, error) {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return nil, ErrInvalidItemID
+	}
+	if _, err := s.repo.ItemBaseUnit(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindItemUnits(ctx, id)
+}
+
+// SetItemUnit crea o canvia el factor de conversió d'una unitat a la unitat
+// base de l'article
+func (s *unitService) SetItemUnit(ctx context.Context, itemID, code string, request ItemUnitRequest) (ItemUnit, error) {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return ItemUnit{}, ErrInvalidItemID
+	}
+	if request.Factor <= 0 {
+		return ItemUnit{}, ErrInvalidRequest
+	}
+	code = NormalizeCode(code)
+	baseUnit, err := s.repo.ItemBaseUnit(ctx, id)
+	if err != nil {
+		return ItemUnit{}, err
+	}
+	if code == baseUnit {
+		return ItemUnit{}, ErrBaseUnit
+	}
+	if _, err := s.repo.FindByCode(ctx, code); err != nil {
+		return ItemUnit{}, err
+	}
+
+	return s.repo.SaveItemUnit(ctx, ItemUnit{ItemID: id, UnitCode: code, Factor: request.Factor})
+}
+
+func (s *unitService) DeleteItemUnit(ctx context.Context, itemID, code string) error {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return ErrInvalidItemID
+	}
+	return s.repo.DeleteItemUnit(ctx, id, NormalizeCode(code))
+}
+
+func (s *unitService) ToBase(ctx context.Context, itemID, unit string, quantity float64) (Conversion, error) {
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return Conversion{}, ErrInvalidItemID
+	}
+	baseUnit, err := s.repo.ItemBaseUnit(ctx, id)
+	if err != nil {
+		return Conversion{}, err
+	}
+	code := NormalizeCode(unit)
+	if code == "" {
+		code = baseUnit
+	}
+	u, err := s.repo.FindByCode(ctx, code)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if !u.AllowDecimals && quantity != math.Trunc(quantity) {
+		return Conversion{}, ErrFractionalQuantity
+	}
+
+	factor := 1.0
+	if code != baseUnit {
+		itemUnit, err := s.repo.FindItemUnit(ctx, id, code)
+		if err != nil {
+			return Conversion{}, err
+		}
+		factor = itemUnit.Factor
+	}
+
+	return Conversion{
+		Unit:         code,
+		Quantity:     quantity,
+		BaseUnit:     baseUnit,
+		BaseQuantity: RoundQuantity(quantity * factor),
+		Factor:       factor,
+	}, nil
+}
+
+// RoundQuantity arrodoneix una quantitat als decimals que es guarden a la base de dades
+func RoundQuantity(quantity float64) float64 {
+	return math.Round(quantity*quantityScale) / quantityScale
+}
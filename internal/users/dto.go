@@ -21,12 +21,46 @@ type ChangePasswordRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest només canvia els camps que s'envien
+type UpdateProfileRequest struct {
+	Email       *string      `json:"email"`
+	Username    *string      `json:"username"`
+	Preferences *Preferences `json:"preferences"`
+}
+
+type ChangeOwnPasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type AssignRoleRequest struct {
+	RoleID string `json:"role_id" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
 type UserResponse struct {
 	ID       string `json:"id" db:"id"`
 	Email    string `json:"email" db:"email"`
 	Username string `json:"username" db:"username"`
 	Password string `json:"password" db:"password"`
 	IsActive bool   `json:"is_active" db:"is_active"`
+	RoleID   string `json:"role_id" db:"role_id"`
+	EmailVerified bool `json:"email_verified" db:"email_verified"`
+	TOTPEnabled bool `json:"totp_enabled" db:"totp_enabled"`
+}
+
+type ProfileResponse struct {
+	UserResponse
+	// Email al qual s'ha enviat l'enllaç de confirmació d'un canvi
+	PendingEmail string      `json:"pending_email,omitempty"`
+	Preferences  Preferences `json:"preferences"`
 }
 
 type LoginResponse struct {
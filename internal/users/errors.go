@@ -9,4 +9,10 @@ var (
 	ErrUsernameTaken  = errors.New("username already taken")
 	ErrInvalidRequest = errors.New("invalid request")
 	ErrInactiveUser   = errors.New("inactive user")
+	ErrPendingVerification = errors.New("email address not verified")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrRegistrationClosed = errors.New("public registration is disabled")
+	ErrEmailTaken = errors.New("email already in use")
+	ErrWrongPassword = errors.New("current password is incorrect")
+	ErrPreferencesTooLarge = errors.New("preferences are too large")
 )
\ No newline at end of file
@@ -1,6 +1,10 @@
 package users
 
 import (
+	"errors"
+	"frdy-api/internal/identity"
+	"frdy-api/internal/password"
+	"frdy-api/internal/roles"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,15 +18,32 @@ func NewUserHandler(userService UserService) *UserHandler {
 	return &UserHandler{userService: userService}
 }
 
+// errorResponse inclou les regles que no compleix la contrasenya, si és el cas
+func errorResponse(err error) gin.H {
+	response := gin.H{"error": err.Error()}
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		response["violations"] = policyErr.Violations
+	}
+	return response
+}
+
+func isPolicyError(err error) bool {
+	var policyErr *password.PolicyError
+	return errors.As(err, &policyErr)
+}
+
 // Create godoc
 // @Summary Register a new user
-// @Description Register a new user with the provided information (Public route)
+// @Description Register a new user with the provided information. The account stays pending until the email is verified. Only available when the registration mode is "open" (Public route)
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param user body CreateUserRequest true "User registration data"
 // @Success 201 {object} UserResponse
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} map[string]interface{} "Invalid request or password rejected by the policy (see violations)"
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string "Username or email already in use"
 // @Failure 500 {object} map[string]string
 // @Router /auth/register [post]
 func (h *UserHandler) Create(c *gin.Context) {	
@@ -34,7 +55,18 @@ func (h *UserHandler) Create(c *gin.Context) {
 
 	user, err := h.userService.Create(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var statusCode int
+		switch {
+		case errors.Is(err, ErrRegistrationClosed):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, ErrInvalidRequest), isPolicyError(err):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrEmailTaken):
+			statusCode = http.StatusConflict
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, errorResponse(err))
 		return
 	}
 
@@ -95,13 +127,14 @@ func (h *UserHandler) Delete(c *gin.Context) {
 
 // ChangePassword godoc
 // @Summary Change user password
-// @Description Change the password for a user (Protected route)
+// @Description Sets a new password for any user, without the current one. Users change their own password through /api/me/password (Protected route, admin only)
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param password body ChangePasswordRequest true "Password change data"
 // @Success 200 {object} UserResponse
-// @Failure 400 {object} map[string]string
+// @Failure 400 {object} map[string]interface{} "Invalid request or password rejected by the policy (see violations)"
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/users/change-password [post]
 // @Security BearerAuth
@@ -114,7 +147,16 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 	user, err := h.userService.ChangePassword(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var statusCode int
+		switch {
+		case errors.Is(err, ErrInvalidRequest), isPolicyError(err):
+			statusCode = http.StatusBadRequest
+		case errors.Is(err, ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, errorResponse(err))
 		return
 	}
 
@@ -183,4 +225,222 @@ func(h *UserHandler) GetAll(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, users)
-}
\ No newline at end of file
+}
+
+// AssignRole godoc
+// @Summary Assign a role to a user
+// @Description Sets the role (and therefore the permissions) of a user. The caller must hold every permission of the role. Takes effect when the user's token is next refreshed (Protected route, admin only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role body AssignRoleRequest true "Role to assign"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users/{id}/role [put]
+// @Security BearerAuth
+func (h *UserHandler) AssignRole(c *gin.Context) {
+	id := c.Param("id")
+	var request AssignRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.AssignRole(c.Request.Context(), id, identity.Permissions(c), request)
+	if err != nil {
+		var statusCode int
+		switch {
+		case errors.Is(err, roles.ErrRoleNotGrantable):
+			statusCode = http.StatusForbidden
+		case errors.Is(err, ErrUserNotFound), errors.Is(err, roles.ErrRoleNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, ErrInvalidID), errors.Is(err, roles.ErrInvalidID):
+			statusCode = http.StatusBadRequest
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Activates a pending account, or confirms an email change, using the signed token sent by email (Public route)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string "The new email is already in use"
+// @Failure 500 {object} map[string]string
+// @Router /auth/verify-email [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var request VerifyEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(c.Request.Context(), request)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Sends a new verification link if there is a pending account with this email. Always answers 202 (Public route)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResendVerificationRequest true "Account email"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/verify-email/resend [post]
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	var request ResendVerificationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ResendVerification(c.Request.Context(), request); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if there is a pending account for this email, a new link has been sent"})
+}
+
+func profileStatus(err error) int {
+	switch {
+	case errors.Is(err, identity.ErrNoIdentity):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrPreferencesTooLarge), isPolicyError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUsernameTaken), errors.Is(err, ErrEmailTaken):
+		return http.StatusConflict
+	case errors.Is(err, ErrWrongPassword), errors.Is(err, ErrInactiveUser):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetMe godoc
+// @Summary Get my profile
+// @Description Returns the profile and preferences of the current user (Protected route)
+// @Tags me
+// @Accept json
+// @Produce json
+// @Success 200 {object} ProfileResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/me [get]
+// @Security BearerAuth
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(profileStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.userService.Profile(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(profileStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateMe godoc
+// @Summary Update my profile
+// @Description Updates the username and preferences of the current user. Only the fields sent are changed. A new email is kept as pending until the link sent to it is confirmed through /auth/verify-email (Protected route)
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param profile body UpdateProfileRequest true "Profile fields to change"
+// @Success 200 {object} ProfileResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string "Username or email already in use"
+// @Failure 500 {object} map[string]string
+// @Router /api/me [patch]
+// @Security BearerAuth
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(profileStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	var request UpdateProfileRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.userService.UpdateProfile(c.Request.Context(), userID, request)
+	if err != nil {
+		c.JSON(profileStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ChangeMyPassword godoc
+// @Summary Change my password
+// @Description Changes the password of the current user after checking the current one. All the user's tokens are revoked, so the client has to log in again (Protected route)
+// @Tags me
+// @Accept json
+// @Produce json
+// @Param password body ChangeOwnPasswordRequest true "Current and new password"
+// @Success 204
+// @Failure 400 {object} map[string]interface{} "Invalid request or password rejected by the policy (see violations)"
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string "Current password is incorrect"
+// @Failure 500 {object} map[string]string
+// @Router /api/me/password [put]
+// @Security BearerAuth
+func (h *UserHandler) ChangeMyPassword(c *gin.Context) {
+	userID, err := identity.UserID(c)
+	if err != nil {
+		c.JSON(profileStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	var request ChangeOwnPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.ChangeOwnPassword(c.Request.Context(), userID, request); err != nil {
+		c.JSON(profileStatus(err), errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
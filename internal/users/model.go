@@ -1,6 +1,9 @@
 package users
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,5 +15,47 @@ type User struct {
 	Username string    `json:"username" db:"username"`
 	Password string    `json:"password" db:"password"`
 	IsActive bool `json:"is_active" db:"is_active"`
+	RoleID   uuid.NullUUID `json:"role_id" db:"role_id"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	TOTPSecret    string   `json:"-" db:"totp_secret"`
+	TOTPEnabled   bool     `json:"totp_enabled" db:"totp_enabled"`
+	// Hashos SHA-256 dels codis de recuperació que encara no s'han fet servir
+	RecoveryCodes []string `json:"-" db:"totp_recovery_codes"`
+	TOTPLastStep  int64    `json:"-" db:"totp_last_step"`
+	// Email nou que l'usuari encara no ha confirmat
+	PendingEmail string `json:"pending_email,omitempty" db:"pending_email"`
+	Preferences Preferences `json:"preferences" db:"preferences"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+}
+
+// Preferences són les opcions del frontend que l'usuari pot desar (idioma,
+// tema...). L'API no n'interpreta el contingut; es guarden com a jsonb.
+type Preferences map[string]interface{}
+
+func (p Preferences) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *Preferences) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*p = Preferences{}
+		return nil
+	default:
+		return errors.New("unsupported type for preferences")
+	}
+	result := Preferences{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	*p = result
+	return nil
+}
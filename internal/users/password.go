@@ -0,0 +1,57 @@
+package users
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// CheckNewPassword comprova, sense canviar res, si la contrasenya compleix la
+// política i l'historial de l'usuari
+func (s *userService) CheckNewPassword(ctx context.Context, id uuid.UUID, newPassword string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ErrInactiveUser
+	}
+	return s.checkNewPassword(ctx, user, newPassword)
+}
+
+func (s *userService) checkNewPassword(ctx context.Context, user User, newPassword string) error {
+	if err := s.policy.Check(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	if s.policy.HistorySize > 0 {
+		// La contrasenya actual compta com la primera de l'historial
+		previous, err := s.repo.RecentPasswordHashes(ctx, user.ID, s.policy.HistorySize-1)
+		if err != nil {
+			return err
+		}
+		if err := s.policy.CheckHistory(s.hasher, newPassword, append([]string{user.Password}, previous...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPasswordHash valida la contrasenya nova d'un usuari existent contra la
+// política i l'historial i en retorna el hash.
+func (s *userService) newPasswordHash(ctx context.Context, user User, newPassword string) (string, error) {
+	if err := s.checkNewPassword(ctx, user, newPassword); err != nil {
+		return "", err
+	}
+	return s.hasher.Hash(newPassword)
+}
+
+// changePassword desa el hash nou i guarda l'actual a l'historial, que en
+// conserva HistorySize-1 (la contrasenya vigent és la primera)
+func (s *userService) changePassword(ctx context.Context, user User, hashedPassword string) (User, error) {
+	keep := 0
+	if s.policy.HistorySize > 1 {
+		keep = s.policy.HistorySize - 1
+	}
+	return s.repo.ChangePassword(ctx, ChangePasswordRequest{ID: user.ID.String(), Password: hashedPassword}, user.Password, keep)
+}
@@ -0,0 +1,218 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"frdy-api/internal/notify"
+	"frdy-api/internal/securetoken"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	changeEmailPurpose = "change-email"
+	// Mida màxima de les preferències serialitzades
+	maxPreferencesBytes = 4096
+)
+
+func mapUserToProfile(user User) ProfileResponse {
+	preferences := user.Preferences
+	if preferences == nil {
+		preferences = Preferences{}
+	}
+	return ProfileResponse{
+		UserResponse: mapUserToResponse(user),
+		PendingEmail: user.PendingEmail,
+		Preferences:  preferences,
+	}
+}
+
+func (s *userService) Profile(ctx context.Context, id uuid.UUID) (ProfileResponse, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ProfileResponse{}, err
+	}
+	return mapUserToProfile(user), nil
+}
+
+// UpdateProfile canvia el nom d'usuari i les preferències de seguida. Un email
+// nou queda pendent fins que es confirma l'enllaç que s'hi envia, perquè
+// l'email verificat és el que es fa servir per vincular comptes externs.
+func (s *userService) UpdateProfile(ctx context.Context, id uuid.UUID, request UpdateProfileRequest) (ProfileResponse, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return ProfileResponse{}, err
+	}
+
+	username := user.Username
+	if request.Username != nil && *request.Username != user.Username {
+		username = strings.TrimSpace(*request.Username)
+		if username == "" {
+			return ProfileResponse{}, ErrInvalidRequest
+		}
+		if err := s.ensureUsernameFree(ctx, id, username); err != nil {
+			return ProfileResponse{}, err
+		}
+	}
+
+	preferences := user.Preferences
+	if request.Preferences != nil {
+		preferences = *request.Preferences
+		encoded, err := json.Marshal(preferences)
+		if err != nil {
+			return ProfileResponse{}, ErrInvalidRequest
+		}
+		if len(encoded) > maxPreferencesBytes {
+			return ProfileResponse{}, ErrPreferencesTooLarge
+		}
+	}
+
+	if err := s.repo.UpdateProfile(ctx, id, username, preferences); err != nil {
+		return ProfileResponse{}, err
+	}
+	user.Username = username
+	user.Preferences = preferences
+
+	if request.Email != nil {
+		email := strings.TrimSpace(*request.Email)
+		switch {
+		case email == "":
+			return ProfileResponse{}, ErrInvalidRequest
+		case strings.EqualFold(email, user.Email):
+			// Tornar a l'email actual cancel·la el canvi pendent
+			if user.PendingEmail != "" {
+				if err := s.repo.SetPendingEmail(ctx, id, ""); err != nil {
+					return ProfileResponse{}, err
+				}
+				user.PendingEmail = ""
+			}
+		case email != user.PendingEmail:
+			if err := s.ensureEmailFree(ctx, id, email); err != nil {
+				return ProfileResponse{}, err
+			}
+			if err := s.repo.SetPendingEmail(ctx, id, email); err != nil {
+				return ProfileResponse{}, err
+			}
+			user.PendingEmail = email
+			if err := s.sendEmailChange(ctx, user); err != nil {
+				return ProfileResponse{}, fmt.Errorf("error sending email confirmation: %w", err)
+			}
+		}
+	}
+
+	return mapUserToProfile(user), nil
+}
+
+// ChangeOwnPassword comprova la contrasenya actual abans de canviar-la. Com
+// el canvi que fa un administrador, invalida tots els tokens de l'usuari.
+func (s *userService) ChangeOwnPassword(ctx context.Context, id uuid.UUID, request ChangeOwnPasswordRequest) error {
+	if request.CurrentPassword == "" || request.NewPassword == "" {
+		return ErrInvalidRequest
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	valid, err := s.hasher.Verify(user.Password, request.CurrentPassword)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrWrongPassword
+	}
+
+	hashedPassword, err := s.newPasswordHash(ctx, user, request.NewPassword)
+	if err != nil {
+		return err
+	}
+	if _, err := s.changePassword(ctx, user, hashedPassword); err != nil {
+		return err
+	}
+	return s.revocations.RevokeUser(ctx, id)
+}
+
+func (s *userService) ensureUsernameFree(ctx context.Context, id uuid.UUID, username string) error {
+	existing, err := s.repo.FindByUsername(ctx, username)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return nil
+	case errors.Is(err, ErrInactiveUser), errors.Is(err, ErrPendingVerification):
+		// El nom el fa servir un compte desactivat o pendent
+		return ErrUsernameTaken
+	case err != nil:
+		return err
+	case existing.ID != id:
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+func (s *userService) ensureEmailFree(ctx context.Context, id uuid.UUID, email string) error {
+	existing, err := s.repo.FindByEmail(ctx, email)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return nil
+	case errors.Is(err, ErrInactiveUser), errors.Is(err, ErrPendingVerification):
+		return ErrEmailTaken
+	case err != nil:
+		return err
+	case existing.ID != id:
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// emailChangeSubject lliga el token a l'usuari i a l'email concret, perquè un
+// enllaç antic no confirmi un canvi demanat després.
+func emailChangeSubject(userID uuid.UUID, email string) string {
+	return userID.String() + ":" + securetoken.Hash(strings.ToLower(email))
+}
+
+// sendEmailChange envia a l'email nou l'enllaç per confirmar el canvi
+func (s *userService) sendEmailChange(ctx context.Context, user User) error {
+	token := securetoken.Sign(s.verification.Secret, changeEmailPurpose,
+		emailChangeSubject(user.ID, user.PendingEmail), time.Now().Add(s.verification.LinkTTL))
+	link := fmt.Sprintf("%s/verify-email?token=%s", s.verification.BaseURL, url.QueryEscape(token))
+	return s.verification.Notifier.Send(ctx, notify.Message{
+		To:      user.PendingEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hello %s,\n\nConfirm this address to use it in your account. The link expires in %s.\n\n%s",
+			user.Username, s.verification.LinkTTL, link),
+	})
+}
+
+// confirmEmailChange aplica el canvi d'email d'un token de changeEmailPurpose
+func (s *userService) confirmEmailChange(ctx context.Context, subject string) (UserResponse, error) {
+	rawID, _, found := strings.Cut(subject, ":")
+	userID, err := uuid.Parse(rawID)
+	if !found || err != nil {
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+	user, err := s.repo.FindByID(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrInactiveUser) {
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return UserResponse{}, err
+	}
+	if user.PendingEmail == "" || emailChangeSubject(userID, user.PendingEmail) != subject {
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+	if err := s.ensureEmailFree(ctx, userID, user.PendingEmail); err != nil {
+		return UserResponse{}, err
+	}
+
+	user, err = s.repo.ConfirmPendingEmail(ctx, userID, user.PendingEmail)
+	if errors.Is(err, ErrUserNotFound) {
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return UserResponse{}, err
+	}
+	return mapUserToResponse(user), nil
+}
@@ -0,0 +1,23 @@
+package users
+
+import "fmt"
+
+// RegistrationMode defineix qui pot crear comptes nous
+type RegistrationMode string
+
+const (
+	// RegistrationOpen permet que qualsevol es registri a /auth/register
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationInvite només permet registrar-se amb una invitació
+	RegistrationInvite RegistrationMode = "invite"
+	// RegistrationClosed no permet crear comptes nous
+	RegistrationClosed RegistrationMode = "closed"
+)
+
+func ParseRegistrationMode(value string) (RegistrationMode, error) {
+	switch mode := RegistrationMode(value); mode {
+	case RegistrationOpen, RegistrationInvite, RegistrationClosed:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown registration mode %q (expected open, invite or closed)", value)
+}
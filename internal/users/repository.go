@@ -3,41 +3,84 @@ package users
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type UserRepository interface {	
 	Create(ctx context.Context, user User) (User, error)
 	Update(ctx context.Context, user User) (User, error)
 	Delete(ctx context.Context, id uuid.UUID) (error)
-	ChangePassword(ctx context.Context, request ChangePasswordRequest) (User, error)
+	// ChangePassword desa la contrasenya nova i, si keep > 0, guarda
+	// previousHash a l'historial (deixant-ne keep), tot en una transacció
+	ChangePassword(ctx context.Context, request ChangePasswordRequest, previousHash string, keep int) (User, error)
+	UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error
+	RecentPasswordHashes(ctx context.Context, id uuid.UUID, limit int) ([]string, error)
 	FindByID(ctx context.Context, id uuid.UUID) (User, error)
 	FindByUsername(ctx context.Context, username string) (User, error)	
+	// FindAnyByUsername també retorna els comptes desactivats i pendents
+	FindAnyByUsername(ctx context.Context, username string) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
 	FindAll(ctx context.Context) ([]User, error)
+	UpdateRole(ctx context.Context, id uuid.UUID, roleID uuid.UUID) error
+	FindPendingByEmail(ctx context.Context, email string) (User, error)
+	MarkEmailVerified(ctx context.Context, id uuid.UUID) (User, error)
+	DeleteUnverified(ctx context.Context, createdBefore time.Time) (int64, error)
+	UpdateTOTP(ctx context.Context, id uuid.UUID, secret string, enabled bool, recoveryCodes []string) error
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
+	ConsumeRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error)
+	UpdateProfile(ctx context.Context, id uuid.UUID, username string, preferences Preferences) error
+	SetPendingEmail(ctx context.Context, id uuid.UUID, email string) error
+	ConfirmPendingEmail(ctx context.Context, id uuid.UUID, email string) (User, error)
 }
 
 type userRepository struct {
 	db *sql.DB
 }
 
+const userColumns = `id, email, username, password, is_active, role_id, email_verified_at,
+	COALESCE(totp_secret, ''), totp_enabled, COALESCE(totp_recovery_codes, '{}'), COALESCE(totp_last_step, 0),
+	COALESCE(pending_email, ''), preferences`
+
+// userFields retorna els camps on s'escanegen les columnes de userColumns
+func userFields(user *User) []interface{} {
+	return []interface{}{
+		&user.ID, &user.Email, &user.Username, &user.Password, &user.IsActive, &user.RoleID, &user.EmailVerifiedAt,
+		&user.TOTPSecret, &user.TOTPEnabled, pq.Array(&user.RecoveryCodes), &user.TOTPLastStep,
+		&user.PendingEmail, &user.Preferences,
+	}
+}
+
 func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db}
 }
 
 func (r *userRepository) Create(ctx context.Context, user User) (User, error) {
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO users (id, email, username, password, is_active)
-		VALUES ($1, $2, $3, $4, $5)`,
-		user.ID,  user.Email, user.Username, user.Password, user.IsActive,
+		INSERT INTO users (id, email, username, password, is_active, role_id, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		user.ID,  user.Email, user.Username, user.Password, user.IsActive, user.RoleID, user.EmailVerifiedAt,
     )
+    if isEmailTaken(err) {
+        return User{}, ErrEmailTaken
+    }
     if err != nil {
         return User{}, fmt.Errorf("error inserting user: %w", err)
     }
     return user, nil
 }
 
+// isEmailTaken detecta la violació de l'índex únic d'emails (migració 025),
+// per quan dos registres amb el mateix email arriben alhora
+func isEmailTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_users_email_unique"
+}
+
 func(r *userRepository) Update(ctx context.Context, user User) (User, error) {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE users
@@ -63,19 +106,36 @@ func(r *userRepository) Delete(ctx context.Context, id uuid.UUID) (error) {
 	return nil
 }
 
-func(r *userRepository) ChangePassword(ctx context.Context, request ChangePasswordRequest) (User, error){
-	_, err := r.db.ExecContext(ctx, `
+func(r *userRepository) ChangePassword(ctx context.Context, request ChangePasswordRequest, previousHash string, keep int) (User, error){
+	strId, err  := uuid.Parse(request.ID)
+	if err != nil {
+		return User{}, fmt.Errorf("error parsing id: %w", err)
+	}
+
+	// L'historial només guarda contrasenyes que s'han substituït de debò
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
 		UPDATE users
 		SET password = $1, password_changed_at = now()
 		WHERE id = $2`,
-		request.Password, request.ID)
+		request.Password, strId)
 	if err != nil {
 		return User{}, fmt.Errorf("error changing password: %w", err)
 	}
-	strId, err  := uuid.Parse(request.ID)
-	if err != nil {
-		return User{}, fmt.Errorf("error parsing id: %w", err)
+	if keep > 0 {
+		if err := addPasswordHistory(ctx, tx, strId, previousHash, keep); err != nil {
+			return User{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return User{}, fmt.Errorf("error committing transaction: %w", err)
 	}
+
 	user, err := r.FindByID(ctx, strId)
 	if err != nil {
 		return User{}, err
@@ -84,55 +144,290 @@ func(r *userRepository) ChangePassword(ctx context.Context, request ChangePasswo
 }
 
 
+// UpdatePasswordHash substitueix el hash per un de recalculat de la mateixa
+// contrasenya. No compta com a canvi de contrasenya, i no fa res si mentrestant
+// la contrasenya ja s'ha canviat.
+func(r *userRepository) UpdatePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET password = $1
+		WHERE id = $2 AND password = $3`,
+		newHash, id, oldHash)
+	if err != nil {
+		return fmt.Errorf("error updating password hash: %w", err)
+	}
+	return nil
+}
+
+// RecentPasswordHashes retorna els hashos de les contrasenyes anteriors de
+// l'usuari, de la més recent a la més antiga
+func(r *userRepository) RecentPasswordHashes(ctx context.Context, id uuid.UUID, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT password_hash FROM password_history
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`,
+		id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting password history: %w", err)
+	}
+	defer rows.Close()
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("error scanning password history: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// addPasswordHistory guarda un hash a l'historial i n'elimina els que
+// sobrepassen els keep més recents
+func addPasswordHistory(ctx context.Context, tx *sql.Tx, id uuid.UUID, hash string, keep int) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO password_history (user_id, password_hash) VALUES ($1, $2)`,
+		id, hash)
+	if err != nil {
+		return fmt.Errorf("error adding password history: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1
+			ORDER BY created_at DESC, id DESC LIMIT $2)`,
+		id, keep)
+	if err != nil {
+		return fmt.Errorf("error pruning password history: %w", err)
+	}
+	return nil
+}
 
 func(r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (User, error){
 	var user User
-	row := r.db.QueryRowContext(ctx, `SELECT id, email, username, password, is_active FROM users WHERE id = $1`, id)
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
 	
-	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.IsActive)
+	err := row.Scan(userFields(&user)...)
 	if err == sql.ErrNoRows {
 		return User{}, ErrUserNotFound
 	}else if err != nil {
 		return User{}, fmt.Errorf("error getting user: %w", err)
 	}
 	if !user.IsActive {
-		return User{}, ErrInactiveUser
+		return User{}, InactiveError(user)
 	}
 	return user, nil
 }
 
 func(r *userRepository) FindByUsername(ctx context.Context, username string) (User, error)	{
+	user, err := r.FindAnyByUsername(ctx, username)
+	if err != nil {
+		return User{}, err
+	}
+	if !user.IsActive {
+		return User{}, InactiveError(user)
+	}
+	return user, nil
+}
+
+func (r *userRepository) FindAnyByUsername(ctx context.Context, username string) (User, error) {
 	var user User
-	row := r.db.QueryRowContext(ctx, `SELECT id, email, username, password, is_active FROM users WHERE username = $1`, username)
-	
-	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.IsActive)
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE username = $1`, username)
+
+	err := row.Scan(userFields(&user)...)
+	if err == sql.ErrNoRows {
+		return User{}, ErrUserNotFound
+	} else if err != nil {
+		return User{}, fmt.Errorf("error getting user: %w", err)
+	}
+	return user, nil
+}
+
+
+func(r *userRepository) FindByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE lower(email) = lower($1) LIMIT 1`, email)
+
+	err := row.Scan(userFields(&user)...)
 	if err == sql.ErrNoRows {
 		return User{}, ErrUserNotFound
 	}else if err != nil {
 		return User{}, fmt.Errorf("error getting user: %w", err)
 	}
 	if !user.IsActive {
-		return User{}, ErrInactiveUser
+		return User{}, InactiveError(user)
 	}
 	return user, nil
 }
 
-
-
 func(r *userRepository) FindAll(ctx context.Context) ([]User, error){
 	var users []User
-	rows, err := r.db.QueryContext(ctx, `SELECT id, email, username, password, is_active FROM users`)
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users`)
 	if err != nil {
 		return nil, fmt.Errorf("error getting users: %w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var user User
-		err := rows.Scan(&user.ID, &user.Email, &user.Username, &user.Password, &user.IsActive)
+		err := rows.Scan(userFields(&user)...)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning user: %w", err)
 		}
 		users = append(users, user)
 	}
 	return users, nil
-}
\ No newline at end of file
+}
+
+func(r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, roleID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET role_id = $1
+		WHERE id = $2`,
+		roleID, id)
+	if err != nil {
+		return fmt.Errorf("error updating user role: %w", err)
+	}
+	return nil
+}
+
+// InactiveError distingeix els comptes pendents de verificar dels desactivats
+func InactiveError(user User) error {
+	if user.EmailVerifiedAt == nil {
+		return ErrPendingVerification
+	}
+	return ErrInactiveUser
+}
+
+func(r *userRepository) FindPendingByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users
+		WHERE lower(email) = lower($1) AND is_active = false AND email_verified_at IS NULL
+		ORDER BY created_at DESC LIMIT 1`, email)
+
+	err := row.Scan(userFields(&user)...)
+	if err == sql.ErrNoRows {
+		return User{}, ErrUserNotFound
+	} else if err != nil {
+		return User{}, fmt.Errorf("error getting user: %w", err)
+	}
+	return user, nil
+}
+
+// MarkEmailVerified activa un compte pendent. Si ja estava verificat no el
+// torna a activar (podria haver estat desactivat després).
+func(r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID) (User, error) {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET email_verified_at = now(), is_active = true
+		WHERE id = $1 AND email_verified_at IS NULL`,
+		id)
+	if err != nil {
+		return User{}, fmt.Errorf("error verifying user email: %w", err)
+	}
+	return r.FindByID(ctx, id)
+}
+
+func(r *userRepository) DeleteUnverified(ctx context.Context, createdBefore time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM users
+		WHERE is_active = false AND email_verified_at IS NULL AND created_at < $1`,
+		createdBefore)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting unverified users: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func(r *userRepository) UpdateTOTP(ctx context.Context, id uuid.UUID, secret string, enabled bool, recoveryCodes []string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET totp_secret = NULLIF($1, ''), totp_enabled = $2, totp_recovery_codes = $3,
+			totp_last_step = CASE WHEN totp_secret IS DISTINCT FROM NULLIF($1, '') THEN 0 ELSE totp_last_step END
+		WHERE id = $4`,
+		secret, enabled, pq.Array(recoveryCodes), id)
+	if err != nil {
+		return fmt.Errorf("error updating user totp: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep registra el període de l'últim codi acceptat. Retorna false si
+// ja s'havia fet servir un codi d'aquest període o d'un de posterior.
+func(r *userRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET totp_last_step = $1
+		WHERE id = $2 AND COALESCE(totp_last_step, 0) < $1`,
+		step, id)
+	if err != nil {
+		return false, fmt.Errorf("error updating totp step: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ConsumeRecoveryCode elimina el codi de recuperació si existeix
+func(r *userRepository) ConsumeRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET totp_recovery_codes = array_remove(totp_recovery_codes, $1)
+		WHERE id = $2 AND $1 = ANY(totp_recovery_codes)`,
+		codeHash, id)
+	if err != nil {
+		return false, fmt.Errorf("error consuming recovery code: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func(r *userRepository) UpdateProfile(ctx context.Context, id uuid.UUID, username string, preferences Preferences) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET username = $1, preferences = $2
+		WHERE id = $3`,
+		username, preferences, id)
+	if err != nil {
+		return fmt.Errorf("error updating user profile: %w", err)
+	}
+	return nil
+}
+
+func(r *userRepository) SetPendingEmail(ctx context.Context, id uuid.UUID, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET pending_email = NULLIF($1, '')
+		WHERE id = $2`,
+		email, id)
+	if err != nil {
+		return fmt.Errorf("error updating pending email: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPendingEmail substitueix l'email per l'email pendent, només si
+// encara és el mateix al qual s'ha enviat l'enllaç.
+func(r *userRepository) ConfirmPendingEmail(ctx context.Context, id uuid.UUID, email string) (User, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users
+		SET email = pending_email, pending_email = NULL, email_verified_at = now()
+		WHERE id = $1 AND is_active = true AND pending_email = $2`,
+		id, email)
+	if err != nil {
+		return User{}, fmt.Errorf("error confirming email change: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return User{}, err
+	}
+	if affected == 0 {
+		return User{}, ErrUserNotFound
+	}
+	return r.FindByID(ctx, id)
+}
@@ -7,13 +7,23 @@ func RegisterRoutes(router *gin.RouterGroup, handler *UserHandler) {
 	{		
 		roles.PUT("/:id", handler.Update)
 		roles.DELETE("/:id", handler.Delete)
+		roles.PUT("/:id/role", handler.AssignRole)
 		roles.POST("/change-password", handler.ChangePassword)				
 		roles.GET("/username/:username", handler.GetByUsername)
 		roles.GET("/:id", handler.GetByID)
 		roles.GET("", handler.GetAll)
 	}
+
+	me := router.Group("/me")
+	{
+		me.GET("", handler.GetMe)
+		me.PATCH("", handler.UpdateMe)
+		me.PUT("/password", handler.ChangeMyPassword)
+	}
 }
 
 func RegisterPublicRoutes(router *gin.RouterGroup, handler *UserHandler) {
     router.POST("/register", handler.Create) // Ruta pública per crear usuaris
+    router.POST("/verify-email", handler.VerifyEmail)
+    router.POST("/verify-email/resend", handler.ResendVerification)
 }
\ No newline at end of file
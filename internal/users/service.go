@@ -5,27 +5,52 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"frdy-api/internal/password"
+	"frdy-api/internal/revocation"
+	"frdy-api/internal/roles"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
 	Create(ctx context.Context, request CreateUserRequest) (UserResponse, error)
+	// CreateInvited crea un compte ja verificat amb el rol de la invitació
+	CreateInvited(ctx context.Context, request CreateUserRequest, roleID uuid.UUID) (UserResponse, error)
+	RegistrationMode() RegistrationMode
 	Update(ctx context.Context, id string, request UpdateUserRequest)(UserResponse, error)
 	Delete(ctx context.Context, id string) (error)
 	ChangePassword(ctx context.Context, request ChangePasswordRequest) (UserResponse, error)
+	// CheckNewPassword valida la contrasenya nova sense desar-la
+	CheckNewPassword(ctx context.Context, id uuid.UUID, password string) error
 	FindByUsername(ctx context.Context, username string) (UserResponse, error)
 	FindByID(ctx context.Context, id string) (UserResponse, error)
 	FindAll(ctx context.Context) ([]UserResponse, error)
+	// AssignRole canvia el rol de l'usuari. granterPermissions són els permisos
+	// de qui l'assigna, que ha de tenir tots els del rol.
+	AssignRole(ctx context.Context, id string, granterPermissions []string, request AssignRoleRequest) (UserResponse, error)
+	VerifyEmail(ctx context.Context, request VerifyEmailRequest) (UserResponse, error)
+	ResendVerification(ctx context.Context, request ResendVerificationRequest) error
+	PurgeUnverified(ctx context.Context) (int64, error)
+	// Perfil de l'usuari autenticat
+	Profile(ctx context.Context, id uuid.UUID) (ProfileResponse, error)
+	UpdateProfile(ctx context.Context, id uuid.UUID, request UpdateProfileRequest) (ProfileResponse, error)
+	ChangeOwnPassword(ctx context.Context, id uuid.UUID, request ChangeOwnPasswordRequest) error
 }
 
 type userService struct {
-	repo UserRepository
+	repo         UserRepository
+	roleRepo     roles.RoleRepository
+	revocations  revocation.Store
+	verification VerificationConfig
+	registration RegistrationMode
+	hasher       password.Hasher
+	policy       password.Policy
 }
 
-func NewUserService(repo UserRepository) UserService {
-	return &userService{repo}
+func NewUserService(repo UserRepository, roleRepo roles.RoleRepository, revocations revocation.Store, verification VerificationConfig, registration RegistrationMode, hasher password.Hasher, policy password.Policy) UserService {
+	return &userService{repo: repo, roleRepo: roleRepo, revocations: revocations, verification: verification, registration: registration, hasher: hasher, policy: policy}
 }
 
 func mapUserToResponse(user User) UserResponse {
@@ -34,48 +59,106 @@ func mapUserToResponse(user User) UserResponse {
 		Email: user.Email,
 		Username: user.Username,
 		IsActive: user.IsActive,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		TOTPEnabled: user.TOTPEnabled,
+	}
+	if user.RoleID.Valid {
+		response.RoleID = user.RoleID.UUID.String()
 	}
 	return response
 }
 
 func(s *userService) Create(ctx context.Context, request CreateUserRequest) (UserResponse, error) {
-	// Validate the request
-	if request.Username == "" || request.Password == "" ||  request.Email == "" {
-		return UserResponse{} , ErrInvalidRequest
+	// Només es pot registrar lliurement en mode obert
+	if s.registration != RegistrationOpen {
+		return UserResponse{}, ErrRegistrationClosed
 	}
 
-	// Check if the username is already taken
-	_, err := s.repo.FindByUsername(ctx, request.Username)	
-	if err == nil {		
-		return UserResponse{}, ErrUsernameTaken
+	// Els usuaris nous reben el rol per defecte (només lectura)
+	defaultRole, err := s.roleRepo.FindByName(ctx, roles.DefaultRoleName)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	user, err := s.newUser(ctx, request, defaultRole.ID)
+	if err != nil {
+		return UserResponse{}, err
 	}
+	user.IsActive = false // Pendent fins que es verifiqui l'email
 
-	if err != ErrUserNotFound {		
+	// Insert the user into the database
+	createdUser, err := s.repo.Create(ctx, user)
+	if err != nil {
 		return UserResponse{}, err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
-    if err != nil {
-        return UserResponse{}, err
-    }
-	// Create a new User instance
-	user := User{
-		ID:       uuid.New(),
-		Email: request.Email,
-		Username: request.Username,
-		Password: string(hashedPassword),
-		IsActive: true,		
+	// Enviar l'enllaç de verificació. Si falla, l'usuari el pot tornar a demanar
+	if err := s.sendVerification(ctx, createdUser); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", createdUser.ID, err)
 	}
 
-	// Insert the user into the database
+	return mapUserToResponse(createdUser), nil
+}
+
+// CreateInvited no envia cap verificació: la invitació ja s'ha rebut a l'email
+func (s *userService) CreateInvited(ctx context.Context, request CreateUserRequest, roleID uuid.UUID) (UserResponse, error) {
+	user, err := s.newUser(ctx, request, roleID)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	now := time.Now()
+	user.IsActive = true
+	user.EmailVerifiedAt = &now
+
 	createdUser, err := s.repo.Create(ctx, user)
 	if err != nil {
 		return UserResponse{}, err
 	}
+	return mapUserToResponse(createdUser), nil
+}
+
+func (s *userService) RegistrationMode() RegistrationMode {
+	return s.registration
+}
+
+// newUser valida la petició i prepara l'usuari amb la contrasenya xifrada
+func (s *userService) newUser(ctx context.Context, request CreateUserRequest, roleID uuid.UUID) (User, error) {
+	// Validate the request
+	if request.Username == "" || request.Password == "" ||  request.Email == "" {
+		return User{}, ErrInvalidRequest
+	}
 
-	//Create a validation string store in redis and send it to the user via email or sms
+	// Check if the username is already taken
+	_, err := s.repo.FindByUsername(ctx, request.Username)
+	if err == nil {
+		return User{}, ErrUsernameTaken
+	}
 
-	return mapUserToResponse(createdUser), nil
+	if err != ErrUserNotFound {
+		return User{}, err
+	}
+
+	// L'email identifica el compte al restabliment de contrasenya, a les
+	// invitacions i a l'OIDC: no pot haver-hi dos usuaris amb el mateix
+	if err := s.ensureEmailFree(ctx, uuid.Nil, request.Email); err != nil {
+		return User{}, err
+	}
+
+	if err := s.policy.Check(request.Password, request.Username, request.Email); err != nil {
+		return User{}, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		return User{}, err
+	}
+
+	return User{
+		ID:       uuid.New(),
+		Email: request.Email,
+		Username: request.Username,
+		Password: hashedPassword,
+		RoleID:   uuid.NullUUID{UUID: roleID, Valid: true},
+	}, nil
 }
 
 func(s *userService) Update(ctx context.Context,id string,  request UpdateUserRequest)(UserResponse, error){
@@ -95,6 +178,7 @@ func(s *userService) Update(ctx context.Context,id string,  request UpdateUserRe
 	}
 	user := User{
 		ID:       uuid.MustParse(id),
+		Email:    existingUser.Email,
 		Username: request.Username,		
 		IsActive: request.IsActive,		
 	}
@@ -127,7 +211,8 @@ func (s *userService) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	return nil
+	// Invalidar tots els tokens de l'usuari eliminat
+	return s.revocations.RevokeUser(ctx, parsedID)
 }
 
 func (s *userService) ChangePassword(ctx context.Context, request ChangePasswordRequest) (UserResponse, error) {
@@ -146,17 +231,20 @@ func (s *userService) ChangePassword(ctx context.Context, request ChangePassword
 		return UserResponse{}, ErrInactiveUser
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.newPasswordHash(ctx, existingUser, request.Password)
 	if err != nil {
 		return UserResponse{}, err
 	}
 
-	request.Password = string(hashedPassword)
-
-	response, err := s.repo.ChangePassword(ctx, request)
+	response, err := s.changePassword(ctx, existingUser, hashedPassword)
 	if err != nil {
 		return UserResponse{}, err
 	}	
+
+	// Els tokens emesos amb la contrasenya antiga deixen de ser vàlids
+	if err := s.revocations.RevokeUser(ctx, existingUser.ID); err != nil {
+		return UserResponse{}, err
+	}
 	return mapUserToResponse(response), nil
 }
 
@@ -205,4 +293,33 @@ func (s *userService) FindAll(ctx context.Context) ([]UserResponse, error) {
 	}
 
 	return userResponses, nil
-}
\ No newline at end of file
+}
+
+func (s *userService) AssignRole(ctx context.Context, id string, granterPermissions []string, request AssignRoleRequest) (UserResponse, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return UserResponse{}, ErrInvalidID
+	}
+	roleID, err := uuid.Parse(request.RoleID)
+	if err != nil {
+		return UserResponse{}, roles.ErrInvalidID
+	}
+
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	role, err := s.roleRepo.FindByID(ctx, roleID)
+	if err != nil {
+		return UserResponse{}, err
+	}
+	if !roles.Covers(granterPermissions, role.Permissions) {
+		return UserResponse{}, roles.ErrRoleNotGrantable
+	}
+
+	if err := s.repo.UpdateRole(ctx, userID, roleID); err != nil {
+		return UserResponse{}, err
+	}
+	user.RoleID = uuid.NullUUID{UUID: roleID, Valid: true}
+	return mapUserToResponse(user), nil
+}
@@ -0,0 +1,107 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"frdy-api/internal/notify"
+	"frdy-api/internal/securetoken"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const verifyEmailPurpose = "verify-email"
+
+// VerificationConfig configura la verificació de l'email dels comptes nous
+type VerificationConfig struct {
+	Notifier notify.Notifier
+	// Secret amb què se signen els enllaços de verificació
+	Secret []byte
+	// URL del frontend on apunta l'enllaç
+	BaseURL string
+	// Validesa de cada enllaç
+	LinkTTL time.Duration
+	// Els comptes que no s'han verificat passat aquest temps s'eliminen
+	AccountTTL time.Duration
+}
+
+// sendVerification envia un enllaç signat per activar el compte
+func (s *userService) sendVerification(ctx context.Context, user User) error {
+	token := securetoken.Sign(s.verification.Secret, verifyEmailPurpose, user.ID.String(), time.Now().Add(s.verification.LinkTTL))
+	link := fmt.Sprintf("%s/verify-email?token=%s", s.verification.BaseURL, url.QueryEscape(token))
+	return s.verification.Notifier.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nConfirm your email address to activate your account. The link expires in %s.\n\n%s",
+			user.Username, s.verification.LinkTTL, link),
+	})
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, request VerifyEmailRequest) (UserResponse, error) {
+	subject, err := securetoken.Verify(s.verification.Secret, verifyEmailPurpose, request.Token)
+	if err != nil {
+		// El mateix enllaç serveix per confirmar un canvi d'email
+		if subject, err := securetoken.Verify(s.verification.Secret, changeEmailPurpose, request.Token); err == nil {
+			return s.confirmEmailChange(ctx, subject)
+		}
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+
+	user, err := s.repo.MarkEmailVerified(ctx, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		// El compte ja s'ha eliminat per caducat
+		return UserResponse{}, ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return UserResponse{}, err
+	}
+	return mapUserToResponse(user), nil
+}
+
+// ResendVerification torna a enviar l'enllaç si hi ha un compte pendent amb
+// aquest email. Si no n'hi ha, no fa res per no revelar quins emails existeixen.
+func (s *userService) ResendVerification(ctx context.Context, request ResendVerificationRequest) error {
+	if request.Email == "" {
+		return ErrInvalidRequest
+	}
+	user, err := s.repo.FindPendingByEmail(ctx, request.Email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user)
+}
+
+func (s *userService) PurgeUnverified(ctx context.Context) (int64, error) {
+	return s.repo.DeleteUnverified(ctx, time.Now().Add(-s.verification.AccountTTL))
+}
+
+// PurgeUnverifiedPeriodically elimina els comptes no verificats caducats
+// cada interval, fins que es cancel·li el context.
+func PurgeUnverifiedPeriodically(ctx context.Context, service UserService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := service.PurgeUnverified(ctx)
+		if err != nil {
+			log.Printf("Error purging unverified users: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Purged %d unverified users", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
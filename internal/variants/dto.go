@@ -0,0 +1,21 @@
+package variants
+
+type AttributeRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Values []string `json:"values" binding:"required,min=1"`
+}
+
+// MatrixRequest defineix els atributs del model. Es poden afegir valors a un
+// atribut existent, però no canviar els atributs un cop hi ha variants.
+type MatrixRequest struct {
+	Attributes []AttributeRequest `json:"attributes" binding:"required,min=1,dive"`
+}
+
+type VariantRequest struct {
+	Code string `json:"code" binding:"required"`
+	// Nul perquè la variant tingui el preu del model
+	PriceOverride *float64 `json:"price_override" binding:"omitempty,gt=0"`
+	IsActive      *bool    `json:"is_active"`
+	// Si s'indica, s'afegeix com a codi de barres de la variant
+	Barcode string `json:"barcode"`
+}
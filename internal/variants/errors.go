@@ -0,0 +1,16 @@
+package variants
+
+import "errors"
+
+var (
+	ErrModelNotFound     = errors.New("model item not found")
+	ErrVariantNotFound   = errors.New("variant not found")
+	ErrInvalidID         = errors.New("invalid ID")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrNestedVariant     = errors.New("a variant cannot have variants of its own")
+	ErrAttributesChanged = errors.New("the attributes of a model with variants cannot change; only new values can be added")
+	ErrUnknownAttribute  = errors.New("unknown attribute for this model")
+	ErrNoAttributes      = errors.New("this item has no variants")
+	ErrTooManyVariants   = errors.New("too many variants for one model")
+	ErrCodeTaken         = errors.New("this code is already assigned to an item")
+)
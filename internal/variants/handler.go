@@ -0,0 +1,162 @@
+package variants
+
+import (
+	"errors"
+	"frdy-api/internal/barcodes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VariantHandler struct {
+	service VariantService
+}
+
+func NewVariantHandler(service VariantService) *VariantHandler {
+	return &VariantHandler{service: service}
+}
+
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, ErrModelNotFound), errors.Is(err, ErrVariantNotFound), errors.Is(err, ErrNoAttributes):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrUnknownAttribute),
+		errors.Is(err, ErrTooManyVariants), errors.Is(err, ErrNestedVariant),
+		errors.Is(err, barcodes.ErrInvalidRequest), errors.Is(err, barcodes.ErrInvalidGTIN):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrAttributesChanged), errors.Is(err, ErrCodeTaken), errors.Is(err, barcodes.ErrCodeTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Generate godoc
+// @Summary Generate the variants of a model item
+// @Description Saves the attribute matrix of a model item (e.g. size and colour) and creates one child item per missing combination, with code "<model code>-<value>-<value>" and the model's description, cost, price, category and base unit. Values can be added to an existing matrix later; existing variants are kept (Protected route)
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Model item ID"
+// @Param request body MatrixRequest true "Attribute matrix"
+// @Success 201 {object} Matrix
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/variants [post]
+// @Security BearerAuth
+func (h *VariantHandler) Generate(c *gin.Context) {
+	var request MatrixRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	matrix, err := h.service.Generate(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, matrix)
+}
+
+// FindMatrix godoc
+// @Summary Get the variants of a model item
+// @Description Retrieves the model item, its attributes and all its variants with their attribute values, barcodes, price override and stock (Protected route)
+// @Tags variants
+// @Produce json
+// @Param id path string true "Model item ID"
+// @Success 200 {object} Matrix
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/variants [get]
+// @Security BearerAuth
+func (h *VariantHandler) FindMatrix(c *gin.Context) {
+	matrix, err := h.service.FindMatrix(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, matrix)
+}
+
+// StockGrid godoc
+// @Summary Get the stock of a model item as a grid
+// @Description Retrieves the stock of all variants of a model item as a grid, e.g. size × colour, with row and column totals. By default the first attribute goes in the rows and the second in the columns; other attributes are added up (Protected route)
+// @Tags variants
+// @Produce json
+// @Param id path string true "Model item ID"
+// @Param rows query string false "Attribute for the rows"
+// @Param columns query string false "Attribute for the columns"
+// @Success 200 {object} StockGrid
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/variants/stock-grid [get]
+// @Security BearerAuth
+func (h *VariantHandler) StockGrid(c *gin.Context) {
+	grid, err := h.service.StockGrid(c.Request.Context(), c.Param("id"), c.Query("rows"), c.Query("columns"))
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, grid)
+}
+
+// Update godoc
+// @Summary Update a variant
+// @Description Changes the code, price override and active flag of a variant, and optionally adds a barcode to it. Without a price override the variant takes the model's price (Protected route)
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Model item ID"
+// @Param variant_id path string true "Variant item ID"
+// @Param request body VariantRequest true "Variant data"
+// @Success 200 {object} Variant
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/variants/{variant_id} [put]
+// @Security BearerAuth
+func (h *VariantHandler) Update(c *gin.Context) {
+	var request VariantRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	variant, err := h.service.Update(c.Request.Context(), c.Param("id"), c.Param("variant_id"), request)
+	if err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// Delete godoc
+// @Summary Delete a variant
+// @Description Deletes the item of a variant (Protected route)
+// @Tags variants
+// @Param id path string true "Model item ID"
+// @Param variant_id path string true "Variant item ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/items/{id}/variants/{variant_id} [delete]
+// @Security BearerAuth
+func (h *VariantHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), c.Param("variant_id")); err != nil {
+		c.JSON(statusFromError(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,48 @@
+package variants
+
+import (
+	"frdy-api/internal/items"
+
+	"github.com/google/uuid"
+)
+
+// Attribute és un eix de la matriu de variants d'un model (p. ex. la talla),
+// amb els valors en l'ordre en què s'han de mostrar
+type Attribute struct {
+	Name   string   `json:"name" example:"size"`
+	Values []string `json:"values" example:"S,M,L"`
+}
+
+// Variant és un article fill d'un model. És un article com qualsevol altre
+// (té codi, codis de barres, preu i estoc propis) amb el valor de cada atribut.
+// Si no té preu propi, el preu és el del model.
+type Variant struct {
+	items.Item
+	ModelID       uuid.UUID         `json:"model_id"`
+	Attributes    map[string]string `json:"attributes"`
+	PriceOverride *float64          `json:"price_override"`
+	Barcodes      []string          `json:"barcodes"`
+	Stock         float64           `json:"stock"`
+}
+
+// Matrix és un model amb els atributs que el defineixen i totes les seves variants
+type Matrix struct {
+	Model      items.Item  `json:"model"`
+	Attributes []Attribute `json:"attributes"`
+	Variants   []Variant   `json:"variants"`
+}
+
+// StockGrid és l'estoc d'un model en una graella (p. ex. talla × color).
+// Quantities[i][j] és l'estoc de la fila Rows[i] i la columna Columns[j];
+// si el model té més de dos atributs, la resta se sumen.
+type StockGrid struct {
+	ModelID         uuid.UUID   `json:"model_id"`
+	RowAttribute    string      `json:"row_attribute"`
+	ColumnAttribute string      `json:"column_attribute,omitempty"`
+	Rows            []string    `json:"rows"`
+	Columns         []string    `json:"columns"`
+	Quantities      [][]float64 `json:"quantities"`
+	RowTotals       []float64   `json:"row_totals"`
+	ColumnTotals    []float64   `json:"column_totals"`
+	Total           float64     `json:"total"`
+}
@@ -0,0 +1,220 @@
+package variants
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const variantColumns = `
+	i.id, i.code, i.description, i.cost, i.last_purchase_cost, i.price, i.is_active, i.category_id, i.base_unit,
+	v.model_id, v.attributes, v.price_override,
+	ARRAY(SELECT c.code FROM item_codes c WHERE c.item_id = i.id ORDER BY c.created_at),
+	COALESCE(s.quantity, 0)`
+
+const variantJoins = `
+	FROM item_variants v
+		INNER JOIN items i ON i.id = v.item_id
+		LEFT JOIN stocks s ON s.item_id = i.id`
+
+type VariantRepository interface {
+	IsVariant(ctx context.Context, itemID uuid.UUID) (bool, error)
+	FindAttributes(ctx context.Context, modelID uuid.UUID) ([]Attribute, error)
+	FindVariants(ctx context.Context, modelID uuid.UUID) ([]Variant, error)
+	FindVariant(ctx context.Context, modelID, variantID uuid.UUID) (Variant, error)
+	// Generate desa els atributs del model i crea les variants noves, tot o res
+	Generate(ctx context.Context, modelID uuid.UUID, attributes []Attribute, variants []Variant) error
+	Update(ctx context.Context, variant Variant) error
+	Delete(ctx context.Context, modelID, variantID uuid.UUID) error
+	// UpdateModelPrices fa que les variants sense preu propi segueixin el
+	// preu del model, dins de la transacció que l'ha canviat
+	UpdateModelPrices(tx *sql.Tx, modelID uuid.UUID, price float64) error
+}
+
+type variantRepository struct {
+	db *sql.DB
+}
+
+func NewVariantRepository(db *sql.DB) VariantRepository {
+	return &variantRepository{db: db}
+}
+
+func scanVariant(row interface{ Scan(...interface{}) error }) (Variant, error) {
+	var v Variant
+	var attributes []byte
+	err := row.Scan(&v.ID, &v.Code, &v.Description, &v.Cost, &v.LastPurchaseCost, &v.Price, &v.IsActive, &v.CategoryID, &v.BaseUnit,
+		&v.ModelID, &attributes, &v.PriceOverride, pq.Array(&v.Barcodes), &v.Stock)
+	if err != nil {
+		return Variant{}, err
+	}
+	if err := json.Unmarshal(attributes, &v.Attributes); err != nil {
+		return Variant{}, fmt.Errorf("error decoding variant attributes: %w", err)
+	}
+	return v, nil
+}
+
+func (r *variantRepository) IsVariant(ctx context.Context, itemID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM item_variants WHERE item_id = $1)`, itemID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking variant: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *variantRepository) FindAttributes(ctx context.Context, modelID uuid.UUID) ([]Attribute, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT name, "values"
+		FROM item_model_attributes
+		WHERE model_id = $1
+		ORDER BY position`, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying model attributes: %w", err)
+	}
+	defer rows.Close()
+
+	attributes := []Attribute{}
+	for rows.Next() {
+		var a Attribute
+		if err := rows.Scan(&a.Name, pq.Array(&a.Values)); err != nil {
+			return nil, fmt.Errorf("error scanning model attribute: %w", err)
+		}
+		attributes = append(attributes, a)
+	}
+	return attributes, rows.Err()
+}
+
+func (r *variantRepository) FindVariants(ctx context.Context, modelID uuid.UUID) ([]Variant, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+variantColumns+variantJoins+`
+		WHERE v.model_id = $1
+		ORDER BY i.code`, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []Variant{}
+	for rows.Next() {
+		v, err := scanVariant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning variant: %w", err)
+		}
+		variants = append(variants, v)
+	}
+	return variants, rows.Err()
+}
+
+func (r *variantRepository) FindVariant(ctx context.Context, modelID, variantID uuid.UUID) (Variant, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+variantColumns+variantJoins+`
+		WHERE v.model_id = $1 AND v.item_id = $2`, modelID, variantID)
+	v, err := scanVariant(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Variant{}, ErrVariantNotFound
+	}
+	if err != nil {
+		return Variant{}, fmt.Errorf("error fetching variant: %w", err)
+	}
+	return v, nil
+}
+
+func (r *variantRepository) Generate(ctx context.Context, modelID uuid.UUID, attributes []Attribute, variants []Variant) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_model_attributes WHERE model_id = $1`, modelID); err != nil {
+		return fmt.Errorf("error saving model attributes: %w", err)
+	}
+	for i, a := range attributes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO item_model_attributes (model_id, position, name, "values")
+			VALUES ($1, $2, $3, $4)`,
+			modelID, i, a.Name, pq.Array(a.Values),
+		); err != nil {
+			return fmt.Errorf("error saving model attributes: %w", err)
+		}
+	}
+
+	for _, v := range variants {
+		attrs, err := json.Marshal(v.Attributes)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO items (id, code, description, cost, price, is_active, category_id, base_unit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			v.ID, v.Code, v.Description, v.Cost, v.Price, v.IsActive, v.CategoryID, v.BaseUnit,
+		); err != nil {
+			return fmt.Errorf("error creating variant %s: %w", v.Code, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO item_variants (item_id, model_id, attributes)
+			VALUES ($1, $2, $3)`,
+			v.ID, modelID, attrs,
+		); err != nil {
+			return fmt.Errorf("error creating variant %s: %w", v.Code, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *variantRepository) UpdateModelPrices(tx *sql.Tx, modelID uuid.UUID, price float64) error {
+	_, err := tx.Exec(`
+		UPDATE items i
+		SET price = $1
+		FROM item_variants v
+		WHERE v.item_id = i.id AND v.model_id = $2 AND v.price_override IS NULL`,
+		price, modelID,
+	)
+	return err
+}
+
+func (r *variantRepository) Update(ctx context.Context, variant Variant) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE items
+		SET code = $1, price = $2, is_active = $3
+		WHERE id = $4`,
+		variant.Code, variant.Price, variant.IsActive, variant.ID,
+	); err != nil {
+		return fmt.Errorf("error updating variant: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE item_variants
+		SET price_override = $1
+		WHERE item_id = $2`,
+		variant.PriceOverride, variant.ID,
+	); err != nil {
+		return fmt.Errorf("error updating variant: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Delete esborra l'article de la variant; la fila d'item_variants cau amb ell
+func (r *variantRepository) Delete(ctx context.Context, modelID, variantID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM items
+		WHERE id = $1 AND id IN (SELECT item_id FROM item_variants WHERE model_id = $2)`,
+		variantID, modelID)
+	if err != nil {
+		return fmt.Errorf("error deleting variant: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrVariantNotFound
+	}
+	return nil
+}
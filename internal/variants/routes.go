@@ -0,0 +1,14 @@
+package variants
+
+import "github.com/gin-gonic/gin"
+
+func RegisterRoutes(router *gin.RouterGroup, handler *VariantHandler) {
+	variants := router.Group("/items/:id/variants")
+	{
+		variants.POST("", handler.Generate)
+		variants.GET("", handler.FindMatrix)
+		variants.GET("/stock-grid", handler.StockGrid)
+		variants.PUT("/:variant_id", handler.Update)
+		variants.DELETE("/:variant_id", handler.Delete)
+	}
+}
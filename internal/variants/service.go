@@ -0,0 +1,418 @@
+package variants
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"frdy-api/internal/barcodes"
+	"frdy-api/internal/items"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxVariants limita la mida de la matriu d'un model
+const maxVariants = 1000
+
+type VariantService interface {
+	// Generate desa els atributs del model i crea les variants que falten de
+	// la matriu. Les que ja existeixen no es toquen.
+	Generate(ctx context.Context, modelID string, request MatrixRequest) (Matrix, error)
+	FindMatrix(ctx context.Context, modelID string) (Matrix, error)
+	Update(ctx context.Context, modelID, variantID string, request VariantRequest) (Variant, error)
+	Delete(ctx context.Context, modelID, variantID string) error
+	// StockGrid retorna l'estoc del model amb un atribut a les files i un
+	// altre a les columnes; si no s'indiquen, els dos primers del model
+	StockGrid(ctx context.Context, modelID, rows, columns string) (StockGrid, error)
+}
+
+type variantService struct {
+	repo     VariantRepository
+	items    items.ItemRepository
+	barcodes barcodes.BarcodeService
+}
+
+func NewVariantService(repo VariantRepository, items items.ItemRepository, barcodes barcodes.BarcodeService) VariantService {
+	return &variantService{repo: repo, items: items, barcodes: barcodes}
+}
+
+func (s *variantService) Generate(ctx context.Context, modelID string, request MatrixRequest) (Matrix, error) {
+	model, err := s.findModel(modelID)
+	if err != nil {
+		return Matrix{}, err
+	}
+	if nested, err := s.repo.IsVariant(ctx, model.ID); err != nil {
+		return Matrix{}, err
+	} else if nested {
+		return Matrix{}, ErrNestedVariant
+	}
+
+	requested, err := normalizeAttributes(request.Attributes)
+	if err != nil {
+		return Matrix{}, err
+	}
+	current, err := s.repo.FindAttributes(ctx, model.ID)
+	if err != nil {
+		return Matrix{}, err
+	}
+	attributes, err := mergeAttributes(current, requested)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	combinations := combine(attributes)
+	if len(combinations) > maxVariants {
+		return Matrix{}, ErrTooManyVariants
+	}
+
+	existing, err := s.repo.FindVariants(ctx, model.ID)
+	if err != nil {
+		return Matrix{}, err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		have[combinationKey(attributes, v.Attributes)] = true
+	}
+
+	var created []Variant
+	codes := make(map[string]bool)
+	for _, combination := range combinations {
+		if have[combinationKey(attributes, combination)] {
+			continue
+		}
+		variant := newVariant(model, attributes, combination)
+		if codes[variant.Code] {
+			return Matrix{}, ErrCodeTaken
+		}
+		codes[variant.Code] = true
+		if err := s.checkCode(ctx, variant.Code, uuid.Nil); err != nil {
+			return Matrix{}, err
+		}
+		created = append(created, variant)
+	}
+
+	if err := s.repo.Generate(ctx, model.ID, attributes, created); err != nil {
+		return Matrix{}, err
+	}
+	return s.FindMatrix(ctx, modelID)
+}
+
+func (s *variantService) FindMatrix(ctx context.Context, modelID string) (Matrix, error) {
+	model, err := s.findModel(modelID)
+	if err != nil {
+		return Matrix{}, err
+	}
+	attributes, err := s.repo.FindAttributes(ctx, model.ID)
+	if err != nil {
+		return Matrix{}, err
+	}
+	variants, err := s.repo.FindVariants(ctx, model.ID)
+	if err != nil {
+		return Matrix{}, err
+	}
+	return Matrix{Model: model, Attributes: attributes, Variants: variants}, nil
+}
+
+func (s *variantService) Update(ctx context.Context, modelID, variantID string, request VariantRequest) (Variant, error) {
+	model, err := s.findModel(modelID)
+	if err != nil {
+		return Variant{}, err
+	}
+	id, err := uuid.Parse(variantID)
+	if err != nil {
+		return Variant{}, ErrInvalidID
+	}
+	variant, err := s.repo.FindVariant(ctx, model.ID, id)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	code := strings.TrimSpace(request.Code)
+	if code == "" {
+		return Variant{}, ErrInvalidRequest
+	}
+	if code != variant.Code {
+		if err := s.checkCode(ctx, code, variant.ID); err != nil {
+			return Variant{}, err
+		}
+	}
+	variant.Code = code
+	variant.PriceOverride = request.PriceOverride
+	variant.Price = model.Price
+	if request.PriceOverride != nil {
+		variant.Price = *request.PriceOverride
+	}
+	if request.IsActive != nil {
+		variant.IsActive = *request.IsActive
+	}
+
+	if err := s.repo.Update(ctx, variant); err != nil {
+		return Variant{}, err
+	}
+
+	if barcode := strings.TrimSpace(request.Barcode); barcode != "" {
+		if err := s.addBarcode(ctx, variant.ID, barcode); err != nil {
+			return Variant{}, err
+		}
+	}
+	return s.repo.FindVariant(ctx, model.ID, variant.ID)
+}
+
+func (s *variantService) Delete(ctx context.Context, modelID, variantID string) error {
+	model, err := uuid.Parse(modelID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	id, err := uuid.Parse(variantID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	return s.repo.Delete(ctx, model, id)
+}
+
+func (s *variantService) StockGrid(ctx context.Context, modelID, rows, columns string) (StockGrid, error) {
+	matrix, err := s.FindMatrix(ctx, modelID)
+	if err != nil {
+		return StockGrid{}, err
+	}
+	if len(matrix.Attributes) == 0 {
+		return StockGrid{}, ErrNoAttributes
+	}
+
+	rowAttr, colAttr := matrix.Attributes[0], Attribute{}
+	if len(matrix.Attributes) > 1 {
+		colAttr = matrix.Attributes[1]
+	}
+	if rows != "" {
+		if rowAttr, err = findAttribute(matrix.Attributes, rows); err != nil {
+			return StockGrid{}, err
+		}
+	}
+	if columns != "" {
+		if colAttr, err = findAttribute(matrix.Attributes, columns); err != nil {
+			return StockGrid{}, err
+		}
+	} else if colAttr.Name == rowAttr.Name {
+		colAttr = Attribute{}
+		for _, a := range matrix.Attributes {
+			if a.Name != rowAttr.Name {
+				colAttr = a
+				break
+			}
+		}
+	}
+	if colAttr.Name == rowAttr.Name {
+		return StockGrid{}, ErrInvalidRequest
+	}
+
+	// Sense segon atribut, la graella té una sola columna
+	colValues := colAttr.Values
+	if colAttr.Name == "" {
+		colValues = []string{""}
+	}
+
+	grid := StockGrid{
+		ModelID:         matrix.Model.ID,
+		RowAttribute:    rowAttr.Name,
+		ColumnAttribute: colAttr.Name,
+		Rows:            rowAttr.Values,
+		Columns:         colValues,
+		Quantities:      make([][]float64, len(rowAttr.Values)),
+		RowTotals:       make([]float64, len(rowAttr.Values)),
+		ColumnTotals:    make([]float64, len(colValues)),
+	}
+	for i := range grid.Quantities {
+		grid.Quantities[i] = make([]float64, len(colValues))
+	}
+
+	rowIndex := indexOf(rowAttr.Values)
+	colIndex := indexOf(colValues)
+	for _, v := range matrix.Variants {
+		i, ok := rowIndex[v.Attributes[rowAttr.Name]]
+		if !ok {
+			continue
+		}
+		j, ok := colIndex[v.Attributes[colAttr.Name]]
+		if !ok {
+			continue
+		}
+		grid.Quantities[i][j] += v.Stock
+		grid.RowTotals[i] += v.Stock
+		grid.ColumnTotals[j] += v.Stock
+		grid.Total += v.Stock
+	}
+	return grid, nil
+}
+
+func (s *variantService) findModel(modelID string) (items.Item, error) {
+	id, err := uuid.Parse(modelID)
+	if err != nil {
+		return items.Item{}, ErrInvalidID
+	}
+	model, err := s.items.FindByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return items.Item{}, ErrModelNotFound
+	}
+	return model, err
+}
+
+// checkCode comprova que el codi no és de cap altre article, ni com a codi
+// principal ni com a codi de barres o àlies
+func (s *variantService) checkCode(ctx context.Context, code string, itemID uuid.UUID) error {
+	match, err := s.barcodes.Lookup(ctx, code)
+	if errors.Is(err, barcodes.ErrCodeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if match.Item.ID != itemID {
+		return ErrCodeTaken
+	}
+	return nil
+}
+
+// addBarcode afegeix el codi de barres a la variant si encara no el té
+func (s *variantService) addBarcode(ctx context.Context, itemID uuid.UUID, barcode string) error {
+	match, err := s.barcodes.Lookup(ctx, barcode)
+	if err == nil {
+		if match.Item.ID == itemID {
+			return nil
+		}
+		return barcodes.ErrCodeTaken
+	}
+	if !errors.Is(err, barcodes.ErrCodeNotFound) {
+		return err
+	}
+	_, err = s.barcodes.Create(ctx, itemID.String(), barcodes.ItemCodeRequest{Code: barcode})
+	return err
+}
+
+// newVariant prepara l'article d'una combinació a partir del model: el codi i
+// la descripció porten els valors dels atributs i la resta s'hereta
+func newVariant(model items.Item, attributes []Attribute, combination map[string]string) Variant {
+	code := model.Code
+	values := make([]string, 0, len(attributes))
+	for _, a := range attributes {
+		value := combination[a.Name]
+		code += "-" + codePart(value)
+		values = append(values, value)
+	}
+
+	item := model
+	item.ID = uuid.New()
+	item.Code = code
+	item.Description = model.Description + " " + strings.Join(values, " / ")
+	item.IsActive = true
+	item.LastPurchaseCost = nil
+	return Variant{Item: item, ModelID: model.ID, Attributes: combination}
+}
+
+// codePart converteix un valor d'atribut en un tros de codi: majúscules i
+// sense espais
+func codePart(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
+
+// normalizeAttributes treu espais i comprova que no hi ha atributs ni valors
+// repetits ni buits
+func normalizeAttributes(requests []AttributeRequest) ([]Attribute, error) {
+	attributes := make([]Attribute, 0, len(requests))
+	names := make(map[string]bool)
+	for _, r := range requests {
+		name := strings.ToLower(strings.TrimSpace(r.Name))
+		if name == "" || names[name] {
+			return nil, ErrInvalidRequest
+		}
+		names[name] = true
+
+		attribute := Attribute{Name: name}
+		seen := make(map[string]bool)
+		for _, value := range r.Values {
+			value = strings.TrimSpace(value)
+			if value == "" || seen[codePart(value)] {
+				return nil, ErrInvalidRequest
+			}
+			seen[codePart(value)] = true
+			attribute.Values = append(attribute.Values, value)
+		}
+		attributes = append(attributes, attribute)
+	}
+	return attributes, nil
+}
+
+// mergeAttributes afegeix als atributs que ja té el model els valors nous de
+// la petició, mantenint l'ordre dels que ja hi eren
+func mergeAttributes(current, requested []Attribute) ([]Attribute, error) {
+	if len(current) == 0 {
+		return requested, nil
+	}
+	if len(current) != len(requested) {
+		return nil, ErrAttributesChanged
+	}
+	merged := make([]Attribute, len(current))
+	for i, a := range current {
+		if requested[i].Name != a.Name {
+			return nil, ErrAttributesChanged
+		}
+		merged[i] = Attribute{Name: a.Name, Values: append([]string{}, a.Values...)}
+		have := make(map[string]bool, len(a.Values))
+		for _, value := range a.Values {
+			have[codePart(value)] = true
+		}
+		for _, value := range requested[i].Values {
+			if !have[codePart(value)] {
+				merged[i].Values = append(merged[i].Values, value)
+			}
+		}
+	}
+	return merged, nil
+}
+
+// combine retorna totes les combinacions de valors dels atributs
+func combine(attributes []Attribute) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, a := range attributes {
+		next := make([]map[string]string, 0, len(combinations)*len(a.Values))
+		for _, c := range combinations {
+			for _, value := range a.Values {
+				combination := make(map[string]string, len(c)+1)
+				for k, v := range c {
+					combination[k] = v
+				}
+				combination[a.Name] = value
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// combinationKey identifica una combinació sense tenir en compte majúscules
+// ni espais, igual que els codis de les variants
+func combinationKey(attributes []Attribute, combination map[string]string) string {
+	parts := make([]string, len(attributes))
+	for i, a := range attributes {
+		parts[i] = codePart(combination[a.Name])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func findAttribute(attributes []Attribute, name string) (Attribute, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, a := range attributes {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return Attribute{}, ErrUnknownAttribute
+}
+
+func indexOf(values []string) map[string]int {
+	index := make(map[string]int, len(values))
+	for i, value := range values {
+		index[value] = i
+	}
+	return index
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"frdy-api/internal/identity"
 	"io"
 	"log"
+	"strings"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
@@ -13,6 +15,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// sensitiveFields no es guarden mai a les metadades del registre
+var sensitiveFields = []string{"password", "current_password", "new_password", "token", "challenge_token"}
+
+// authSensitiveFields només s'amaguen a les rutes d'autenticació i de segon
+// factor: a la resta "code" és el codi d'un article, un codi de barres...
+var authSensitiveFields = []string{"code"}
+
+// maxLoggedBody és la mida màxima del cos JSON que es copia al registre
+const maxLoggedBody = 64 << 10
+
 type ActionLogMiddleware struct {
 	db *sql.DB
 }
@@ -35,33 +47,23 @@ func (alm *ActionLogMiddleware) LogAction() gin.HandlerFunc {
 		// Inicialitzar metadata
 		metadata := "{}"
 
-		// Llegir i reconstruir body
-		bodyBytes, err := io.ReadAll(c.Request.Body)
-		if err == nil && len(bodyBytes) > 0 {
-			// Tornar a posar el body perquè altres handlers puguin llegir-lo
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-
-			
+		// Només es llegeixen els cossos JSON, i fins a maxLoggedBody: la resta
+		// (p. ex. fitxers pujats) es deixa intacta per al handler
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
 			if c.ContentType() == "application/json" {
-				
-				var jsonBody map[string]interface{}
-				if err := json.Unmarshal(bodyBytes, &jsonBody); err == nil {					
-					delete(jsonBody, "password")					
-					modifiedBodyBytes, err := json.Marshal(jsonBody)
-					if err == nil {
-						metadata = string(modifiedBodyBytes)
-					} else {
-						metadata = `{"note": "error marshalling JSON"}`
-					}
-				} else {
-					metadata = `{"note": "invalid JSON body"}`
+				bodyBytes, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody+1))
+				// Tornar a posar el body perquè altres handlers puguin llegir-lo
+				c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(bodyBytes), c.Request.Body), c.Request.Body}
+				if err != nil {
+					metadata = `{"note": "error reading body"}`
+				} else if len(bodyBytes) > maxLoggedBody {
+					metadata = `{"note": "body too large to log"}`
+				} else if len(bodyBytes) > 0 {
+					metadata = jsonMetadata(bodyBytes, sensitiveFieldsFor(c.Request.URL.Path))
 				}
 			} else {
 				metadata = `{"note": "non-JSON content type"}`
 			}
-		} else {
-			// Si error llegint body, o està buit
-			c.Request.Body = io.NopCloser(bytes.NewBuffer([]byte{}))
 		}
 
 		// Transformar user_id
@@ -73,8 +75,22 @@ func (alm *ActionLogMiddleware) LogAction() gin.HandlerFunc {
 			}
 		}
 
+		// Si algú suplanta l'usuari, queda registrat qui és
+		var impersonatorID uuid.NullUUID
+		if id, ok := identity.ClaimImpersonator(claims); ok {
+			impersonatorID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		// Les peticions amb clau d'API no tenen usuari: queda registrada la clau
+		var apiKeyID uuid.NullUUID
+		if id, ok := claims["api_key_id"].(string); ok {
+			if parsed, err := uuid.Parse(id); err == nil {
+				apiKeyID = uuid.NullUUID{UUID: parsed, Valid: true}
+			}
+		}
+
 		// Guardar log
-		if err := alm.SaveActionLog(userUUID, actionType, metadata, timezone, performedAt); err != nil {
+		if err := alm.saveActionLog(userUUID, impersonatorID, apiKeyID, actionType, metadata, timezone, performedAt); err != nil {
 			// Potser vols fer un log aquí
 			log.Printf("Error saving action log: %v", err)
 		}
@@ -83,10 +99,42 @@ func (alm *ActionLogMiddleware) LogAction() gin.HandlerFunc {
 	}
 }
 
+// readCloser llegeix del cos reconstruït però tanca el cos original
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
 
-func (alm *ActionLogMiddleware) SaveActionLog(userID uuid.UUID, actionType, metadata, timezone string, performedAt time.Time) error {
-		query := `INSERT INTO action_logs (user_id, action_type, metadata, timezone, performed_at) 
-				VALUES ($1, $2, $3::jsonb, $4, $5)`
-		_, err := alm.db.Exec(query, userID, actionType, metadata, timezone, performedAt)
-		return err
+func sensitiveFieldsFor(path string) []string {
+	if strings.HasPrefix(path, "/auth/") || strings.HasPrefix(path, "/api/mfa") {
+		return append(append([]string{}, sensitiveFields...), authSensitiveFields...)
 	}
+	return sensitiveFields
+}
+
+// jsonMetadata treu els camps sensibles del cos JSON de la petició
+func jsonMetadata(body []byte, fields []string) string {
+	var jsonBody map[string]interface{}
+	if err := json.Unmarshal(body, &jsonBody); err != nil {
+		return `{"note": "invalid JSON body"}`
+	}
+	for _, field := range fields {
+		delete(jsonBody, field)
+	}
+	modifiedBodyBytes, err := json.Marshal(jsonBody)
+	if err != nil {
+		return `{"note": "error marshalling JSON"}`
+	}
+	return string(modifiedBodyBytes)
+}
+
+func (alm *ActionLogMiddleware) SaveActionLog(userID uuid.UUID, actionType, metadata, timezone string, performedAt time.Time) error {
+	return alm.saveActionLog(userID, uuid.NullUUID{}, uuid.NullUUID{}, actionType, metadata, timezone, performedAt)
+}
+
+func (alm *ActionLogMiddleware) saveActionLog(userID uuid.UUID, impersonatorID, apiKeyID uuid.NullUUID, actionType, metadata, timezone string, performedAt time.Time) error {
+	query := `INSERT INTO action_logs (user_id, impersonator_id, api_key_id, action_type, metadata, timezone, performed_at)
+			VALUES ($1, $2, $3, $4, $5::jsonb, $6, $7)`
+	_, err := alm.db.Exec(query, userID, impersonatorID, apiKeyID, actionType, metadata, timezone, performedAt)
+	return err
+}
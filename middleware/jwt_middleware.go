@@ -2,21 +2,28 @@
 package middleware
 
 import (
-	"frdy-api/config"
+	"frdy-api/internal/jwtkeys"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupJWT(cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
+func SetupJWT(keys *jwtkeys.KeySet, policy *AccessPolicy) (*jwt.GinJWTMiddleware, error) {
     return jwt.New(&jwt.GinJWTMiddleware{
         Realm:       "frdy-api",
-        Key:         []byte(cfg.JWTSecret),
+        // Els tokens els signa jwtkeys.Issuer; aquí només es verifiquen, amb
+        // la clau que correspon al kid de la capçalera
+        SigningAlgorithm: keys.Algorithm(),
+        KeyFunc:     keys.Keyfunc,
         Timeout:     time.Hour * 8,
         MaxRefresh:  time.Hour * 24,
         IdentityKey: "id",
         PayloadFunc: func(data interface{}) jwt.MapClaims {
+            // El servei d'autenticació passa les claims ja construïdes (id, rol i permisos)
+            if v, ok := data.(jwt.MapClaims); ok {
+                return v
+            }
             if v, ok := data.(string); ok {
                 return jwt.MapClaims{
                     "id": v,
@@ -39,9 +46,11 @@ func SetupJWT(cfg *config.Config) (*jwt.GinJWTMiddleware, error) {
         },
         // Verificar si l'usuari té accés a una ruta específica
         Authorizator: func(data interface{}, c *gin.Context) bool {
-            // Aquí pots implementar una lògica més complexa de verificació de permisos
-            // Per exemple, verificar el rol de l'usuari per a rutes específiques
-            return data != nil
+            // Els permisos del rol viatgen a les claims i es comparen amb la política de rutes
+            if data == nil {
+                return false
+            }
+            return policy.Authorize(jwt.ExtractClaims(c), c.Request.Method, c.Request.URL.Path)
         },
         Unauthorized: func(c *gin.Context, code int, message string) {
             c.JSON(code, gin.H{
@@ -0,0 +1,35 @@
+// middleware/revocation_middleware.go
+package middleware
+
+import (
+	"frdy-api/internal/revocation"
+	"log"
+	"net/http"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// RejectRevoked rebutja els tokens revocats (logout, canvi de contrasenya o
+// usuari eliminat). S'ha d'afegir després del middleware JWT.
+func RejectRevoked(store revocation.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revoked, err := revocation.IsClaimsRevoked(c.Request.Context(), store, jwt.ExtractClaims(c))
+		if err != nil {
+			log.Printf("Error checking token revocation: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"code":    http.StatusInternalServerError,
+				"message": "could not verify token",
+			})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    http.StatusUnauthorized,
+				"message": "token has been revoked",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,23 @@
+// middleware/session_middleware.go
+package middleware
+
+import (
+	"frdy-api/internal/identity"
+	"frdy-api/internal/sessions"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrackSession actualitza l'últim ús de la sessió del token. S'ha d'afegir
+// després del middleware JWT.
+func TrackSession(service sessions.SessionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if family := identity.TokenFamily(c); family != "" {
+			if err := service.Touch(c.Request.Context(), family); err != nil {
+				log.Printf("Error updating session last use: %v", err)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,81 @@
+-- Rols i permisos (control d'accés per rols)
+
+CREATE TABLE IF NOT EXISTS permissions (
+    code        varchar(64) PRIMARY KEY,
+    description text
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          uuid PRIMARY KEY,
+    name        varchar(64) NOT NULL UNIQUE,
+    description text,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id         uuid NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_code varchar(64) NOT NULL REFERENCES permissions(code) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_code)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role_id uuid REFERENCES roles(id);
+
+INSERT INTO permissions (code, description) VALUES
+    ('*',                 'All permissions'),
+    ('items:read',        'List and read items'),
+    ('items:write',       'Create, update and delete items'),
+    ('stock:read',        'Read stock levels'),
+    ('stock:write',       'Adjust stock quantities'),
+    ('sales:read',        'List and read sales'),
+    ('sales:write',       'Create, update and delete sales'),
+    ('sales:send',        'Send sales and take stock out'),
+    ('purchases:read',    'List and read purchases'),
+    ('purchases:write',   'Create, update and delete purchases'),
+    ('purchases:receive', 'Receive purchases into stock'),
+    ('users:manage',      'Manage users'),
+    ('roles:manage',      'Manage roles and permissions')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO roles (id, name, description) VALUES
+    (gen_random_uuid(), 'admin',       'Full access'),
+    (gen_random_uuid(), 'manager',     'Manages items, sales and purchases'),
+    (gen_random_uuid(), 'sales_clerk', 'Creates and sends sales'),
+    (gen_random_uuid(), 'warehouse',   'Adjusts stock and receives purchases'),
+    (gen_random_uuid(), 'read_only',   'Read-only access')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, p.code
+FROM roles r
+    JOIN (VALUES
+        ('admin',       '*'),
+        ('manager',     'items:read'),
+        ('manager',     'items:write'),
+        ('manager',     'stock:read'),
+        ('manager',     'sales:read'),
+        ('manager',     'sales:write'),
+        ('manager',     'sales:send'),
+        ('manager',     'purchases:read'),
+        ('manager',     'purchases:write'),
+        ('sales_clerk', 'items:read'),
+        ('sales_clerk', 'stock:read'),
+        ('sales_clerk', 'sales:read'),
+        ('sales_clerk', 'sales:write'),
+        ('sales_clerk', 'sales:send'),
+        ('warehouse',   'items:read'),
+        ('warehouse',   'stock:read'),
+        ('warehouse',   'stock:write'),
+        ('warehouse',   'purchases:read'),
+        ('warehouse',   'purchases:write'),
+        ('warehouse',   'purchases:receive'),
+        ('read_only',   'items:read'),
+        ('read_only',   'stock:read'),
+        ('read_only',   'sales:read'),
+        ('read_only',   'purchases:read')
+    ) AS p(role_name, code) ON p.role_name = r.name
+ON CONFLICT DO NOTHING;
+
+-- Els usuaris existents passen a només lectura. Per promoure el primer
+-- administrador:
+--   UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'admin') WHERE username = '<usuari>';
+UPDATE users SET role_id = (SELECT id FROM roles WHERE name = 'read_only') WHERE role_id IS NULL;
@@ -0,0 +1,16 @@
+-- Revocació de tokens JWT (logout, canvi de contrasenya, usuari eliminat)
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        varchar(64) PRIMARY KEY,
+    user_id    uuid NOT NULL,
+    revoked_at timestamptz NOT NULL DEFAULT now(),
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+-- Tots els tokens d'un usuari amb auth_time anterior a revoked_before són invàlids
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    user_id        uuid PRIMARY KEY,
+    revoked_before timestamptz NOT NULL
+);
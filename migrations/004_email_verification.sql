@@ -0,0 +1,9 @@
+-- Verificació de l'email dels comptes nous
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+
+-- Els comptes existents (actius o desactivats) es consideren verificats, perquè
+-- no es confonguin amb comptes pendents i s'eliminin
+UPDATE users SET email_verified_at = COALESCE(created_at, now()) WHERE email_verified_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_unverified ON users (created_at) WHERE email_verified_at IS NULL;
@@ -0,0 +1,11 @@
+-- Comptadors d'intents de login fallits i bloquejos per usuari i per IP
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope            varchar(8) NOT NULL CHECK (scope IN ('user', 'ip')),
+    key              varchar(255) NOT NULL,
+    failures         integer NOT NULL DEFAULT 0,
+    first_failure_at timestamptz NOT NULL DEFAULT now(),
+    locked_until     timestamptz,
+    updated_at       timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (scope, key)
+);
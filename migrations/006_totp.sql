@@ -0,0 +1,8 @@
+-- Segon factor d'autenticació (TOTP) i codis de recuperació
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret text;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled boolean NOT NULL DEFAULT false;
+-- Hash SHA-256 dels codis de recuperació que encara no s'han fet servir
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_recovery_codes text[];
+-- Últim pas TOTP acceptat, per no acceptar el mateix codi dues vegades
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint NOT NULL DEFAULT 0;
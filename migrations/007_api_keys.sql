@@ -0,0 +1,20 @@
+-- Claus d'API per a integracions (botiga en línia, lectors del magatzem...)
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           uuid PRIMARY KEY,
+    name         varchar(100) NOT NULL,
+    -- Part pública de la clau (frdy_<prefix>_<secret>), per trobar-la
+    prefix       varchar(16) NOT NULL UNIQUE,
+    -- SHA-256 de la clau completa; la clau en clar no es guarda
+    key_hash     char(64) NOT NULL,
+    permissions  text[] NOT NULL DEFAULT '{}',
+    created_by   uuid NOT NULL REFERENCES users(id),
+    expires_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz,
+    created_at   timestamptz NOT NULL DEFAULT now()
+);
+
+INSERT INTO permissions (code, description) VALUES
+    ('apikeys:manage', 'Issue and revoke API keys')
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,18 @@
+-- Invitacions per crear comptes amb un rol assignat. Només es guarda el hash
+-- del token que s'envia per email.
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id          uuid PRIMARY KEY,
+    email       varchar(255) NOT NULL,
+    role_id     uuid NOT NULL REFERENCES roles(id),
+    invited_by  uuid NOT NULL REFERENCES users(id),
+    token_hash  varchar(64) NOT NULL UNIQUE,
+    expires_at  timestamptz NOT NULL,
+    accepted_at timestamptz,
+    -- Usuari creat en acceptar la invitació
+    user_id     uuid REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at  timestamptz,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_email ON invitations (lower(email));
@@ -0,0 +1,16 @@
+-- Sessions (login per dispositiu). token_family és el jti dels tokens, que
+-- es manté quan es refresquen.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id           uuid PRIMARY KEY,
+    user_id      uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_family varchar(64) NOT NULL UNIQUE,
+    user_agent   text NOT NULL DEFAULT '',
+    ip           varchar(64) NOT NULL DEFAULT '',
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    last_seen_at timestamptz NOT NULL DEFAULT now(),
+    expires_at   timestamptz NOT NULL,
+    revoked_at   timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,22 @@
+-- Login amb OpenID Connect
+
+-- Peticions d'autorització en curs (state, verificador PKCE i nonce)
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state_hash    varchar(64) PRIMARY KEY,
+    code_verifier text NOT NULL,
+    nonce         text NOT NULL,
+    expires_at    timestamptz NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+-- Identitats del proveïdor vinculades a usuaris
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer     text NOT NULL,
+    subject    text NOT NULL,
+    user_id    uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email      varchar(255) NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
@@ -0,0 +1,6 @@
+-- Perfil de l'usuari: preferències i canvi d'email pendent de confirmar
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences jsonb NOT NULL DEFAULT '{}';
+
+-- El nou email no substitueix l'actual fins que es confirma l'enllaç que s'hi envia
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email varchar(255);
@@ -0,0 +1,2 @@
+-- Els hashos Argon2id (format PHC) són més llargs que els de bcrypt
+ALTER TABLE users ALTER COLUMN password TYPE text;
@@ -0,0 +1,10 @@
+-- Historial de contrasenyes, per no deixar reutilitzar les últimes
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id            bigserial PRIMARY KEY,
+    user_id       uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash text NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id ON password_history (user_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Suplantació d'usuaris per part de l'equip de suport
+
+INSERT INTO permissions (code, description) VALUES
+    ('users:impersonate', 'Act as another user to reproduce what they see')
+ON CONFLICT (code) DO NOTHING;
+
+-- Les accions fetes amb un token de suplantació guarden qui la fa
+ALTER TABLE action_logs ADD COLUMN IF NOT EXISTS impersonator_id uuid;
+
+CREATE INDEX IF NOT EXISTS idx_action_logs_impersonator_id ON action_logs (impersonator_id) WHERE impersonator_id IS NOT NULL;
@@ -0,0 +1,7 @@
+-- Exportació i anonimització de dades personals (RGPD)
+
+INSERT INTO permissions (code, description) VALUES
+    ('privacy:manage', 'Export and anonymize personal data of users and customers')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at timestamptz;
@@ -0,0 +1,21 @@
+-- Categories d'articles en un arbre sense límit de nivells
+
+CREATE TABLE IF NOT EXISTS categories (
+    id          uuid PRIMARY KEY,
+    name        varchar(100) NOT NULL,
+    description text,
+    parent_id   uuid REFERENCES categories(id),
+    created_at  timestamptz NOT NULL DEFAULT now(),
+    updated_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+-- Dues germanes no poden tenir el mateix nom
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_parent_name
+    ON categories (COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), lower(name));
+
+-- En esborrar una categoria, els seus articles queden sense categoria
+ALTER TABLE items ADD COLUMN IF NOT EXISTS category_id uuid REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_items_category_id ON items (category_id);
@@ -0,0 +1,50 @@
+-- Unitats de mesura, unitat base per article i factors de conversió.
+-- Les quantitats passen a ser decimals per poder vendre a pes.
+
+CREATE TABLE IF NOT EXISTS units (
+    code           varchar(20) PRIMARY KEY,
+    name           varchar(100) NOT NULL,
+    allow_decimals boolean NOT NULL DEFAULT false
+);
+
+INSERT INTO units (code, name, allow_decimals) VALUES
+    ('UNIT', 'Unit', false),
+    ('BOX', 'Box', false),
+    ('PACK', 'Pack', false),
+    ('KG', 'Kilogram', true),
+    ('G', 'Gram', true),
+    ('L', 'Litre', true),
+    ('M', 'Metre', true)
+ON CONFLICT (code) DO NOTHING;
+
+-- L'estoc de cada article es compta en la seva unitat base
+ALTER TABLE items ADD COLUMN IF NOT EXISTS base_unit varchar(20) NOT NULL DEFAULT 'UNIT'
+    REFERENCES units(code) ON UPDATE CASCADE;
+
+-- Quantes unitats base hi ha en cada altra unitat de l'article (1 BOX = 12 UNIT)
+CREATE TABLE IF NOT EXISTS item_units (
+    item_id   uuid NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    unit_code varchar(20) NOT NULL REFERENCES units(code) ON UPDATE CASCADE,
+    factor    numeric(18,6) NOT NULL CHECK (factor > 0),
+    PRIMARY KEY (item_id, unit_code)
+);
+
+ALTER TABLE stocks ALTER COLUMN quantity TYPE numeric(14,3);
+
+-- Les línies guarden la quantitat en la unitat indicada i en unitats base;
+-- les existents eren en unitats base
+ALTER TABLE sales_details ALTER COLUMN quantity TYPE numeric(14,3);
+ALTER TABLE sales_details ADD COLUMN IF NOT EXISTS unit varchar(20) REFERENCES units(code) ON UPDATE CASCADE;
+ALTER TABLE sales_details ADD COLUMN IF NOT EXISTS base_quantity numeric(14,3);
+UPDATE sales_details sd SET unit = i.base_unit, base_quantity = sd.quantity
+FROM items i WHERE i.id = sd.item_id AND sd.base_quantity IS NULL;
+ALTER TABLE sales_details ALTER COLUMN unit SET NOT NULL;
+ALTER TABLE sales_details ALTER COLUMN base_quantity SET NOT NULL;
+
+ALTER TABLE purchase_details ALTER COLUMN quantity TYPE numeric(14,3);
+ALTER TABLE purchase_details ADD COLUMN IF NOT EXISTS unit varchar(20) REFERENCES units(code) ON UPDATE CASCADE;
+ALTER TABLE purchase_details ADD COLUMN IF NOT EXISTS base_quantity numeric(14,3);
+UPDATE purchase_details pd SET unit = i.base_unit, base_quantity = pd.quantity
+FROM items i WHERE i.id = pd.item_id AND pd.base_quantity IS NULL;
+ALTER TABLE purchase_details ALTER COLUMN unit SET NOT NULL;
+ALTER TABLE purchase_details ALTER COLUMN base_quantity SET NOT NULL;
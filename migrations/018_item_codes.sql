@@ -0,0 +1,17 @@
+-- Codis de barres i codis alternatius dels articles (EAN, UPC, codis de
+-- proveïdor i àlies interns)
+
+CREATE TABLE IF NOT EXISTS item_codes (
+    id            uuid PRIMARY KEY,
+    item_id       uuid NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    code          varchar(64) NOT NULL UNIQUE,
+    kind          varchar(20) NOT NULL,
+    -- GTIN normalitzat a 14 dígits, per trobar un UPC llegit com a EAN-13
+    gtin          char(14) UNIQUE,
+    supplier_name varchar(255),
+    -- Unitat que representa el codi (p. ex. la caixa); buit vol dir la unitat base
+    unit          varchar(20) REFERENCES units(code) ON UPDATE CASCADE,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_codes_item_id ON item_codes (item_id);
@@ -0,0 +1,59 @@
+-- Tarifes de preus per grup de clients, amb vigència i preus per quantitat
+
+INSERT INTO permissions (code, description) VALUES
+    ('prices:read',  'View price lists and customer groups'),
+    ('prices:write', 'Manage price lists and customer groups')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, p.code
+FROM roles r
+    JOIN (VALUES
+        ('manager',     'prices:read'),
+        ('manager',     'prices:write'),
+        ('sales_clerk', 'prices:read'),
+        ('read_only',   'prices:read')
+    ) AS p(role_name, code) ON p.role_name = r.name
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS customer_groups (
+    id          uuid PRIMARY KEY,
+    name        varchar(100) NOT NULL,
+    description text,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_groups_name ON customer_groups (lower(name));
+
+CREATE TABLE IF NOT EXISTS price_lists (
+    id                uuid PRIMARY KEY,
+    name              varchar(100) NOT NULL,
+    description       text,
+    -- Sense grup, la tarifa s'aplica a tots els clients
+    customer_group_id uuid REFERENCES customer_groups(id),
+    priority          integer NOT NULL DEFAULT 0,
+    is_active         boolean NOT NULL DEFAULT true,
+    created_at        timestamptz NOT NULL DEFAULT now(),
+    updated_at        timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_lists_name ON price_lists (lower(name));
+
+-- Preu per unitat base a partir d'una quantitat mínima, opcionalment limitat
+-- a un període (dates incloses)
+CREATE TABLE IF NOT EXISTS price_list_items (
+    id            uuid PRIMARY KEY,
+    price_list_id uuid NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    item_id       uuid NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    min_quantity  numeric(14,3) NOT NULL DEFAULT 0,
+    price         numeric(12,4) NOT NULL CHECK (price > 0),
+    valid_from    date,
+    valid_to      date,
+    CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_item_id ON price_list_items (item_id);
+CREATE INDEX IF NOT EXISTS idx_price_list_items_price_list_id ON price_list_items (price_list_id);
+
+-- Grup de clients de la venda, per triar la tarifa de les línies sense preu
+ALTER TABLE sales_headers ADD COLUMN IF NOT EXISTS customer_group_id uuid REFERENCES customer_groups(id);
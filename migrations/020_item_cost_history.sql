@@ -0,0 +1,23 @@
+-- Cost mitjà ponderat dels articles, recalculat a cada recepció de compra
+
+-- La mitjana no és un import: es guarda amb 4 decimals per no perdre precisió
+-- d'una recepció a l'altra
+ALTER TABLE items ALTER COLUMN cost TYPE numeric(14,4);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS last_purchase_cost numeric(14,4);
+
+-- Cada línia de compra rebuda deixa el cost que tenia l'article i el que li
+-- queda. Quantitats i costos en la unitat base de l'article.
+CREATE TABLE IF NOT EXISTS item_cost_history (
+    id                 uuid PRIMARY KEY,
+    item_id            uuid NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    purchase_header_id uuid NOT NULL REFERENCES purchase_headers(id) ON DELETE CASCADE,
+    purchase_detail_id uuid NOT NULL,
+    quantity           numeric(14,3) NOT NULL,
+    unit_cost          numeric(14,4) NOT NULL,
+    stock_before       numeric(14,3) NOT NULL,
+    cost_before        numeric(14,4) NOT NULL,
+    cost_after         numeric(14,4) NOT NULL,
+    created_at         timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_cost_history_item_id ON item_cost_history (item_id, created_at DESC);
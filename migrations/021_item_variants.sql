@@ -0,0 +1,23 @@
+-- Variants d'articles (talla, color...): un article model i un article fill
+-- per cada combinació de valors dels seus atributs
+
+-- Atributs de cada model amb els valors en l'ordre en què es mostren
+CREATE TABLE IF NOT EXISTS item_model_attributes (
+    model_id uuid NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    name     varchar(50) NOT NULL,
+    "values" text[] NOT NULL,
+    PRIMARY KEY (model_id, name)
+);
+
+-- Cada variant és un article amb codi, codis de barres, preu i estoc propis.
+-- No es pot esborrar un model que encara té variants.
+CREATE TABLE IF NOT EXISTS item_variants (
+    item_id        uuid PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+    model_id       uuid NOT NULL REFERENCES items(id),
+    -- Valor de cada atribut, p. ex. {"size": "M", "colour": "Red"}
+    attributes     jsonb NOT NULL,
+    -- Nul si la variant té el preu del model
+    price_override numeric(12,2),
+    UNIQUE (model_id, attributes)
+);
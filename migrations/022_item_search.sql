@@ -0,0 +1,14 @@
+-- Cerca, filtres i ordenació de la llista d'articles
+
+-- Els índexs de trigrames fan servir ILIKE '%text%' i l'operador de
+-- semblança (%) sense recórrer tota la taula
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_items_code_trgm ON items USING gin (code gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_items_description_trgm ON items USING gin (description gin_trgm_ops);
+
+-- Ordenació i paginació per cursor: la columna i l'id per desfer empats
+CREATE INDEX IF NOT EXISTS idx_items_code_id ON items (code, id);
+CREATE INDEX IF NOT EXISTS idx_items_description_id ON items (description, id);
+CREATE INDEX IF NOT EXISTS idx_items_price_id ON items (price, id);
+CREATE INDEX IF NOT EXISTS idx_items_cost_id ON items (cost, id);
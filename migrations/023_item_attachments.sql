@@ -0,0 +1,18 @@
+-- Imatges i documents adjunts als articles. El contingut és a
+-- l'emmagatzematge configurat (disc local o S3); aquí només les metadades.
+CREATE TABLE IF NOT EXISTS item_attachments (
+    id                     uuid PRIMARY KEY,
+    item_id                uuid NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    -- 'image' (surt a les respostes dels articles) o 'document'
+    kind                   varchar(20) NOT NULL CHECK (kind IN ('image', 'document')),
+    file_name              varchar(255) NOT NULL,
+    content_type           varchar(255) NOT NULL,
+    size                   bigint NOT NULL,
+    storage_key            text NOT NULL UNIQUE,
+    -- Nuls si la imatge no té miniatura (documents, WebP...)
+    thumbnail_key          text,
+    thumbnail_content_type varchar(255),
+    created_at             timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_attachments_item ON item_attachments (item_id, created_at);
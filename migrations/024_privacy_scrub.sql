@@ -0,0 +1,23 @@
+-- Anonimització de les metadades dels registres d'accions (RGPD)
+
+-- scrub_personal_values substitueix per replacement els valors de text de
+-- les claus keys que coincideixen (sense distingir majúscules) amb algun de
+-- vals, a qualsevol nivell d'objectes i arrays. La resta no es modifica.
+CREATE OR REPLACE FUNCTION scrub_personal_values(doc jsonb, keys text[], vals text[], replacement text)
+RETURNS jsonb LANGUAGE plpgsql IMMUTABLE AS $$
+BEGIN
+    CASE jsonb_typeof(doc)
+    WHEN 'object' THEN
+        RETURN (SELECT COALESCE(jsonb_object_agg(e.key,
+                CASE WHEN e.key = ANY(keys) AND jsonb_typeof(e.value) = 'string' AND lower(e.value #>> '{}') = ANY(vals)
+                    THEN to_jsonb(replacement)
+                    ELSE scrub_personal_values(e.value, keys, vals, replacement) END), '{}'::jsonb)
+            FROM jsonb_each(doc) e);
+    WHEN 'array' THEN
+        RETURN (SELECT COALESCE(jsonb_agg(scrub_personal_values(a.value, keys, vals, replacement) ORDER BY a.n), '[]'::jsonb)
+            FROM jsonb_array_elements(doc) WITH ORDINALITY a(value, n));
+    ELSE
+        RETURN doc;
+    END CASE;
+END
+$$;
@@ -0,0 +1,4 @@
+-- Un email només pot ser d'un usuari (sense distingir majúscules). Si ja hi
+-- ha duplicats, s'han de resoldre abans d'aplicar aquesta migració.
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_unique ON users (lower(email));
@@ -0,0 +1,6 @@
+-- Les accions fetes amb una clau d'API (botiga en línia, escàners) no tenen
+-- usuari: es guarda quina clau les ha fet
+
+ALTER TABLE action_logs ADD COLUMN IF NOT EXISTS api_key_id uuid;
+
+CREATE INDEX IF NOT EXISTS idx_action_logs_api_key_id ON action_logs (api_key_id) WHERE api_key_id IS NOT NULL;
@@ -1,15 +1,42 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"frdy-api/config"
+	"frdy-api/internal/apikeys"
+	"frdy-api/internal/attachments"
 	"frdy-api/internal/auth"
+	"frdy-api/internal/barcodes"
+	"frdy-api/internal/categories"
+	"frdy-api/internal/invitations"
+	"frdy-api/internal/impersonation"
 	"frdy-api/internal/items"
+	"frdy-api/internal/jwtkeys"
+	"frdy-api/internal/lockout"
+	"frdy-api/internal/mfa"
+	"frdy-api/internal/notify"
+	"frdy-api/internal/oidc"
+	"frdy-api/internal/password"
+	"frdy-api/internal/passwordreset"
+	"frdy-api/internal/pricelists"
+	"frdy-api/internal/privacy"
 	"frdy-api/internal/purchases"
+	"frdy-api/internal/revocation"
+	"frdy-api/internal/roles"
 	"frdy-api/internal/sales"
+	"frdy-api/internal/sessions"
+	"frdy-api/internal/storage"
 	"frdy-api/internal/stock"
+	"frdy-api/internal/units"
 	"frdy-api/internal/users"
+	"frdy-api/internal/variants"
 	"frdy-api/middleware"
+	"log"
+	"net/http"
+	"time"
 
 	_ "frdy-api/docs"
 
@@ -49,66 +76,232 @@ func NewServer(cfg *config.Config, db *sql.DB) *Server {
 	}
 }
 
+// minJWTSecretLength és la mida mínima de JWT_SECRET (256 bits, com la
+// sortida d'HMAC-SHA256)
+const minJWTSecretLength = 32
+
 func (s *Server) Setup() error {
+	// L'IP del client s'usa per limitar els intents de login i al registre
+	// de sessions: només es llegeix X-Forwarded-For dels proxies de confiança
+	if err := s.router.SetTrustedProxies(s.cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
 	// CORS middleware
 	s.router.Use(middleware.SetupCORS())
 	
 
-	// JWT middleware
-	authMiddleware, err := middleware.SetupJWT(s.cfg)
+	// Sense valor per defecte: amb un secret conegut qualsevol podria
+	// falsificar els enllaços de verificació i els reptes de segon factor
+	if s.cfg.TokenSigningSecret == "" || s.cfg.TokenSigningSecret == s.cfg.JWTSecret {
+		return errors.New("TOKEN_SIGNING_SECRET must be set and different from JWT_SECRET")
+	}
+
+	// Claus de signatura i JWT middleware
+	jwtKeys, err := jwtkeys.Load(s.cfg.JWTAlgorithm, []byte(s.cfg.JWTSecret), s.cfg.JWTPrivateKeyFile, s.cfg.JWTVerificationKeyFiles)
 	if err != nil {
 		return err
 	}
+	if jwtKeys.Algorithm() == jwtkeys.AlgHS256 {
+		// Els tokens porten els permisos: amb un secret buit, curt o conegut
+		// qualsevol en podria falsificar un d'administrador
+		if len(s.cfg.JWTSecret) < minJWTSecretLength {
+			return fmt.Errorf("JWT_SECRET must be at least %d bytes when JWT_ALGORITHM is HS256", minJWTSecretLength)
+		}
+		log.Println("Warning: JWTs are signed with the shared JWT_SECRET; set JWT_ALGORITHM=RS256 or EdDSA to publish verification keys")
+	}
+	policy := accessPolicy(s.cfg.MFARequiredPermissions)
+	authMiddleware, err := middleware.SetupJWT(jwtKeys, policy)
+	if err != nil {
+		return err
+	}
+	tokenIssuer := jwtkeys.NewIssuer(jwtKeys, authMiddleware.Timeout, authMiddleware.MaxRefresh)
 
 	// Action log middleware
-	//actionLogMiddleware := middleware.NewActionLogMiddleware(s.db)
+	actionLogMiddleware := middleware.NewActionLogMiddleware(s.db)
 	
 	// Inicialitzar repositoris
 	userRepo := users.NewUserRepository(s.db)
-	itemRepo := items.NewItemRepository(s.db)
+	roleRepo := roles.NewRoleRepository(s.db)
+	revocationRepo := revocation.NewRevocationRepository(s.db)
+	resetRepo := passwordreset.NewResetRepository(s.db)
+	lockoutRepo := lockout.NewLockoutRepository(s.db)
+	apiKeyRepo := apikeys.NewAPIKeyRepository(s.db)
+	invitationRepo := invitations.NewInvitationRepository(s.db)
+	sessionRepo := sessions.NewSessionRepository(s.db)
+	oidcRepo := oidc.NewOIDCRepository(s.db)
+	privacyRepo := privacy.NewPrivacyRepository(s.db)
+	variantRepo := variants.NewVariantRepository(s.db)
+	itemRepo := items.NewItemRepository(s.db, variantRepo)
+	categoryRepo := categories.NewCategoryRepository(s.db)
+	unitRepo := units.NewUnitRepository(s.db)
+	barcodeRepo := barcodes.NewBarcodeRepository(s.db)
+	priceListRepo := pricelists.NewPriceListRepository(s.db)
+	attachmentRepo := attachments.NewAttachmentRepository(s.db)
 	salesRepo := sales.NewSalesRepository(s.db)
 	stockRepo := stock.NewStockRepository(s.db)
 	purchaseRepo := purchases.NewPurchaseRepository(s.db)
 
 
 
+	notifier, err := notify.New(s.cfg.Notifier, s.cfg.NotifierFile)
+	if err != nil {
+		return err
+	}
+	fileStorage, err := storage.New(s.cfg.Storage, s.cfg.StorageLocalDir, storage.S3Config{
+		Endpoint:  s.cfg.S3Endpoint,
+		Region:    s.cfg.S3Region,
+		Bucket:    s.cfg.S3Bucket,
+		AccessKey: s.cfg.S3AccessKey,
+		SecretKey: s.cfg.S3SecretKey,
+		PathStyle: s.cfg.S3PathStyle,
+	})
+	if err != nil {
+		return err
+	}
+	registrationMode, err := users.ParseRegistrationMode(s.cfg.RegistrationMode)
+	if err != nil {
+		return err
+	}
+
 	// Inicialitzar serveis
-	userService := users.NewUserService(userRepo)
-	authService := auth.NewAuthService(userRepo, authMiddleware)
-	itemService := items.NewItemService(itemRepo)
+	argon2Params := password.DefaultArgon2idParams
+	argon2Params.Memory = s.cfg.PasswordArgon2Memory
+	argon2Params.Iterations = s.cfg.PasswordArgon2Iterations
+	argon2Params.Parallelism = s.cfg.PasswordArgon2Parallelism
+	passwordHasher := password.NewHasher(argon2Params)
+	passwordPolicy := password.Policy{
+		MinLength:       s.cfg.PasswordMinLength,
+		RequiredClasses: s.cfg.PasswordRequiredClasses,
+		HistorySize:     s.cfg.PasswordHistory,
+	}
+	if s.cfg.PasswordBreachedListFile != "" {
+		passwordPolicy.Breached, err = password.LoadBreachedList(s.cfg.PasswordBreachedListFile)
+		if err != nil {
+			return err
+		}
+		log.Printf("Loaded %d breached password hashes", passwordPolicy.Breached.Len())
+	}
+	revocationStore := revocation.NewStore(revocationRepo)
+	sessionService := sessions.NewSessionService(sessionRepo, revocationStore, tokenIssuer.Timeout+tokenIssuer.MaxRefresh)
+	lockoutService := lockout.NewLockoutService(lockoutRepo, actionLogMiddleware, lockout.Settings{
+		MaxUserFailures: s.cfg.LoginMaxUserFailures,
+		MaxIPFailures:   s.cfg.LoginMaxIPFailures,
+		Window:          s.cfg.LoginFailureWindow,
+		LockDuration:    s.cfg.LoginLockoutDuration,
+	})
+	userService := users.NewUserService(userRepo, roleRepo, revocationStore, users.VerificationConfig{
+		Notifier:   notifier,
+		Secret:     []byte(s.cfg.TokenSigningSecret),
+		BaseURL:    s.cfg.AppBaseURL,
+		LinkTTL:    s.cfg.EmailVerificationTTL,
+		AccountTTL: s.cfg.UnverifiedAccountTTL,
+	}, registrationMode, passwordHasher, passwordPolicy)
+	roleService := roles.NewRoleService(roleRepo)
+	mfaService := mfa.NewMFAService(userRepo, s.cfg.MFAIssuer)
+	apiKeyService := apikeys.NewAPIKeyService(apiKeyRepo, roleRepo)
+	invitationService := invitations.NewInvitationService(invitationRepo, userRepo, userService, roleRepo, notifier, s.cfg.AppBaseURL, s.cfg.InvitationTTL)
+	resetService := passwordreset.NewResetService(resetRepo, userRepo, userService, notifier, s.cfg.AppBaseURL, s.cfg.PasswordResetTTL)
+	authService := auth.NewAuthService(userRepo, roleRepo, revocationStore, lockoutService, sessionService, mfaService, []byte(s.cfg.TokenSigningSecret), tokenIssuer, passwordHasher)
+	var oidcProvider *oidc.Provider
+	if s.cfg.OIDCIssuer != "" {
+		oidcProvider = oidc.NewProvider(oidc.ProviderConfig{
+			IssuerURL:    s.cfg.OIDCIssuer,
+			ClientID:     s.cfg.OIDCClientID,
+			ClientSecret: s.cfg.OIDCClientSecret,
+			RedirectURL:  s.cfg.OIDCRedirectURL,
+			Scopes:       s.cfg.OIDCScopes,
+		}, nil)
+	}
+	oidcService := oidc.NewOIDCService(oidcProvider, oidcRepo, userRepo, userService, roleRepo, authService, oidc.ProvisionConfig{
+		Enabled:     s.cfg.OIDCAutoProvision,
+		DefaultRole: s.cfg.OIDCDefaultRole,
+	})
+	impersonationService := impersonation.NewImpersonationService(userRepo, authService, tokenIssuer, revocationStore, s.cfg.ImpersonationTTL)
+	privacyService := privacy.NewPrivacyService(privacyRepo, revocationStore)
+	categoryService := categories.NewCategoryService(categoryRepo)
+	unitService := units.NewUnitService(unitRepo)
+	barcodeService := barcodes.NewBarcodeService(barcodeRepo, itemRepo, unitService)
+	priceListService := pricelists.NewPriceListService(priceListRepo, itemRepo, unitService)
+	variantService := variants.NewVariantService(variantRepo, itemRepo, barcodeService)
+	attachmentService := attachments.NewAttachmentService(attachmentRepo, fileStorage, s.cfg.AttachmentMaxSize)
+	itemService := items.NewItemService(itemRepo, attachmentService)
 	
 	stockService := stock.NewStockService(stockRepo)
-	salesService := sales.NewSalesService(salesRepo, stockService)
-	purchaseService := purchases.NewPurchaseService(purchaseRepo, stockService)
+	salesService := sales.NewSalesService(salesRepo, stockService, unitService, barcodeService, priceListService)
+	purchaseService := purchases.NewPurchaseService(purchaseRepo, stockService, unitService, barcodeService)
 
 
 
 	// Inicialitzar handlers
 	userHandler := users.NewUserHandler(userService)
-	authHandler := auth.NewAuthHandler(authService, authMiddleware)
+	roleHandler := roles.NewRoleHandler(roleService)
+	resetHandler := passwordreset.NewResetHandler(resetService)
+	lockoutHandler := lockout.NewLockoutHandler(lockoutService)
+	mfaHandler := mfa.NewMFAHandler(mfaService)
+	apiKeyHandler := apikeys.NewAPIKeyHandler(apiKeyService)
+	invitationHandler := invitations.NewInvitationHandler(invitationService)
+	sessionHandler := sessions.NewSessionHandler(sessionService)
+	authHandler := auth.NewAuthHandler(authService, authMiddleware, tokenIssuer, sessionService)
+	jwksHandler := jwtkeys.NewJWKSHandler(jwtKeys)
+	oidcHandler := oidc.NewOIDCHandler(oidcService)
+	impersonationHandler := impersonation.NewImpersonationHandler(impersonationService)
+	privacyHandler := privacy.NewPrivacyHandler(privacyService)
 	itemHandler := items.NewItemHandler(itemService)
+	categoryHandler := categories.NewCategoryHandler(categoryService)
+	unitHandler := units.NewUnitHandler(unitService)
+	barcodeHandler := barcodes.NewBarcodeHandler(barcodeService)
+	priceListHandler := pricelists.NewPriceListHandler(priceListService)
+	variantHandler := variants.NewVariantHandler(variantService)
+	attachmentHandler := attachments.NewAttachmentHandler(attachmentService)
 	salesHandler := sales.NewSalesHandler(salesService)
 	stocksHandler := stock.NewStockHandler(stockService)
 	purchaseHandler := purchases.NewPurchasesHandler(purchaseService)
 
 	
+	// Eliminar periòdicament els comptes que no s'han verificat
+	go users.PurgeUnverifiedPeriodically(context.Background(), userService, time.Hour)
+
 	// Configurar les rutes públiques (sense autenticació)
 	public := s.router.Group("/auth")
 	//public.Use(actionLogMiddleware.LogAction())
 	users.RegisterPublicRoutes(public, userHandler)
-	auth.RegisterRoutes(public, authHandler, authMiddleware)
+	invitations.RegisterPublicRoutes(public, invitationHandler)
+	auth.RegisterRoutes(public, authHandler, authMiddleware, revocationStore)
+	passwordreset.RegisterPublicRoutes(public, resetHandler)
+	jwtkeys.RegisterPublicRoutes(public, jwksHandler)
+	oidc.RegisterPublicRoutes(public, oidcHandler)
 	public.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 
-	// Configurar les rutes protegides (amb autenticació JWT)
+	// Configurar les rutes protegides (amb autenticació JWT o clau d'API)
 	protected := s.router.Group("/api")
-	protected.Use(authMiddleware.MiddlewareFunc())
+	protected.Use(middleware.APIKeyAuth(apiKeyService, policy))
+	protected.Use(middleware.UnlessAPIKey(authMiddleware.MiddlewareFunc()))
+	protected.Use(middleware.UnlessAPIKey(middleware.RejectRevoked(revocationStore)))
+	protected.Use(middleware.UnlessAPIKey(middleware.TrackSession(sessionService)))
+	// Registre d'accions, amb l'usuari (i qui el suplanta) ja identificat
+	protected.Use(actionLogMiddleware.LogAction())
 
 	
 
 	// Registrar les rutes protegides
 	users.RegisterRoutes(protected, userHandler)
+	roles.RegisterRoutes(protected, roleHandler)
+	lockout.RegisterRoutes(protected, lockoutHandler)
+	mfa.RegisterRoutes(protected, mfaHandler)
+	apikeys.RegisterRoutes(protected, apiKeyHandler)
+	invitations.RegisterRoutes(protected, invitationHandler)
+	sessions.RegisterRoutes(protected, sessionHandler)
+	impersonation.RegisterRoutes(protected, impersonationHandler)
+	privacy.RegisterRoutes(protected, privacyHandler)
 	items.RegisterRoutes(protected, itemHandler)
+	categories.RegisterRoutes(protected, categoryHandler)
+	units.RegisterRoutes(protected, unitHandler)
+	barcodes.RegisterRoutes(protected, barcodeHandler)
+	pricelists.RegisterRoutes(protected, priceListHandler)
+	variants.RegisterRoutes(protected, variantHandler)
+	attachments.RegisterRoutes(protected, attachmentHandler)
 	sales.RegisterRoutes(protected, salesHandler)
 	stock.RegisterRoutes(protected, stocksHandler)
 	purchases.RegisterRoutes(protected, purchaseHandler)
@@ -117,6 +310,58 @@ func (s *Server) Setup() error {
 	return nil
 }
 
+// accessPolicy defineix quin permís cal per a cada grup de rutes protegides.
+// Les lectures (GET) i les escriptures tenen permisos separats; les rutes que
+// no apareixen aquí queden denegades. Els permisos de mfaPermissions només
+// es concedeixen si l'usuari ha verificat el segon factor.
+func accessPolicy(mfaPermissions []string) *middleware.AccessPolicy {
+	return middleware.NewAccessPolicy().
+		RequireMFAFor(mfaPermissions...).
+		Authenticated("/auth/logout").
+		Authenticated("/api/mfa").
+		Authenticated("/api/me").
+		Require("/api/users", roles.PermUsersManage).
+		Require("/api/roles", roles.PermRolesManage).
+		Require("/api/lockouts", roles.PermUsersManage).
+		Require("/api/api-keys", roles.PermAPIKeysManage).
+		Require("/api/invitations", roles.PermUsersManage).
+		Require("/api/impersonation", roles.PermUsersImpersonate, http.MethodPost).
+		Authenticated("/api/impersonation", http.MethodDelete).
+		Require("/api/privacy", roles.PermPrivacyManage).
+		// Els codis de barres, les variants i els adjunts penjen de /api/items
+		// perquè segueixin els permisos dels articles
+		Require("/api/items", roles.PermItemsRead, http.MethodGet).
+		Require("/api/items", roles.PermItemsWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/categories", roles.PermItemsRead, http.MethodGet).
+		Require("/api/categories", roles.PermItemsWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/units", roles.PermItemsRead, http.MethodGet).
+		Require("/api/units", roles.PermItemsWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/price-lists", roles.PermPricesRead, http.MethodGet).
+		Require("/api/price-lists", roles.PermPricesWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/customer-groups", roles.PermPricesRead, http.MethodGet).
+		Require("/api/customer-groups", roles.PermPricesWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/stock", roles.PermStockRead, http.MethodGet).
+		Require("/api/stock", roles.PermStockWrite, http.MethodPut).
+		Require("/api/sales", roles.PermSalesRead, http.MethodGet).
+		Require("/api/sales", roles.PermSalesWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/sales/headers/send", roles.PermSalesSend).
+		Require("/api/purchases", roles.PermPurchasesRead, http.MethodGet).
+		Require("/api/purchases", roles.PermPurchasesWrite, http.MethodPost, http.MethodPut, http.MethodDelete).
+		Require("/api/purchases/headers/receive", roles.PermPurchasesReceive).
+		// Qui suplanta un usuari no pot tocar credencials ni gestionar usuaris
+		DenyImpersonated("/api/me/password").
+		DenyImpersonated("/api/me", http.MethodPatch).
+		DenyImpersonated("/api/me/sessions", http.MethodDelete).
+		DenyImpersonated("/api/mfa").
+		DenyImpersonated("/api/users").
+		DenyImpersonated("/api/roles").
+		DenyImpersonated("/api/lockouts").
+		DenyImpersonated("/api/api-keys").
+		DenyImpersonated("/api/invitations").
+		DenyImpersonated("/api/privacy").
+		DenyImpersonated("/api/impersonation", http.MethodPost)
+}
+
 func (s *Server) Run() error {
 	//return s.router.RunTLS(":" + s.cfg.ApiPort, "./certs/cert.pem", "./certs/key.pem")
 	return s.router.Run(":" + s.cfg.ApiPort)